@@ -42,6 +42,10 @@ type Config struct {
 	ModuleAllowList        []string          `json:"moduleAllowList,omitzero" Description:"list of patterns that define the module allow-list. If empty all modules are allowed."`
 	ModuleVerificationKeys []string          `json:"moduleVerificationKeys,omitzero" Description:"list of public keys uses to verify modules. Allowlist takes precedence."`
 	DisableCustomReadiness bool              `json:"disableCustomReadiness" Description:"omit loading custom readiness definition from in-cluster configmaps."`
+	Replicas               int               `json:"replicas,omitzero" Description:"number of atc replicas. When greater than one, leader election is enabled and only the leader runs the controller."`
+	ShardName              string            `json:"shardName,omitzero" Description:"name of the shard, required when sharding. Must be unique across atc installations in the cluster."`
+	ShardNamespaces        []string          `json:"shardNamespaces,omitzero" Description:"restricts the atc to flights and airway instances within these namespaces. Cluster scoped resources are not owned by a shard restricted to namespaces."`
+	ShardSelector          string            `json:"shardSelector,omitzero" Description:"restricts the atc to flights, airways and airway instances matching this label selector."`
 }
 
 func Run(cfg Config) (flight.Stages, error) {
@@ -205,6 +209,30 @@ func Run(cfg Config) (flight.Stages, error) {
 		environment = append(environment, corev1.EnvVar{Name: "CONCURRENCY", Value: strconv.Itoa(cfg.Concurrency)})
 	}
 
+	if cfg.ShardName != "" {
+		environment = append(
+			environment,
+			corev1.EnvVar{Name: "SHARD_NAME", Value: cfg.ShardName},
+			corev1.EnvVar{Name: "SHARD_NAMESPACES", Value: strings.Join(cfg.ShardNamespaces, ",")},
+			corev1.EnvVar{Name: "SHARD_SELECTOR", Value: cfg.ShardSelector},
+		)
+	}
+
+	cfg.Replicas = max(cfg.Replicas, 1)
+
+	if cfg.Replicas > 1 {
+		environment = append(
+			environment,
+			corev1.EnvVar{Name: "LEADER_ELECTION", Value: "true"},
+			corev1.EnvVar{
+				Name: "POD_NAME",
+				ValueFrom: &corev1.EnvVarSource{
+					FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"},
+				},
+			},
+		)
+	}
+
 	tlsVolume := corev1.Volume{
 		Name: "tls-secrets",
 		VolumeSource: corev1.VolumeSource{
@@ -229,7 +257,7 @@ func Run(cfg Config) (flight.Stages, error) {
 			Namespace: flight.Namespace(),
 		},
 		Spec: appsv1.DeploymentSpec{
-			Replicas: new(int32(1)),
+			Replicas: new(int32(cfg.Replicas)),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: selector,
 			},
@@ -295,7 +323,13 @@ func Run(cfg Config) (flight.Stages, error) {
 					},
 				},
 			},
-			Strategy: appsv1.DeploymentStrategy{Type: "Recreate"},
+			Strategy: func() appsv1.DeploymentStrategy {
+				if cfg.Replicas > 1 {
+					// With leader election, replicas can be rolled one at a time while a leader keeps reconciling.
+					return appsv1.DeploymentStrategy{Type: appsv1.RollingUpdateDeploymentStrategyType}
+				}
+				return appsv1.DeploymentStrategy{Type: "Recreate"}
+			}(),
 		},
 	}
 
@@ -1,11 +1,15 @@
 package main
 
 import (
+	"cmp"
+	"fmt"
 	"os"
 	"runtime"
 
 	"github.com/davidmdm/conf"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/atc"
 	"github.com/yokecd/yoke/internal/xcrypto"
@@ -23,6 +27,9 @@ type Config struct {
 
 	Service atc.ServiceDef
 
+	// Shard restricts the ATC to a subset of Flights and Airway instances so that multiple ATCs can share a cluster.
+	Shard atc.Shard
+
 	DockerConfigSecretName string
 
 	Verbose bool
@@ -30,6 +37,17 @@ type Config struct {
 	TLS TLSConfig
 
 	DisableCustomReadiness bool
+
+	LeaderElection LeaderElectionConfig
+}
+
+type LeaderElectionConfig struct {
+	// Enabled runs the controller only on the replica holding the lease. Required when running more than one replica.
+	Enabled bool
+	// Lease is the name of the Lease in the ATC's namespace. Defaults to "<service name>-leader".
+	Lease string
+	// Identity of this replica in the lease. Must be the pod name, as followers use it to reach the leader. Defaults to the hostname.
+	Identity string
 }
 
 type File struct {
@@ -56,6 +74,14 @@ func LoadConfig() (*Config, error) {
 	conf.Var(parser, &cfg.ModuleAllowList, "MODULE_ALLOW_LIST")
 	conf.Var(parser, &cfg.DisableCustomReadiness, "DISABLE_CUSTOM_READINESS")
 	conf.Var(parser, &cfg.DockerConfigSecretName, "DOCKER_CONFIG_SECRET_NAME")
+	conf.Var(parser, &cfg.LeaderElection.Enabled, "LEADER_ELECTION")
+	conf.Var(parser, &cfg.LeaderElection.Lease, "LEADER_ELECTION_LEASE")
+	conf.Var(parser, &cfg.LeaderElection.Identity, "POD_NAME")
+	conf.Var(parser, &cfg.Shard.Name, "SHARD_NAME")
+	conf.Var(parser, &cfg.Shard.Namespaces, "SHARD_NAMESPACES")
+
+	var shardSelector string
+	conf.Var(parser, &shardSelector, "SHARD_SELECTOR")
 
 	conf.Var(parser, &cfg.TLS.CA.Path, "TLS_CA_CERT", conf.RequiredNonEmpty[string]())
 	conf.Var(parser, &cfg.TLS.ServerCert.Path, "TLS_SERVER_CERT", conf.RequiredNonEmpty[string]())
@@ -89,5 +115,26 @@ func LoadConfig() (*Config, error) {
 	cfg.Service.CABundle = cfg.TLS.CA.Data
 	cfg.Concurrency = max(cfg.Concurrency, 1)
 
+	if shardSelector != "" {
+		selector, err := metav1.ParseToLabelSelector(shardSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid shard selector: %w", err)
+		}
+		cfg.Shard.Selector = selector
+	}
+
+	if cfg.Shard.Name == "" && (len(cfg.Shard.Namespaces) > 0 || cfg.Shard.Selector != nil) {
+		return nil, fmt.Errorf("shard name is required when sharding by namespaces or selector")
+	}
+
+	cfg.LeaderElection.Lease = cmp.Or(cfg.LeaderElection.Lease, cfg.Service.Name+"-leader")
+	if cfg.LeaderElection.Identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get hostname for leader election identity: %w", err)
+		}
+		cfg.LeaderElection.Identity = hostname
+	}
+
 	return &cfg, nil
 }
@@ -23,6 +23,7 @@ import (
 
 	"github.com/yokecd/yoke/cmd/atc-installer/installer"
 	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/atc"
 	"github.com/yokecd/yoke/internal/home"
 	"github.com/yokecd/yoke/internal/k8s"
 	"github.com/yokecd/yoke/internal/testutils"
@@ -124,6 +125,31 @@ func TestFlightInstance(t *testing.T) {
 		"flight resources were not updated as expected",
 	)
 
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			events, err := client.Clientset.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{
+				FieldSelector: "involvedObject.kind=Flight,involvedObject.name=" + flight.Name,
+			})
+			if err != nil {
+				return err
+			}
+			reasons := map[string]bool{}
+			for _, event := range events.Items {
+				reasons[event.Reason] = true
+			}
+			for _, reason := range []string{atc.ReasonTakeoffStarted, atc.ReasonTakeoffSucceeded, atc.ReasonReady} {
+				if !reasons[reason] {
+					return fmt.Errorf("expected event with reason %s but got: %v", reason, reasons)
+				}
+			}
+			return nil
+		},
+		time.Second,
+		10*time.Second,
+		"flight events were not emitted as expected",
+	)
+
 	require.NoError(t, flightIntf.Delete(context.Background(), flight.Name, metav1.DeleteOptions{}))
 
 	testutils.EventuallyNoErrorf(
@@ -464,3 +490,235 @@ func TestFlightCodeSigning(t *testing.T) {
 		Poll:          time.Second,
 	}))
 }
+
+func TestFlightModes(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	flightIntf := k8s.TypedInterface[v1alpha1.Flight](client, v1alpha1.FlightGVR()).Namespace("default")
+
+	flight, err := flightIntf.Create(
+		context.Background(),
+		&v1alpha1.Flight{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "modes",
+			},
+			Spec: v1alpha1.FlightSpec{
+				WasmURL:  "oci://registry:80/basic.wasm",
+				Input:    `{"hello":"world"}`,
+				Insecure: true,
+				Mode:     v1alpha1.AirwayModeStatic,
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, flightIntf.Delete(context.Background(), flight.Name, metav1.DeleteOptions{}))
+		testutils.EventuallyNoErrorf(
+			t,
+			func() error {
+				if _, err := flightIntf.Get(context.Background(), flight.Name, metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+					return fmt.Errorf("expected flight not to be found but got error: %w", err)
+				}
+				return nil
+			},
+			time.Second,
+			10*time.Second,
+			"flight did not delete as expected",
+		)
+	}()
+
+	cmIntf := client.Clientset.CoreV1().ConfigMaps("default")
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			_, err := cmIntf.Get(context.Background(), flight.Name, metav1.GetOptions{})
+			return err
+		},
+		time.Second,
+		10*time.Second,
+		"flight resources were not created as expected",
+	)
+
+	setHello := func(value string) error {
+		return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			cm, err := cmIntf.Get(context.Background(), flight.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			cm.Data["hello"] = value
+			_, err = cmIntf.Update(context.Background(), cm, metav1.UpdateOptions{})
+			return err
+		})
+	}
+
+	require.ErrorContains(t, setHello("static"), "cannot modify flight sub-resources")
+
+	require.NoError(t, retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		flight, err = flightIntf.Get(context.Background(), flight.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		flight.Spec.Mode = v1alpha1.AirwayModeDynamic
+		flight, err = flightIntf.Update(context.Background(), flight, metav1.UpdateOptions{})
+		return err
+	}))
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			if err := setHello("dynamic"); err != nil {
+				return err
+			}
+			time.Sleep(time.Second)
+			cm, err := cmIntf.Get(context.Background(), flight.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if hello := cm.Data["hello"]; hello != "world" {
+				return fmt.Errorf("expected dynamic flight to revert hello to world but got: %s", hello)
+			}
+			return nil
+		},
+		time.Second,
+		30*time.Second,
+		"dynamic flight did not revert changes to its resources",
+	)
+}
+
+func TestFlightSuspend(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	flightIntf := k8s.TypedInterface[v1alpha1.Flight](client, v1alpha1.FlightGVR()).Namespace("default")
+	cmIntf := client.Clientset.CoreV1().ConfigMaps("default")
+
+	toJSONString := func(t *testing.T, value any) string {
+		var buffer bytes.Buffer
+		require.NoError(t, json.NewEncoder(&buffer).Encode(value))
+		return buffer.String()
+	}
+
+	updateFlight := func(t *testing.T, name string, mutate func(*v1alpha1.Flight)) {
+		require.NoError(t, retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			flight, err := flightIntf.Get(context.Background(), name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			mutate(flight)
+			_, err = flightIntf.Update(context.Background(), flight, metav1.UpdateOptions{})
+			return err
+		}))
+	}
+
+	expectData := func(t *testing.T, name, value string) {
+		testutils.EventuallyNoErrorf(
+			t,
+			func() error {
+				cm, err := cmIntf.Get(context.Background(), name, metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get expected configmap: %w", err)
+				}
+				if hello := cm.Data["hello"]; hello != value {
+					return fmt.Errorf("expected configmap data hello to be %s but got: %s", value, hello)
+				}
+				return nil
+			},
+			time.Second,
+			10*time.Second,
+			"flight resources were not taken off as expected",
+		)
+	}
+
+	flight, err := flightIntf.Create(
+		context.Background(),
+		&v1alpha1.Flight{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       v1alpha1.KindFlight,
+				APIVersion: v1alpha1.AirwayGVR().GroupVersion().Identifier(),
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "suspended",
+			},
+			Spec: v1alpha1.FlightSpec{
+				WasmURL:  "oci://registry:80/basic.wasm",
+				Input:    toJSONString(t, map[string]string{"hello": "world"}),
+				Insecure: true,
+				Suspend:  true,
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, flightIntf.Delete(context.Background(), flight.Name, metav1.DeleteOptions{}))
+	}()
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			events, err := client.Clientset.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{
+				FieldSelector: "involvedObject.kind=Flight,involvedObject.name=" + flight.Name + ",reason=" + atc.ReasonSuspended,
+			})
+			if err != nil {
+				return err
+			}
+			if len(events.Items) == 0 {
+				return fmt.Errorf("expected suspended event")
+			}
+			return nil
+		},
+		time.Second,
+		10*time.Second,
+		"flight suspension was not reported",
+	)
+
+	_, err = cmIntf.Get(context.Background(), flight.Name, metav1.GetOptions{})
+	require.True(t, kerrors.IsNotFound(err), "expected suspended flight not to be taken off but got: %v", err)
+
+	updateFlight(t, flight.Name, func(flight *v1alpha1.Flight) { flight.Spec.Suspend = false })
+
+	expectData(t, flight.Name, "world")
+
+	updateFlight(t, flight.Name, func(flight *v1alpha1.Flight) {
+		flight.Annotations = map[string]string{v1alpha1.AnnotationSuspend: "true"}
+		flight.Spec.Input = toJSONString(t, map[string]string{"hello": "suspended"})
+	})
+
+	time.Sleep(5 * time.Second)
+
+	cm, err := cmIntf.Get(context.Background(), flight.Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "world", cm.Data["hello"], "expected suspended flight not to be taken off")
+
+	updateFlight(t, flight.Name, func(flight *v1alpha1.Flight) { delete(flight.Annotations, v1alpha1.AnnotationSuspend) })
+
+	expectData(t, flight.Name, "suspended")
+
+	updateFlight(t, flight.Name, func(flight *v1alpha1.Flight) {
+		flight.Annotations = map[string]string{v1alpha1.AnnotationReconcileRequestedAt: time.Now().Format(time.RFC3339Nano)}
+	})
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			events, err := client.Clientset.CoreV1().Events("default").List(context.Background(), metav1.ListOptions{
+				FieldSelector: "involvedObject.kind=Flight,involvedObject.name=" + flight.Name + ",reason=" + atc.ReasonReconcileRequested,
+			})
+			if err != nil {
+				return err
+			}
+			if len(events.Items) == 0 {
+				return fmt.Errorf("expected reconcile requested event")
+			}
+			return nil
+		},
+		time.Second,
+		10*time.Second,
+		"reconcile request was not acknowledged",
+	)
+}
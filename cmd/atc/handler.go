@@ -9,21 +9,25 @@ import (
 	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/davidmdm/x/xerr"
 	"github.com/davidmdm/x/xsync"
 
 	admissionv1 "k8s.io/api/admission/v1"
 	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/atc"
 	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/metrics"
 	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/wasi/cache"
 	"github.com/yokecd/yoke/internal/wasi/host"
@@ -36,10 +40,12 @@ import (
 
 type HandlerParams struct {
 	Controller   *ctrl.Instance
+	Leadership   *Leadership
 	FlightStates *xsync.Map[string, atc.InstanceState]
 	Client       *k8s.Client
 	Cache        *cache.ModuleCache
 	Dispatcher   *atc.EventDispatcher
+	Recorder     record.EventRecorder
 	Logger       *slog.Logger
 	Filter       xhttp.LogFilterFunc
 }
@@ -59,6 +65,8 @@ func Handler(params HandlerParams) http.Handler {
 
 	mux.HandleFunc("GET /memstats", xhttp.MemStatHandler)
 
+	mux.Handle("GET /metrics", metrics.Handler())
+
 	mux.HandleFunc("POST /crdconvert/{airway}", func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
@@ -160,6 +168,8 @@ func Handler(params HandlerParams) http.Handler {
 			return
 		}
 
+		defer observeAdmission(r, &review, time.Now())
+
 		var cr unstructured.Unstructured
 		if err := json.Unmarshal(review.Request.Object.Raw, &cr); err != nil {
 			http.Error(w, fmt.Sprintf("failed to decode resource: %v", err), http.StatusBadRequest)
@@ -186,6 +196,16 @@ func Handler(params HandlerParams) http.Handler {
 					"msg", review.Response.Result.Message,
 				),
 			)
+			if !review.Response.Allowed && params.Recorder != nil {
+				params.Recorder.Eventf(
+					atc.EventRef(cr.GetAPIVersion(), cr.GetKind(), &cr),
+					corev1.EventTypeWarning,
+					atc.ReasonAdmissionDenied,
+					"%s denied: %s",
+					review.Request.Operation,
+					review.Response.Result.Message,
+				)
+			}
 			if err := json.NewEncoder(w).Encode(review); err != nil {
 				params.Logger.Error("failed to write response to connection", "error", err)
 			}
@@ -257,12 +277,82 @@ func Handler(params HandlerParams) http.Handler {
 			return
 		}
 
+		if validator := airway.Spec.WasmURLs.Validator; validator != "" {
+			mod, err := params.Cache.FromURL(
+				r.Context(),
+				cache.FromURLParams{
+					URL:      validator,
+					Checksum: airway.Spec.WasmURLs.ValidatorChecksum,
+					Insecure: airway.Spec.Insecure,
+					Attrs: cache.ModuleAttrs{
+						MaxMemoryMib:    airway.Spec.MaxMemoryMib,
+						HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(params.Client)),
+						Metered:         airway.Spec.MaxFunctionCalls > 0,
+					},
+				},
+			)
+			if err != nil {
+				failReview(&review, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Reason:  metav1.StatusReasonInternalError,
+					Message: fmt.Sprintf("failed to get validator module from cache: %v", err),
+				})
+				return
+			}
+
+			output, err := wasi.Execute(r.Context(), wasi.ExecParams{
+				Module:           mod,
+				Stdin:            bytes.NewReader(review.Request.Object.Raw),
+				BinName:          "validator",
+				Timeout:          airway.Spec.Timeout.Duration,
+				MaxFunctionCalls: airway.Spec.MaxFunctionCalls,
+			})
+			if err != nil {
+				failReview(&review, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Reason:  metav1.StatusReasonInternalError,
+					Message: fmt.Sprintf("failed to execute validator: %v", err),
+				})
+				return
+			}
+
+			var validation v1alpha1.Validation
+			if err := json.Unmarshal(output, &validation); err != nil {
+				failReview(&review, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Reason:  metav1.StatusReasonInternalError,
+					Message: fmt.Sprintf("failed to parse validator output: %v", err),
+				})
+				return
+			}
+
+			xhttp.AddRequestAttrs(r.Context(), slog.Group("validator", "allowed", validation.Allowed, "errors", len(validation.Errors)))
+
+			if !validation.Allowed {
+				failReview(&review, validationStatus(&cr, validation))
+				return
+			}
+		}
+
 		if airway.Spec.SkipAdmissionWebhook {
 			review.Response.Result.Message = "admission skipped"
 			xhttp.AddRequestAttrs(r.Context(), slog.Bool("skipped", true))
 			return
 		}
 
+		// Suspended instances are not taken off, and reconcile requests must go through even if the instance is currently failing.
+		if airway.Spec.Suspend || v1alpha1.IsSuspended(&cr) {
+			review.Response.Result.Message = "admission skipped: instance is suspended"
+			xhttp.AddRequestAttrs(r.Context(), slog.Bool("skipped", true))
+			return
+		}
+
+		if prev, err := UnstructuredFromRawExt(review.Request.OldObject); err == nil && onlyRequestsReconcile(prev, &cr) {
+			review.Response.Result.Message = "admission skipped: reconcile requested"
+			xhttp.AddRequestAttrs(r.Context(), slog.Bool("skipped", true))
+			return
+		}
+
 		object, _, err := unstructured.NestedFieldNoCopy(cr.Object, airway.Spec.ObjectPath...)
 		if err != nil {
 			failReview(&review, metav1.Status{
@@ -292,12 +382,17 @@ func Handler(params HandlerParams) http.Handler {
 				Enabled:          airway.Spec.ClusterAccess,
 				ResourceMatchers: airway.Spec.ResourceAccessMatchers,
 			},
+			HTTPAccess: host.HTTPAccessParams{
+				URLMatchers: airway.Spec.HTTPAccessMatchers,
+			},
 			Flight: yoke.FlightParams{
-				Path:         airway.Spec.WasmURLs.Flight,
-				Insecure:     airway.Spec.Insecure,
-				Input:        bytes.NewReader(data),
-				MaxMemoryMib: uint64(airway.Spec.MaxMemoryMib),
-				Timeout:      airway.Spec.Timeout.Duration,
+				Path:             airway.Spec.WasmURLs.Flight,
+				Insecure:         airway.Spec.Insecure,
+				Input:            bytes.NewReader(data),
+				MaxMemoryMib:     uint64(airway.Spec.MaxMemoryMib),
+				Timeout:          airway.Spec.Timeout.Duration,
+				MaxFunctionCalls: airway.Spec.MaxFunctionCalls,
+				Deterministic:    airway.Spec.Deterministic,
 			},
 			DryRun:         true,
 			ForceOwnership: true,
@@ -332,15 +427,24 @@ func Handler(params HandlerParams) http.Handler {
 			}
 			takeoffParams.Flight.Path = overrideURL
 		} else {
+			module := flight.Module{URL: airway.Spec.WasmURLs.Flight, Checksum: airway.Spec.WasmURLs.FlightChecksum}
+			if current := atc.InstanceModule(&cr); airway.Spec.Rollout != nil && current.URL != "" {
+				// Instances are upgraded to a new flight as allowed by the rollout policy of the airway.
+				// Until then they are validated against the flight they run.
+				module = current
+			}
+			takeoffParams.Checksum = module.Checksum
+
 			flightMod, err := params.Cache.FromURL(
 				r.Context(),
 				cache.FromURLParams{
-					URL:      airway.Spec.WasmURLs.Flight,
-					Checksum: airway.Spec.WasmURLs.FlightChecksum,
+					URL:      module.URL,
+					Checksum: module.Checksum,
 					Insecure: airway.Spec.Insecure,
 					Attrs: cache.ModuleAttrs{
 						MaxMemoryMib:    airway.Spec.MaxMemoryMib,
-						HostFunctionMap: host.BuildFunctionMap(params.Client),
+						HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(params.Client)),
+						Metered:         airway.Spec.MaxFunctionCalls > 0,
 					},
 				},
 			)
@@ -351,7 +455,7 @@ func Handler(params HandlerParams) http.Handler {
 			takeoffParams.Flight.Module = yoke.Module{
 				Instance: flightMod,
 				SourceMetadata: internal.Source{
-					Ref:      airway.Spec.WasmURLs.Flight,
+					Ref:      module.URL,
 					Checksum: flightMod.Checksum(),
 				},
 			}
@@ -368,13 +472,133 @@ func Handler(params HandlerParams) http.Handler {
 		}
 	})
 
-	mux.HandleFunc("POST /validations/resources", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST /mutations/{airway}", func(w http.ResponseWriter, r *http.Request) {
+		var review admissionv1.AdmissionReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, fmt.Sprintf("failed to decode review: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		defer observeAdmission(r, &review, time.Now())
+
+		object := review.Request.Object.Raw
+
+		review.Response = &admissionv1.AdmissionResponse{
+			UID:     review.Request.UID,
+			Allowed: true,
+			Result: &metav1.Status{
+				Status:  metav1.StatusSuccess,
+				Message: "defaults applied",
+			},
+		}
+		review.Request = nil
+
+		defer func() {
+			xhttp.AddRequestAttrs(
+				r.Context(),
+				slog.Group(
+					"mutation",
+					"allowed", review.Response.Allowed,
+					"reason", review.Response.Result.Reason,
+					"msg", review.Response.Result.Message,
+					"patched", len(review.Response.Patch) > 0,
+				),
+			)
+			if err := json.NewEncoder(w).Encode(review); err != nil {
+				params.Logger.Error("failed to write response to connection", "error", err)
+			}
+		}()
+
+		airway, err := params.Client.AirwayIntf().Get(r.Context(), r.PathValue("airway"), metav1.GetOptions{})
+		if err != nil {
+			failReview(&review, metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInternalError,
+				Message: fmt.Sprintf("failed to get airway: %v", err),
+			})
+			return
+		}
+
+		if airway.Spec.WasmURLs.Defaulter == "" {
+			// The mutation webhook is removed by the airway reconciler when the defaulter is unset.
+			review.Response.Result.Message = "no defaulter"
+			return
+		}
+
+		mod, err := params.Cache.FromURL(
+			r.Context(),
+			cache.FromURLParams{
+				URL:      airway.Spec.WasmURLs.Defaulter,
+				Checksum: airway.Spec.WasmURLs.DefaulterChecksum,
+				Insecure: airway.Spec.Insecure,
+				Attrs: cache.ModuleAttrs{
+					MaxMemoryMib:    airway.Spec.MaxMemoryMib,
+					HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(params.Client)),
+					Metered:         airway.Spec.MaxFunctionCalls > 0,
+				},
+			},
+		)
+		if err != nil {
+			failReview(&review, metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInternalError,
+				Message: fmt.Sprintf("failed to get defaulter module from cache: %v", err),
+			})
+			return
+		}
+
+		output, err := wasi.Execute(r.Context(), wasi.ExecParams{
+			Module:           mod,
+			Stdin:            bytes.NewReader(object),
+			BinName:          "defaulter",
+			Timeout:          airway.Spec.Timeout.Duration,
+			MaxFunctionCalls: airway.Spec.MaxFunctionCalls,
+		})
+		if err != nil {
+			failReview(&review, metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInternalError,
+				Message: fmt.Sprintf("failed to execute defaulter: %v", err),
+			})
+			return
+		}
+
+		patch := bytes.TrimSpace(output)
+		if len(patch) == 0 {
+			review.Response.Result.Message = "no defaults"
+			return
+		}
+
+		var operations []map[string]any
+		if err := json.Unmarshal(patch, &operations); err != nil {
+			failReview(&review, metav1.Status{
+				Status:  metav1.StatusFailure,
+				Reason:  metav1.StatusReasonInternalError,
+				Message: fmt.Sprintf("defaulter did not return a json patch: %v", err),
+			})
+			return
+		}
+
+		if len(operations) == 0 {
+			review.Response.Result.Message = "no defaults"
+			return
+		}
+
+		patchType := admissionv1.PatchTypeJSONPatch
+
+		review.Response.Patch = patch
+		review.Response.PatchType = &patchType
+	})
+
+	mux.HandleFunc("POST /validations/resources", params.Leadership.Forward(func(w http.ResponseWriter, r *http.Request) {
 		var review admissionv1.AdmissionReview
 		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
+		defer observeAdmission(r, &review, time.Now())
+
 		xhttp.AddRequestAttrs(r.Context(), slog.String("user", review.Request.UserInfo.Username))
 		xhttp.AddRequestAttrs(r.Context(), slog.String("operation", string(review.Request.Operation)))
 
@@ -459,6 +683,11 @@ func Handler(params HandlerParams) http.Handler {
 
 		flight, ok := params.FlightStates.Load(instanceRef)
 		if !ok {
+			// When sharded, resources of every shard in the namespace are routed to this webhook.
+			if !params.Controller.InScope(ctrl.Event{Name: instanceName, Namespace: instanceNS, GroupKind: schema.ParseGroupKind(instanceGK)}) {
+				xhttp.AddRequestAttrs(r.Context(), slog.String("skipReason", "instance not in controller scope"))
+				return
+			}
 			xhttp.AddRequestAttrs(r.Context(), slog.String("skipReason", "no flight state"), slog.String("ERROR", "unexpected: no flight state associated to resource"))
 			return
 		}
@@ -490,6 +719,11 @@ func Handler(params HandlerParams) http.Handler {
 
 		xhttp.AddRequestAttrs(r.Context(), slog.String("airwayMode", string(flight.Mode)))
 
+		if flight.Suspended {
+			xhttp.AddRequestAttrs(r.Context(), slog.String("skipReason", "instance is suspended"))
+			return
+		}
+
 		switch flight.Mode {
 		case v1alpha1.AirwayModeStatic:
 			if next == nil || !next.GetDeletionTimestamp().IsZero() {
@@ -568,15 +802,17 @@ func Handler(params HandlerParams) http.Handler {
 		default:
 			return
 		}
-	})
+	}))
 
-	mux.HandleFunc("POST /validations/external-resources", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("POST /validations/external-resources", params.Leadership.Forward(func(w http.ResponseWriter, r *http.Request) {
 		var review admissionv1.AdmissionReview
 		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
+		defer observeAdmission(r, &review, time.Now())
+
 		prev, err := UnstructuredFromRawExt(review.Request.OldObject)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -589,14 +825,10 @@ func Handler(params HandlerParams) http.Handler {
 			return
 		}
 
-		resource := func() string {
-			if next != nil {
-				return internal.ResourceRef(next)
-			}
-			return internal.ResourceRef(prev)
-		}()
+		object := cmp.Or(next, prev)
+		resource := internal.ResourceRef(object)
 
-		dispatches := params.Dispatcher.Dispatch(resource)
+		dispatches := params.Dispatcher.Dispatch(object)
 
 		for _, evt := range dispatches {
 			params.Logger.Info(
@@ -627,7 +859,7 @@ func Handler(params HandlerParams) http.Handler {
 		if err := json.NewEncoder(w).Encode(review); err != nil {
 			params.Logger.Error("unexpected: failed to write response to connection", "error", err)
 		}
-	})
+	}))
 
 	mux.HandleFunc("POST /validations/airways.yoke.cd", func(w http.ResponseWriter, r *http.Request) {
 		var review admissionv1.AdmissionReview
@@ -636,6 +868,8 @@ func Handler(params HandlerParams) http.Handler {
 			return
 		}
 
+		defer observeAdmission(r, &review, time.Now())
+
 		var airway v1alpha1.Airway
 		if err := json.Unmarshal(review.Request.Object.Raw, &airway); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -669,7 +903,8 @@ func Handler(params HandlerParams) http.Handler {
 			Insecure: airway.Spec.Insecure,
 			Attrs: cache.ModuleAttrs{
 				MaxMemoryMib:    airway.Spec.MaxMemoryMib,
-				HostFunctionMap: host.BuildFunctionMap(params.Client),
+				HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(params.Client)),
+				Metered:         airway.Spec.MaxFunctionCalls > 0,
 			},
 		}); err != nil {
 			failReview(&review, metav1.Status{
@@ -695,6 +930,31 @@ func Handler(params HandlerParams) http.Handler {
 			}
 		}
 
+		for _, module := range []struct {
+			Name     string
+			URL      string
+			Checksum string
+		}{
+			{"validator", airway.Spec.WasmURLs.Validator, airway.Spec.WasmURLs.ValidatorChecksum},
+			{"defaulter", airway.Spec.WasmURLs.Defaulter, airway.Spec.WasmURLs.DefaulterChecksum},
+		} {
+			if module.URL == "" {
+				continue
+			}
+			if _, err := params.Cache.FromURL(r.Context(), cache.FromURLParams{
+				URL:      module.URL,
+				Checksum: module.Checksum,
+				Insecure: airway.Spec.Insecure,
+			}); err != nil {
+				failReview(&review, metav1.Status{
+					Status:  metav1.StatusFailure,
+					Message: fmt.Sprintf("failed to validate %s url %q: %v", module.Name, module.URL, err),
+					Reason:  metav1.StatusReasonInvalid,
+				})
+				return
+			}
+		}
+
 		crd, err := internal.ToUnstructured(airway.CRD())
 		if err != nil {
 			failReview(&review, metav1.Status{
@@ -722,6 +982,8 @@ func Handler(params HandlerParams) http.Handler {
 			return
 		}
 
+		defer observeAdmission(r, &review, time.Now())
+
 		// The AltFlight is of the same type as v1alpha1.Flight or v1alpha1.ClusterFlight and is declared here to drop
 		// convenince json marhsalling methods as we need to be able to distinguish between each type.
 		type AltFlight v1alpha1.Flight
@@ -732,6 +994,18 @@ func Handler(params HandlerParams) http.Handler {
 			return
 		}
 
+		prev, err := UnstructuredFromRawExt(review.Request.OldObject)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		next, err := UnstructuredFromRawExt(review.Request.Object)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
 		review.Response = &admissionv1.AdmissionResponse{
 			UID:     review.Request.UID,
 			Allowed: true,
@@ -761,6 +1035,16 @@ func Handler(params HandlerParams) http.Handler {
 			}
 		}()
 
+		if flight.Spec.Suspend || v1alpha1.IsSuspended(&flight.ObjectMeta) {
+			review.Response.Result.Message = "dryrun skipped: flight is suspended"
+			return
+		}
+
+		if onlyRequestsReconcile(prev, next) {
+			review.Response.Result.Message = "dryrun skipped: reconcile requested"
+			return
+		}
+
 		mod, err := params.Cache.FromURL(
 			r.Context(),
 			cache.FromURLParams{
@@ -769,7 +1053,8 @@ func Handler(params HandlerParams) http.Handler {
 				Insecure: flight.Spec.Insecure,
 				Attrs: cache.ModuleAttrs{
 					MaxMemoryMib:    flight.Spec.MaxMemoryMib,
-					HostFunctionMap: host.BuildFunctionMap(params.Client),
+					HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(params.Client)),
+					Metered:         flight.Spec.MaxFunctionCalls > 0,
 				},
 			},
 		)
@@ -810,9 +1095,11 @@ func Handler(params HandlerParams) http.Handler {
 							Checksum: mod.Checksum(),
 						},
 					},
-					Input:   v1alpha1.FlightInputStream(flight.Spec),
-					Args:    flight.Spec.Args,
-					Timeout: flight.Spec.Timeout.Duration,
+					Input:            v1alpha1.FlightInputStream(flight.Spec),
+					Args:             flight.Spec.Args,
+					Timeout:          flight.Spec.Timeout.Duration,
+					MaxFunctionCalls: flight.Spec.MaxFunctionCalls,
+					Deterministic:    flight.Spec.Deterministic,
 				},
 				DryRun:         true,
 				ForceConflicts: true,
@@ -822,6 +1109,9 @@ func Handler(params HandlerParams) http.Handler {
 					Enabled:          flight.Spec.ClusterAccess,
 					ResourceMatchers: flight.Spec.ResourceAccessMatchers,
 				},
+				HTTPAccess: yoke.HTTPAccessParams{
+					URLMatchers: flight.Spec.HTTPAccessMatchers,
+				},
 				ManagedBy: "atc.yoke",
 			},
 		); err != nil {
@@ -835,11 +1125,23 @@ func Handler(params HandlerParams) http.Handler {
 	})
 
 	handler := xhttp.WithRecover(mux)
+	handler = xhttp.WithTracing(handler)
 	handler = xhttp.WithLogger(params.Logger, handler, params.Filter)
 
 	return handler
 }
 
+// observeAdmission records the latency and outcome of an admission review.
+// The webhook label is the final segment of the validation path, for example an airway name or "flights.yoke.cd",
+// and is prefixed by "mutations/" for mutation webhooks.
+func observeAdmission(r *http.Request, review *admissionv1.AdmissionReview, start time.Time) {
+	webhook := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/"), "validations/")
+	metrics.AdmissionDuration.WithLabelValues(webhook).Observe(time.Since(start).Seconds())
+	if review.Response != nil && !review.Response.Allowed {
+		metrics.AdmissionDenials.WithLabelValues(webhook).Inc()
+	}
+}
+
 func UnstructuredFromRawExt(ext runtime.RawExtension) (*unstructured.Unstructured, error) {
 	if len(ext.Raw) == 0 {
 		return nil, nil
@@ -853,6 +1155,61 @@ func UnstructuredFromRawExt(ext runtime.RawExtension) (*unstructured.Unstructure
 	return &resource, nil
 }
 
+// validationStatus returns the status of the admission review of the resource denied by the validator of its airway.
+// Field errors are reported both as causes and in the message, since clients such as kubectl only display the latter.
+func validationStatus(resource *unstructured.Unstructured, validation v1alpha1.Validation) metav1.Status {
+	status := metav1.Status{
+		Status:  metav1.StatusFailure,
+		Reason:  metav1.StatusReasonInvalid,
+		Message: cmp.Or(validation.Message, "denied by validator"),
+		Details: &metav1.StatusDetails{
+			Name:  resource.GetName(),
+			Group: resource.GroupVersionKind().Group,
+			Kind:  resource.GetKind(),
+		},
+	}
+
+	var fields []string
+	for _, fieldErr := range validation.Errors {
+		status.Details.Causes = append(status.Details.Causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   fieldErr.Field,
+			Message: fieldErr.Message,
+		})
+		fields = append(fields, fmt.Sprintf("%s: %s", fieldErr.Field, fieldErr.Message))
+	}
+
+	if len(fields) > 0 {
+		status.Message += ": " + strings.Join(fields, ", ")
+	}
+
+	return status
+}
+
+// onlyRequestsReconcile reports whether the update from prev to next only changes the v1alpha1.AnnotationReconcileRequestedAt annotation.
+// Such updates do not change the desired state of the resource and need not be validated by a dry-run.
+func onlyRequestsReconcile(prev, next *unstructured.Unstructured) bool {
+	if prev == nil || next == nil {
+		return false
+	}
+	if prev.GetAnnotations()[v1alpha1.AnnotationReconcileRequestedAt] == next.GetAnnotations()[v1alpha1.AnnotationReconcileRequestedAt] {
+		return false
+	}
+
+	withoutRequest := func(resource *unstructured.Unstructured) *unstructured.Unstructured {
+		resource = resource.DeepCopy()
+		annotations := resource.GetAnnotations()
+		delete(annotations, v1alpha1.AnnotationReconcileRequestedAt)
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		resource.SetAnnotations(annotations)
+		return resource
+	}
+
+	return internal.ResourcesAreEqual(withoutRequest(prev), withoutRequest(next))
+}
+
 func failReview(review *admissionv1.AdmissionReview, status metav1.Status) {
 	review.Response.Allowed = false
 	review.Response.Result = &status
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	v1 "github.com/yokecd/yoke/cmd/atc/internal/testing/apis/backend/v1"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var backend v1.Backend
+	if err := json.NewDecoder(os.Stdin).Decode(&backend); err != nil {
+		return fmt.Errorf("failed to decode backend: %w", err)
+	}
+
+	patch := []map[string]any{}
+
+	if backend.Spec.Replicas == 0 {
+		patch = append(patch, map[string]any{"op": "add", "path": "/spec/replicas", "value": 2})
+	}
+	if backend.Spec.HealthCheck == "" {
+		patch = append(patch, map[string]any{"op": "add", "path": "/spec/healthcheck", "value": "/health"})
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(patch)
+}
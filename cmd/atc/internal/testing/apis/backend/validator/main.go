@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	v1 "github.com/yokecd/yoke/cmd/atc/internal/testing/apis/backend/v1"
+	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var backend v1.Backend
+	if err := json.NewDecoder(os.Stdin).Decode(&backend); err != nil {
+		return fmt.Errorf("failed to decode backend: %w", err)
+	}
+
+	var errs []v1alpha1.FieldError
+	if backend.Spec.Image == "" {
+		errs = append(errs, v1alpha1.FieldError{Field: "spec.image", Message: "image is required"})
+	}
+	if backend.Spec.Replicas < 0 {
+		errs = append(errs, v1alpha1.FieldError{Field: "spec.replicas", Message: "replicas cannot be negative"})
+	}
+
+	validation := v1alpha1.Validation{Allowed: len(errs) == 0, Errors: errs}
+	if !validation.Allowed {
+		validation.Message = "invalid backend"
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(validation)
+}
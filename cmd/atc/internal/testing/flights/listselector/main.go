@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/yaml"
+
+	"github.com/yokecd/yoke/pkg/flight"
+	"github.com/yokecd/yoke/pkg/flight/wasi/k8s"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+type Spec struct {
+	Selector string `json:"selector"`
+	Target   string `json:"target"`
+}
+
+type Status struct {
+	Count int `json:"count"`
+}
+
+type MergeJob struct {
+	metav1.TypeMeta
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              Spec   `json:"spec"`
+	Status            Status `json:"status"`
+}
+
+// This program aims to test that listing resources works and that new resources matching the list requeue the instance.
+// This program merges the data of every configmap matching the selector into the target configmap.
+func run() error {
+	var job MergeJob
+	if err := yaml.NewYAMLToJSONDecoder(os.Stdin).Decode(&job); err != nil {
+		return fmt.Errorf("failed to decode stdin into cr: %w", err)
+	}
+
+	sources, err := k8s.List[corev1.ConfigMap](k8s.ListOptions{
+		Namespace:     "default",
+		Kind:          "ConfigMap",
+		ApiVersion:    "v1",
+		LabelSelector: job.Spec.Selector,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list source configmaps: %w", err)
+	}
+
+	data := map[string]string{}
+	for _, source := range sources {
+		maps.Copy(data, source.Data)
+	}
+
+	job.Status.Count = len(sources)
+
+	return json.NewEncoder(os.Stdout).Encode(flight.Resources{
+		&job,
+		&corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      job.Spec.Target,
+				Namespace: "default",
+			},
+			Data: data,
+		},
+	})
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/davidmdm/x/xsync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/xhttp"
+)
+
+// headerForwarded marks admission requests forwarded by a follower to the leader.
+// Its value is a signature of the request derived from the server key shared by all replicas,
+// such that the header cannot be set by anyone but a replica. Forwarded requests are never forwarded twice.
+const headerForwarded = "X-Atc-Forwarded"
+
+type LeadershipParams struct {
+	Client   *k8s.Client
+	Config   *Config
+	Logger   *slog.Logger
+	LeadFunc func(context.Context) error
+}
+
+// Leadership runs the controller only on the ATC replica that holds the leader lease.
+// The admission webhook server runs on every replica. Admission requests that depend on in-memory controller state,
+// such as flight states and the event dispatcher, are forwarded by followers to the leader.
+type Leadership struct {
+	elector   *leaderelection.LeaderElector
+	client    *k8s.Client
+	namespace string
+	port      string
+	transport http.RoundTripper
+	signKey   []byte
+	logger    *slog.Logger
+	addrs     xsync.Map[string, string]
+	acquired  atomic.Bool
+	result    chan error
+}
+
+func NewLeadership(params LeadershipParams) (*Leadership, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(params.Config.TLS.CA.Data) {
+		return nil, fmt.Errorf("failed to parse tls ca certificate")
+	}
+
+	leadership := &Leadership{
+		client:    params.Client,
+		namespace: params.Config.Service.Namespace,
+		port:      strconv.Itoa(params.Config.Port),
+		signKey:   signKey(params.Config.TLS.ServerKey.Data),
+		logger:    params.Logger,
+		result:    make(chan error, 1),
+		transport: &http.Transport{
+			TLSClientConfig: &tls.Config{
+				RootCAs: pool,
+				// Replicas are dialed by pod IP but present the certificate of the service.
+				ServerName: fmt.Sprintf("%s.%s.svc", params.Config.Service.Name, params.Config.Service.Namespace),
+			},
+		},
+	}
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      params.Config.LeaderElection.Lease,
+				Namespace: params.Config.Service.Namespace,
+			},
+			Client:     params.Client.Clientset.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{Identity: params.Config.LeaderElection.Identity},
+		},
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		// The lease is not released on shutdown. Letting it expire gives the exiting leader time to
+		// tear down its webhooks before the next leader applies them.
+		ReleaseOnCancel: false,
+		Name:            params.Config.LeaderElection.Lease,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				leadership.acquired.Store(true)
+				params.Logger.Info("acquired leader lease", "identity", params.Config.LeaderElection.Identity)
+				leadership.result <- params.LeadFunc(ctx)
+			},
+			OnStoppedLeading: func() {
+				params.Logger.Info("stopped leading", "identity", params.Config.LeaderElection.Identity)
+			},
+			OnNewLeader: func(identity string) {
+				params.Logger.Info("new leader elected", "identity", identity)
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create leader elector: %w", err)
+	}
+
+	leadership.elector = elector
+
+	return leadership, nil
+}
+
+// Run campaigns for the leader lease and runs the lead func once it is acquired.
+// Run returns when ctx is done, or with an error if the lease is lost. In-memory controller state cannot be handed
+// over to the next leader, so a replica that loses its lease is expected to exit and rejoin as a follower.
+func (leadership *Leadership) Run(ctx context.Context) error {
+	leadership.elector.Run(ctx)
+
+	if !leadership.acquired.Load() {
+		return context.Cause(ctx)
+	}
+
+	err := <-leadership.result
+	if ctx.Err() == nil {
+		return fmt.Errorf("lost leader lease: %w", err)
+	}
+	return err
+}
+
+// IsLeader reports whether this replica holds the leader lease. It is always true when leader election is disabled.
+func (leadership *Leadership) IsLeader() bool {
+	return leadership == nil || leadership.elector.IsLeader()
+}
+
+// Forward wraps handlers that depend on controller state.
+// When this replica is not the leader, the request is forwarded to the leader and its response is returned as is.
+// Followers never handle such requests themselves: if the leader cannot be reached, or if a forwarded request reaches
+// a replica that is no longer the leader, the request fails with a 503 and the webhook failure policy applies.
+func (leadership *Leadership) Forward(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		signature := r.Header.Get(headerForwarded)
+		r.Header.Del(headerForwarded)
+
+		if leadership.IsLeader() {
+			handler(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("failed to read body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		if signature != "" && hmac.Equal([]byte(signature), []byte(leadership.sign(r, body))) {
+			xhttp.AddRequestAttrs(r.Context(), slog.String("forwardError", "forwarded request received by a follower"))
+			http.Error(w, "forwarded request received by a follower: leadership changed", http.StatusServiceUnavailable)
+			return
+		}
+
+		resp, err := leadership.forward(r, body)
+		if err != nil {
+			xhttp.AddRequestAttrs(r.Context(), slog.String("forwardError", err.Error()))
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		defer resp.Body.Close()
+
+		xhttp.AddRequestAttrs(r.Context(), slog.String("forwardedTo", leadership.elector.GetLeader()))
+
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		if _, err := io.Copy(w, resp.Body); err != nil {
+			leadership.logger.Error("failed to write forwarded response", "error", err)
+		}
+	}
+}
+
+func (leadership *Leadership) forward(r *http.Request, body []byte) (*http.Response, error) {
+	addr, err := leadership.leaderAddr(r.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), r.Method, "https://"+addr+r.URL.RequestURI(), bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+
+	req.Header = r.Header.Clone()
+	req.Header.Set(headerForwarded, leadership.sign(r, body))
+
+	resp, err := leadership.transport.RoundTrip(req)
+	if err != nil {
+		// The leader may have been rescheduled with a new IP.
+		leadership.addrs.Delete(leadership.elector.GetLeader())
+		return nil, fmt.Errorf("failed to forward request to leader: %w", err)
+	}
+
+	return resp, nil
+}
+
+// sign returns the signature of a forwarded request.
+func (leadership *Leadership) sign(r *http.Request, body []byte) string {
+	mac := hmac.New(sha256.New, leadership.signKey)
+	mac.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signKey derives the key used to sign forwarded requests from the server key, which only the replicas hold.
+func signKey(serverKey []byte) []byte {
+	key := sha256.Sum256(append([]byte("atc-forward:"), serverKey...))
+	return key[:]
+}
+
+// leaderAddr returns the address of the leader. Leader identities are pod names and are resolved to the pod's IP.
+func (leadership *Leadership) leaderAddr(ctx context.Context) (string, error) {
+	identity := leadership.elector.GetLeader()
+	if identity == "" {
+		return "", errors.New("no leader elected")
+	}
+
+	if addr, ok := leadership.addrs.Load(identity); ok {
+		return addr, nil
+	}
+
+	pod, err := leadership.client.Clientset.CoreV1().Pods(leadership.namespace).Get(ctx, identity, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get leader pod: %w", err)
+	}
+	if pod.Status.PodIP == "" {
+		return "", fmt.Errorf("leader pod %s has no ip", identity)
+	}
+
+	addr := net.JoinHostPort(pod.Status.PodIP, leadership.port)
+	leadership.addrs.Store(identity, addr)
+
+	return addr, nil
+}
@@ -16,12 +16,17 @@ import (
 	"github.com/davidmdm/x/xerr"
 	"github.com/davidmdm/x/xsync"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/yokecd/yoke/internal/atc"
 	internalk8s "github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/internal/wasi/cache"
 	"github.com/yokecd/yoke/internal/xhttp"
 	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
@@ -59,6 +64,14 @@ func run() (err error) {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
+	shutdownTracing, err := tracing.Setup(ctx, "atc")
+	if err != nil {
+		return fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	defer func() {
+		err = xerr.Join(err, shutdownTracing(context.Background()))
+	}()
+
 	kubecfg, err := func() (kubecfg *rest.Config, err error) {
 		defer func() {
 			if kubecfg == nil {
@@ -83,16 +96,6 @@ func run() (err error) {
 
 	logger.Info("initializing atc")
 
-	logger.Info("applying resources")
-
-	teardown, err := ApplyResources(ctx, client, cfg)
-	if err != nil {
-		return fmt.Errorf("failed to apply dependent resources: %w", err)
-	}
-	defer func() {
-		err = xerr.Join(err, teardown(context.Background()))
-	}()
-
 	if !cfg.DisableCustomReadiness {
 		readiness, cancel, err := client.WatchCustomReadiness(ctx)
 		if err != nil {
@@ -106,31 +109,21 @@ func run() (err error) {
 	eventDispatcher := new(atc.EventDispatcher)
 	flightStates := &xsync.Map[string, atc.InstanceState]{}
 
+	// The broadcaster correlates events before sending them such that repeated events are aggregated
+	// into a single event with an incremented count instead of flooding the api-server.
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	defer broadcaster.Shutdown()
+
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.Clientset.CoreV1().Events("")})
+
+	recorder := broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "atc"})
+
 	controller := ctrl.NewController(ctrl.Params{
 		Client:      (*k8s.Client)(client),
 		Logger:      logger.With("component", "controller"),
 		Concurrency: max(cfg.Concurrency, 1),
+		Recorder:    recorder,
 	})
-	if err := controller.Register(
-		ctrl.Entry{
-			GroupKind: schema.GroupKind{Group: "yoke.cd", Kind: v1alpha1.KindAirway},
-			Forwarders: []schema.GroupKind{{
-				Group: "apiextensions.k8s.io",
-				Kind:  "CustomResourceDefinition",
-			}},
-			Funcs: atc.GetAirwayReconciler(cfg.Service, moduleCache, eventDispatcher, flightStates),
-		},
-		ctrl.Entry{
-			GroupKind: schema.GroupKind{Group: "yoke.cd", Kind: v1alpha1.KindFlight},
-			Funcs:     atc.FlightReconciler(moduleCache),
-		},
-		ctrl.Entry{
-			GroupKind: schema.GroupKind{Group: "yoke.cd", Kind: v1alpha1.KindClusterFlight},
-			Funcs:     atc.ClusterFlightReconsiler(moduleCache),
-		},
-	); err != nil {
-		return fmt.Errorf("failed to register group kind handlers: %w", err)
-	}
 
 	var wg sync.WaitGroup
 	defer wg.Wait()
@@ -140,6 +133,83 @@ func run() (err error) {
 	ctx, cancel = context.WithCancel(ctx)
 	defer cancel()
 
+	// lead applies the resources the ATC depends on and runs the controller.
+	// When leader election is enabled, only the replica holding the lease leads.
+	lead := func(leadCtx context.Context) (err error) {
+		logger.Info("applying resources")
+
+		teardown, err := ApplyResources(leadCtx, client, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to apply dependent resources: %w", err)
+		}
+		defer func() {
+			// If leadership was lost while the ATC is still running, the webhooks now belong to the new leader.
+			if ctx.Err() == nil {
+				return
+			}
+			err = xerr.Join(err, teardown(context.Background()))
+		}()
+
+		entries := []ctrl.Entry{
+			{
+				// Airways are watched by every shard as each shard runs the flight controllers for its own airway instances.
+				GroupKind: schema.GroupKind{Group: "yoke.cd", Kind: v1alpha1.KindAirway},
+				Forwarders: []schema.GroupKind{{
+					Group: "apiextensions.k8s.io",
+					Kind:  "CustomResourceDefinition",
+				}},
+				Funcs: atc.GetAirwayReconciler(cfg.Service, cfg.Shard, moduleCache, eventDispatcher, flightStates),
+			},
+			{
+				GroupKind: schema.GroupKind{Group: "yoke.cd", Kind: v1alpha1.KindFlight},
+				Funcs: atc.FlightReconciler(atc.FlightReconcilerParams{
+					Modules:    moduleCache,
+					Dispatcher: eventDispatcher,
+					States:     flightStates,
+				}),
+				Scope: cfg.Shard.Scope(),
+			},
+		}
+
+		if cfg.Shard.OwnsClusterScope() {
+			entries = append(entries, ctrl.Entry{
+				GroupKind: schema.GroupKind{Group: "yoke.cd", Kind: v1alpha1.KindClusterFlight},
+				Funcs: atc.ClusterFlightReconsiler(atc.FlightReconcilerParams{
+					Modules:    moduleCache,
+					Dispatcher: eventDispatcher,
+					States:     flightStates,
+				}),
+				Scope: cfg.Shard.Scope(),
+			})
+		}
+
+		if err := controller.Register(entries...); err != nil {
+			return fmt.Errorf("failed to register group kind handlers: %w", err)
+		}
+
+		logger.Info("Controller Starting", "concurrency", controller.Concurrency, "shard", cfg.Shard.Name)
+
+		if err := controller.Run(leadCtx); err != nil {
+			return fmt.Errorf("controller exited run with error: %w", err)
+		}
+		return nil
+	}
+
+	leadership, err := func() (*Leadership, error) {
+		if !cfg.LeaderElection.Enabled {
+			return nil, nil
+		}
+		return NewLeadership(LeadershipParams{
+			Client:   client,
+			Config:   cfg,
+			Logger:   logger.With("component", "leader-election"),
+			LeadFunc: lead,
+		})
+	}()
+	if err != nil {
+		return fmt.Errorf("failed to setup leader election: %w", err)
+	}
+
 	wg.Go(func() {
 		if cfg.DockerConfigSecretName == "" {
 			return
@@ -156,9 +226,15 @@ func run() (err error) {
 	})
 
 	wg.Go(func() {
-		logger.Info("Controller Starting", "concurrency", controller.Concurrency)
-		if err := controller.Run(ctx); err != nil {
-			e <- fmt.Errorf("controller exited run with error: %w", err)
+		if leadership == nil {
+			if err := lead(ctx); err != nil {
+				e <- err
+			}
+			return
+		}
+		logger.Info("Campaigning for leader lease", "lease", cfg.LeaderElection.Lease, "identity", cfg.LeaderElection.Identity)
+		if err := leadership.Run(ctx); err != nil {
+			e <- fmt.Errorf("leader election: %w", err)
 		}
 	})
 
@@ -175,10 +251,12 @@ func run() (err error) {
 		svr := http.Server{
 			Handler: Handler(HandlerParams{
 				Controller:   controller,
+				Leadership:   leadership,
 				FlightStates: flightStates,
 				Client:       client,
 				Cache:        moduleCache,
 				Dispatcher:   eventDispatcher,
+				Recorder:     recorder,
 				Logger:       logger.With("component", "server"),
 				Filter:       filter,
 			}),
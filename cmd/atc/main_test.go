@@ -163,6 +163,8 @@ func TestMain(m *testing.M) {
 		"flight.v2.wasm":              "./internal/testing/apis/backend/v2/flight",
 		"flight.dev.wasm":             "./internal/testing/apis/backend/v2/dev",
 		"converter.wasm":              "./internal/testing/apis/backend/converter",
+		"validator.wasm":              "./internal/testing/apis/backend/validator",
+		"defaulter.wasm":              "./internal/testing/apis/backend/defaulter",
 		"crossnamespace.wasm":         "./internal/testing/flights/crossnamespace",
 		"longrunning.wasm":            "./internal/testing/flights/longrunning",
 		"resourceaccessmatchers.wasm": "./internal/testing/flights/resourceaccessmatchers",
@@ -170,6 +172,7 @@ func TestMain(m *testing.M) {
 		"deploymentstatus.wasm":       "./internal/testing/flights/deploymentstatus",
 		"prune.wasm":                  "./internal/testing/flights/prune",
 		"externalcreation.wasm":       "./internal/testing/flights/externalcreation",
+		"listselector.wasm":           "./internal/testing/flights/listselector",
 		"timeout.wasm":                "./internal/testing/flights/timeout",
 		"subscriptions.wasm":          "./internal/testing/flights/subscriptions",
 		"basic.wasm":                  "./internal/testing/flights/basic",
@@ -1370,6 +1373,140 @@ func TestHistoryCap(t *testing.T) {
 	require.Len(t, release.History, 3)
 }
 
+func TestReleaseNameMigration(t *testing.T) {
+	DropAllAirways(t)
+
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	ctx := internal.WithDebugFlag(context.Background(), func(value bool) *bool { return &value }(true))
+
+	commander := yoke.FromK8Client(client)
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			return commander.Takeoff(ctx, yoke.TakeoffParams{
+				Release: "backend-airway",
+				Flight: yoke.FlightParams{
+					Input: internal.JSONReader(v1alpha1.Airway{
+						ObjectMeta: metav1.ObjectMeta{
+							Name: "backends.examples.com",
+						},
+						Spec: v1alpha1.AirwaySpec{
+							WasmURLs: v1alpha1.WasmURLs{
+								Flight: "oci://registry:80/flight.v1.wasm",
+							},
+							Insecure: true,
+							Template: apiextv1.CustomResourceDefinitionSpec{
+								Group: "examples.com",
+								Names: apiextv1.CustomResourceDefinitionNames{
+									Plural:     "backends",
+									Singular:   "backend",
+									ShortNames: []string{"be"},
+									Kind:       "Backend",
+								},
+								Scope: apiextv1.NamespaceScoped,
+								Versions: []apiextv1.CustomResourceDefinitionVersion{
+									{
+										Name:    "v1",
+										Served:  true,
+										Storage: true,
+										Schema: &apiextv1.CustomResourceValidation{
+											OpenAPIV3Schema: openapi.SchemaFor[backendv1.Backend](),
+										},
+									},
+								},
+							},
+						},
+					}),
+				},
+				Wait: 30 * time.Second,
+				Poll: time.Second,
+			})
+		},
+		time.Second,
+		10*time.Second,
+		"failed to create airway",
+	)
+	defer func() {
+		require.NoError(t, commander.Mayday(ctx, yoke.MaydayParams{Release: "backend-airway"}))
+	}()
+
+	backend, err := internal.ToUnstructured(&backendv1.Backend{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "examples.com/v1",
+			Kind:       "Backend",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "default",
+		},
+		Spec: backendv1.BackendSpec{
+			Image:    "yokecd/c4ts:test",
+			Replicas: 1,
+			Labels:   map[string]string{},
+		},
+	})
+	require.NoError(t, err)
+
+	// Simulate a release created by a previous version of the ATC before the instance is first reconciled.
+	// Once migrated, the legacy configmap belongs to the release of the instance and is pruned by its first takeoff.
+	require.NoError(t, commander.Takeoff(ctx, yoke.TakeoffParams{
+		Release: atc.DeprecatedReleaseName(backend),
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(&corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "legacy",
+				},
+			}),
+		},
+	}))
+
+	backendIntf := client.Dynamic.
+		Resource(schema.GroupVersionResource{
+			Group:    "examples.com",
+			Version:  "v1",
+			Resource: "backends",
+		}).
+		Namespace("default")
+
+	_, err = backendIntf.Create(ctx, backend, metav1.CreateOptions{})
+	require.NoError(t, err)
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			deployment, err := client.Clientset.AppsV1().Deployments("default").Get(ctx, "test", metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get deployment: %w", err)
+			}
+			if release := deployment.Annotations[internal.AnnotationYokeRelease]; release != atc.ReleaseName(backend) {
+				return fmt.Errorf("expected deployment release to be %q but got %q", atc.ReleaseName(backend), release)
+			}
+			if _, err := client.Clientset.CoreV1().ConfigMaps("default").Get(ctx, "legacy", metav1.GetOptions{}); !kerrors.IsNotFound(err) {
+				return fmt.Errorf("expected legacy configmap to be pruned but got: %v", err)
+			}
+			return nil
+		},
+		time.Second,
+		30*time.Second,
+		"expected release to be migrated",
+	)
+
+	deprecated, err := client.GetRelease(ctx, atc.DeprecatedReleaseName(backend), "default")
+	require.NoError(t, err)
+	require.Empty(t, deprecated.History)
+
+	release, err := client.GetRelease(ctx, atc.ReleaseName(backend), "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 2)
+}
+
 func TestFixDriftInterval(t *testing.T) {
 	DropAllAirways(t)
 
@@ -2457,6 +2594,162 @@ func TestExternalDynamicCreateEvent(t *testing.T) {
 	)
 }
 
+func TestExternalDynamicListEvent(t *testing.T) {
+	DropAllAirways(t)
+
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	ctx := internal.WithDebugFlag(context.Background(), new(true))
+
+	type Spec struct {
+		Selector string `json:"selector"`
+		Target   string `json:"target"`
+	}
+
+	type Status struct {
+		Count int `json:"count"`
+	}
+
+	type MergeJob struct {
+		metav1.TypeMeta
+		metav1.ObjectMeta `json:"metadata"`
+		Spec              Spec   `json:"spec"`
+		Status            Status `json:"status"`
+	}
+
+	commander := yoke.FromK8Client(client)
+
+	require.NoError(t, commander.Takeoff(ctx, yoke.TakeoffParams{
+		Release: "dynamic-external-list-airway",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(v1alpha1.Airway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "merges.examples.com",
+				},
+				Spec: v1alpha1.AirwaySpec{
+					WasmURLs: v1alpha1.WasmURLs{
+						Flight: "oci://registry:80/listselector.wasm",
+					},
+					Insecure:               true,
+					Mode:                   v1alpha1.AirwayModeDynamic,
+					ClusterAccess:          true,
+					ResourceAccessMatchers: []string{"default/ConfigMap"},
+					Template: apiextv1.CustomResourceDefinitionSpec{
+						Group: "examples.com",
+						Names: apiextv1.CustomResourceDefinitionNames{
+							Plural:   "merges",
+							Singular: "merge",
+							Kind:     "Merge",
+						},
+						Scope: apiextv1.NamespaceScoped,
+						Versions: []apiextv1.CustomResourceDefinitionVersion{
+							{
+								Name:    "v1",
+								Served:  true,
+								Storage: true,
+								Schema: &apiextv1.CustomResourceValidation{
+									OpenAPIV3Schema: openapi.SchemaFor[MergeJob](),
+								},
+							},
+						},
+					},
+				},
+			}),
+		},
+		Wait: 30 * time.Second,
+		Poll: time.Second,
+	}))
+
+	configmapIntf := client.Clientset.CoreV1().ConfigMaps("default")
+
+	defer func() {
+		require.NoError(t, commander.Mayday(ctx, yoke.MaydayParams{Release: "dynamic-external-list-airway"}))
+		require.NoError(t, configmapIntf.DeleteCollection(ctx, metav1.DeleteOptions{}, metav1.ListOptions{LabelSelector: "team=merge"}))
+	}()
+
+	mergeIntf := k8s.
+		TypedInterface[MergeJob](client, schema.GroupVersionResource{
+		Group:    "examples.com",
+		Version:  "v1",
+		Resource: "merges",
+	}).
+		Namespace("default")
+
+	_, err = configmapIntf.Create(
+		ctx,
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "first", Labels: map[string]string{"team": "merge"}},
+			Data:       map[string]string{"first": "data"},
+		},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	_, err = mergeIntf.Create(
+		ctx,
+		&MergeJob{
+			TypeMeta: metav1.TypeMeta{
+				Kind:       "Merge",
+				APIVersion: "examples.com/v1",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "test",
+				Namespace: "default",
+			},
+			Spec: Spec{
+				Selector: "team=merge",
+				Target:   "merged",
+			},
+		},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	assertMerged := func(keys ...string) {
+		testutils.EventuallyNoErrorf(
+			t,
+			func() error {
+				merged, err := configmapIntf.Get(ctx, "merged", metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				for _, key := range keys {
+					if merged.Data[key] != "data" {
+						return fmt.Errorf("expected merged.data.%s to be data but got: %v", key, merged.Data[key])
+					}
+				}
+				job, err := mergeIntf.Get(ctx, "test", metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if job.Status.Count != len(keys) {
+					return fmt.Errorf("expected status.count to be %d but got %d", len(keys), job.Status.Count)
+				}
+				return nil
+			},
+			time.Second,
+			30*time.Second,
+			"error asserting merged configmap state",
+		)
+	}
+
+	assertMerged("first")
+
+	// The second configmap did not exist when the flight listed configmaps, yet its creation must requeue the instance.
+	_, err = configmapIntf.Create(
+		ctx,
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "second", Labels: map[string]string{"team": "merge"}},
+			Data:       map[string]string{"second": "data"},
+		},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	assertMerged("first", "second")
+}
+
 func TestStatusUpdates(t *testing.T) {
 	DropAllAirways(t)
 
@@ -2934,7 +3227,7 @@ func TestPruning(t *testing.T) {
 	)
 	require.NoError(t, err)
 
-	expectedOwner := "default/examples.com.Test.test"
+	expectedOwner := "default/default/Test.examples.com:test"
 
 	testutils.EventuallyNoErrorf(
 		t,
@@ -3973,6 +4266,198 @@ func DropAllAirways(t *testing.T) {
 	t.Logf("finished dropping airways after %s", time.Since(start))
 }
 
+func TestProgressiveRollout(t *testing.T) {
+	DropAllAirways(t)
+
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	ctx := internal.WithDebugFlag(context.Background(), new(true))
+
+	commander := yoke.FromK8Client(client)
+
+	takeoffAirway := func(flightURL string) error {
+		return commander.Takeoff(ctx, yoke.TakeoffParams{
+			Release: "rollout-airway",
+			Flight: yoke.FlightParams{
+				Input: internal.JSONReader(v1alpha1.Airway{
+					ObjectMeta: metav1.ObjectMeta{
+						Name: "backends.examples.com",
+					},
+					Spec: v1alpha1.AirwaySpec{
+						WasmURLs: v1alpha1.WasmURLs{
+							Flight: flightURL,
+						},
+						Insecure: true,
+						Timeout:  metav1.Duration{Duration: 2 * time.Second},
+						Rollout: &v1alpha1.RolloutPolicy{
+							MaxConcurrent: 1,
+							MaxFailures:   1,
+							Canary: &metav1.LabelSelector{
+								MatchLabels: map[string]string{"canary": "true"},
+							},
+						},
+						Template: apiextv1.CustomResourceDefinitionSpec{
+							Group: "examples.com",
+							Names: apiextv1.CustomResourceDefinitionNames{
+								Plural:   "backends",
+								Singular: "backend",
+								Kind:     "Backend",
+							},
+							Scope: apiextv1.NamespaceScoped,
+							Versions: []apiextv1.CustomResourceDefinitionVersion{
+								{
+									Name:    "v1",
+									Served:  true,
+									Storage: true,
+									Schema: &apiextv1.CustomResourceValidation{
+										OpenAPIV3Schema: openapi.SchemaFor[backendv1.Backend](),
+									},
+								},
+							},
+						},
+					},
+				}),
+			},
+			Wait: 30 * time.Second,
+			Poll: time.Second,
+		})
+	}
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error { return takeoffAirway("oci://registry:80/flight.v1.wasm") },
+		time.Second,
+		10*time.Second,
+		"failed to create airway",
+	)
+
+	defer func() {
+		require.NoError(t, commander.Mayday(ctx, yoke.MaydayParams{Release: "rollout-airway"}))
+
+		testutils.EventuallyNoErrorf(
+			t,
+			func() error {
+				_, err := client.AirwayIntf().Get(ctx, "backends.examples.com", metav1.GetOptions{})
+				if err == nil {
+					return fmt.Errorf("backends.examples.com has not been removed")
+				}
+				if !kerrors.IsNotFound(err) {
+					return err
+				}
+				return nil
+			},
+			time.Second,
+			30*time.Second,
+			"failed to cleanup rollout test resources",
+		)
+	}()
+
+	backendIntf := client.Dynamic.
+		Resource(schema.GroupVersionResource{
+			Group:    "examples.com",
+			Version:  "v1",
+			Resource: "backends",
+		}).
+		Namespace("default")
+
+	for name, labels := range map[string]map[string]string{
+		"canary": {"canary": "true"},
+		"tenant": nil,
+	} {
+		backend, err := internal.ToUnstructured(&backendv1.Backend{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+			Spec: backendv1.BackendSpec{
+				Image:    "yokecd/c4ts:test",
+				Replicas: 1,
+			},
+		})
+		require.NoError(t, err)
+
+		_, err = backendIntf.Create(ctx, backend, metav1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	expectFlight := func(name, url string) func() error {
+		return func() error {
+			backend, err := backendIntf.Get(ctx, name, metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get backend: %w", err)
+			}
+			if actual := atc.InstanceModule(backend).URL; actual != url {
+				return fmt.Errorf("expected backend %s to run flight %q but got %q", name, url, actual)
+			}
+			return nil
+		}
+	}
+
+	for _, name := range []string{"canary", "tenant"} {
+		testutils.EventuallyNoErrorf(t, expectFlight(name, "oci://registry:80/flight.v1.wasm"), time.Second, 30*time.Second, "expected backend to run initial flight")
+	}
+
+	// The timeout flight never completes: upgrading the canary fails and pauses the rollout before any other instance is upgraded.
+	require.NoError(t, takeoffAirway("oci://registry:80/timeout.wasm"))
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			airway, err := client.AirwayIntf().Get(ctx, "backends.examples.com", metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get airway: %w", err)
+			}
+			rollout := airway.Status.Rollout
+			if rollout == nil {
+				return fmt.Errorf("airway has no rollout status")
+			}
+			if rollout.Flight.URL != "oci://registry:80/timeout.wasm" {
+				return fmt.Errorf("expected rollout of timeout flight but got %q", rollout.Flight.URL)
+			}
+			if !rollout.Paused {
+				return fmt.Errorf("expected rollout to be paused")
+			}
+			if expected := "default/Backend.examples.com:canary"; !slices.Equal(rollout.Failed, []string{expected}) {
+				return fmt.Errorf("expected failed instances to be %v but got %v", []string{expected}, rollout.Failed)
+			}
+			return nil
+		},
+		time.Second,
+		time.Minute,
+		"expected rollout to be paused",
+	)
+
+	require.NoError(t, expectFlight("tenant", "oci://registry:80/flight.v1.wasm")())
+
+	_, err = client.Clientset.AppsV1().Deployments("default").Get(ctx, "tenant", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// Rolling back to the previous flight starts a new rollout that every instance has already completed.
+	require.NoError(t, takeoffAirway("oci://registry:80/flight.v1.wasm"))
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			airway, err := client.AirwayIntf().Get(ctx, "backends.examples.com", metav1.GetOptions{})
+			if err != nil {
+				return fmt.Errorf("failed to get airway: %w", err)
+			}
+			if rollout := airway.Status.Rollout; rollout == nil || rollout.Flight.URL != "oci://registry:80/flight.v1.wasm" || rollout.Paused {
+				return fmt.Errorf("expected unpaused rollout of initial flight but got %+v", rollout)
+			}
+			return nil
+		},
+		time.Second,
+		30*time.Second,
+		"expected rollout to be rolled back",
+	)
+
+	for _, name := range []string{"canary", "tenant"} {
+		testutils.EventuallyNoErrorf(t, expectFlight(name, "oci://registry:80/flight.v1.wasm"), time.Second, 30*time.Second, "expected backend to run initial flight")
+	}
+}
+
 func TestAirwayCodeSigning(t *testing.T) {
 	DropAllAirways(t)
 
@@ -4086,3 +4571,152 @@ func TestAirwayCodeSigning(t *testing.T) {
 		Poll:          time.Second,
 	}))
 }
+
+func TestAirwayValidatorAndDefaulter(t *testing.T) {
+	DropAllAirways(t)
+
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	ctx := internal.WithDebugFlag(context.Background(), new(true))
+
+	commander := yoke.FromK8Client(client)
+
+	require.NoError(t, commander.Takeoff(ctx, yoke.TakeoffParams{
+		Release: "backend-airway",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(v1alpha1.Airway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "backends.examples.com",
+				},
+				Spec: v1alpha1.AirwaySpec{
+					WasmURLs: v1alpha1.WasmURLs{
+						Flight:    "oci://registry:80/flight.v1.wasm",
+						Validator: "oci://registry:80/validator.wasm",
+						Defaulter: "oci://registry:80/defaulter.wasm",
+					},
+					Insecure: true,
+					Template: apiextv1.CustomResourceDefinitionSpec{
+						Group: "examples.com",
+						Names: apiextv1.CustomResourceDefinitionNames{
+							Plural:   "backends",
+							Singular: "backend",
+							Kind:     "Backend",
+						},
+						Scope: apiextv1.NamespaceScoped,
+						Versions: []apiextv1.CustomResourceDefinitionVersion{
+							{
+								Name:    "v1",
+								Served:  true,
+								Storage: true,
+								Schema: &apiextv1.CustomResourceValidation{
+									OpenAPIV3Schema: openapi.SchemaFor[backendv1.Backend](),
+								},
+							},
+						},
+					},
+				},
+			}),
+		},
+		Wait: 30 * time.Second,
+		Poll: time.Second,
+	}))
+
+	defer func() {
+		require.NoError(t, commander.Mayday(ctx, yoke.MaydayParams{Release: "backend-airway"}))
+
+		testutils.EventuallyNoErrorf(
+			t,
+			func() error {
+				_, err := client.AirwayIntf().Get(ctx, "backends.examples.com", metav1.GetOptions{})
+				if err == nil {
+					return fmt.Errorf("backends.examples.com has not been removed")
+				}
+				if !kerrors.IsNotFound(err) {
+					return err
+				}
+				return nil
+			},
+			time.Second,
+			30*time.Second,
+			"failed to remove airway",
+		)
+	}()
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			_, err := client.Clientset.AdmissionregistrationV1().MutatingWebhookConfigurations().Get(ctx, "backends.examples.com", metav1.GetOptions{})
+			return err
+		},
+		time.Second,
+		30*time.Second,
+		"mutation webhook was not created",
+	)
+
+	backendIntf := k8s.TypedInterface[backendv1.Backend](client, schema.GroupVersionResource{
+		Group:    "examples.com",
+		Version:  "v1",
+		Resource: "backends",
+	}).Namespace("default")
+
+	_, err = backendIntf.Create(
+		ctx,
+		&backendv1.Backend{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+			Spec:       backendv1.BackendSpec{Replicas: -1},
+		},
+		metav1.CreateOptions{},
+	)
+	require.ErrorContains(t, err, "invalid backend: spec.image: image is required, spec.replicas: replicas cannot be negative")
+
+	backend, err := backendIntf.Create(
+		ctx,
+		&backendv1.Backend{
+			ObjectMeta: metav1.ObjectMeta{Name: "defaulted"},
+			Spec:       backendv1.BackendSpec{Image: "yokecd/c4ts:test"},
+		},
+		metav1.CreateOptions{},
+	)
+	require.NoError(t, err)
+
+	require.EqualValues(t, 2, backend.Spec.Replicas)
+	require.Equal(t, "/health", backend.Spec.HealthCheck)
+
+	require.NoError(t, backendIntf.Delete(ctx, backend.Name, metav1.DeleteOptions{}))
+
+	// The function call budget of the airway applies to its validator: exceeding it denies the request in-band.
+	airway, err := client.AirwayIntf().Get(ctx, "backends.examples.com", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	airway.Spec.WasmURLs.Defaulter = ""
+	airway.Spec.MaxFunctionCalls = 1
+
+	_, err = client.AirwayIntf().Update(ctx, airway, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	testutils.EventuallyNoErrorf(
+		t,
+		func() error {
+			_, err := backendIntf.Create(
+				ctx,
+				&backendv1.Backend{
+					ObjectMeta: metav1.ObjectMeta{Name: "budget"},
+					Spec:       backendv1.BackendSpec{Image: "yokecd/c4ts:test", Replicas: 1},
+				},
+				metav1.CreateOptions{},
+			)
+			if err == nil {
+				_ = backendIntf.Delete(ctx, "budget", metav1.DeleteOptions{})
+				return fmt.Errorf("expected backend to be denied")
+			}
+			if msg := err.Error(); !strings.Contains(msg, "failed to execute validator") || !strings.Contains(msg, "function call budget exceeded") {
+				return fmt.Errorf("unexpected error: %w", err)
+			}
+			return nil
+		},
+		time.Second,
+		30*time.Second,
+		"validator exceeding its function call budget was not denied",
+	)
+}
@@ -182,7 +182,7 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 			Kind:       "ValidatingWebhookConfiguration",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "atc-airway",
+			Name: cfg.Shard.ResourceName("atc-airway"),
 		},
 		Webhooks: []admissionregistrationv1.ValidatingWebhook{
 			{
@@ -199,6 +199,7 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 				SideEffects:             ptr.To(admissionregistrationv1.SideEffectClassNone),
 				TimeoutSeconds:          new(int32(30)),
 				AdmissionReviewVersions: []string{"v1"},
+				ObjectSelector:          cfg.Shard.ObjectSelector(),
 				Rules: []admissionregistrationv1.RuleWithOperations{
 					{
 						Operations: []admissionregistrationv1.OperationType{
@@ -223,7 +224,7 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 			Kind:       "ValidatingWebhookConfiguration",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "atc-flight",
+			Name: cfg.Shard.ResourceName("atc-flight"),
 		},
 		Webhooks: []admissionregistrationv1.ValidatingWebhook{
 			{
@@ -242,8 +243,10 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 				// We are using the maximum timeout.
 				// It is likely that for this webhook handles the download and compilation of the flights wasm.
 				// In general this should be fast, on the order of a couple seconds, but lets stay on the side of caution for now.
-				TimeoutSeconds: new(int32(30)),
-				MatchPolicy:    ptr.To(admissionregistrationv1.Exact),
+				TimeoutSeconds:    new(int32(30)),
+				MatchPolicy:       ptr.To(admissionregistrationv1.Exact),
+				NamespaceSelector: cfg.Shard.NamespaceSelector(),
+				ObjectSelector:    cfg.Shard.ObjectSelector(),
 				MatchConditions: []admissionregistrationv1.MatchCondition{
 					{
 						Name: "not-atc-service-account",
@@ -277,7 +280,7 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 			Kind:       "ValidatingWebhookConfiguration",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "atc-resources",
+			Name: cfg.Shard.ResourceName("atc-resources"),
 		},
 		Webhooks: []admissionregistrationv1.ValidatingWebhook{
 			{
@@ -295,6 +298,8 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 				AdmissionReviewVersions: []string{"v1"},
 				FailurePolicy:           ptr.To(admissionregistrationv1.Ignore),
 				MatchPolicy:             ptr.To(admissionregistrationv1.Exact),
+				// Resources do not carry the labels of the instance that created them and are only routed to a shard by namespace.
+				NamespaceSelector: cfg.Shard.NamespaceSelector(),
 				MatchConditions: []admissionregistrationv1.MatchCondition{
 					{
 						Name:       "managed-by-atc",
@@ -333,7 +338,7 @@ func ApplyResources(ctx context.Context, client *k8s.Client, cfg *Config) (teard
 			Kind:       "ValidatingWebhookConfiguration",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "atc-external-resources",
+			Name: cfg.Shard.ResourceName("atc-external-resources"),
 		},
 		Webhooks: []admissionregistrationv1.ValidatingWebhook{
 			{
@@ -16,8 +16,8 @@ import (
 	"gopkg.in/yaml.v3"
 
 	"github.com/yokecd/yoke/internal"
-	"github.com/yokecd/yoke/internal/k8s"
 	"github.com/yokecd/yoke/internal/text"
+	"github.com/yokecd/yoke/pkg/yoke"
 )
 
 type BlackboxParams struct {
@@ -29,6 +29,31 @@ type BlackboxParams struct {
 	Context        int
 }
 
+// ReleaseSummary is the structured output of blackbox when no release is given.
+type ReleaseSummary struct {
+	Release    string `json:"release"`
+	Namespace  string `json:"namespace"`
+	RevisionID int    `json:"revisionId"`
+}
+
+// ReleaseHistory is the structured output of blackbox for a single release.
+type ReleaseHistory struct {
+	Release   string            `json:"release"`
+	Namespace string            `json:"namespace"`
+	History   []RevisionSummary `json:"history"`
+}
+
+type RevisionSummary struct {
+	ID int `json:"id"`
+	internal.Revision
+}
+
+// RevisionResources is the structured output of blackbox for a single revision.
+type RevisionResources struct {
+	Revision  int            `json:"revision"`
+	Resources map[string]any `json:"resources"`
+}
+
 //go:embed cmd_blackbox_help.txt
 var blackboxHelp string
 
@@ -73,22 +98,36 @@ func GetBlackBoxParams(settings GlobalSettings, args []string) (*BlackboxParams,
 }
 
 func Blackbox(ctx context.Context, params BlackboxParams) error {
-	client, err := k8s.NewClientFromConfigFlags(params.Kube)
+	commander, err := NewCommander(params.GlobalSettings)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate k8 client: %w", err)
 	}
 
+	store := commander.ReleaseStore()
+
 	releases, err := func() ([]internal.Release, error) {
 		if ns := params.Namespace; ns != "" {
-			return client.GetReleasesByNS(ctx, ns)
+			return store.GetReleasesByNS(ctx, ns)
 		}
-		return client.GetReleases(ctx)
+		return store.GetReleases(ctx)
 	}()
 	if err != nil {
 		return fmt.Errorf("failed to get revisions: %w", err)
 	}
 
 	if params.Release == "" {
+		if params.Output.Structured() {
+			summaries := []ReleaseSummary{}
+			for _, release := range releases {
+				summaries = append(summaries, ReleaseSummary{
+					Release:    release.Name,
+					Namespace:  release.Namespace,
+					RevisionID: release.ActiveIndex() + 1,
+				})
+			}
+			return internal.WriteOutput(internal.Stdout(ctx), params.Output, summaries)
+		}
+
 		tbl := table.NewWriter()
 		tbl.SetStyle(table.StyleRounded)
 
@@ -114,6 +153,18 @@ func Blackbox(ctx context.Context, params BlackboxParams) error {
 	release := matchingReleases[0]
 
 	if params.RevisionID == 0 {
+		if params.Output.Structured() {
+			history := ReleaseHistory{
+				Release:   release.Name,
+				Namespace: release.Namespace,
+				History:   make([]RevisionSummary, len(release.History)),
+			}
+			for i, revision := range release.History {
+				history.History[i] = RevisionSummary{ID: i + 1, Revision: revision}
+			}
+			return internal.WriteOutput(internal.Stdout(ctx), params.Output, history)
+		}
+
 		tbl := table.NewWriter()
 		tbl.SetStyle(table.StyleRounded)
 
@@ -121,18 +172,27 @@ func Blackbox(ctx context.Context, params BlackboxParams) error {
 
 		activeIndex := release.ActiveIndex()
 
-		tbl.AppendHeader(table.Row{"id", "resources", "flight", "sha", "active at", "created at"})
+		tbl.AppendHeader(table.Row{"id", "status", "resources", "flight", "sha", "active at", "created at"})
 		for i, version := range release.History {
 			id := strconv.Itoa(len(release.History) - i)
 			if i == activeIndex {
 				id += " (current)"
 			}
+			status := string(version.Status)
+			if version.Failed() {
+				status += fmt.Sprintf(" (stage %d)", version.FailedStage)
+			}
+			activeAt := ""
+			if !version.ActiveAt.IsZero() {
+				activeAt = version.ActiveAt.Format(time.DateTime)
+			}
 			tbl.AppendRow(table.Row{
 				id,
+				status,
 				version.Resources,
 				version.Source.Ref,
 				version.Source.Checksum,
-				version.ActiveAt.Format(time.DateTime),
+				activeAt,
 				version.CreatedAt.Format(time.DateTime),
 			})
 		}
@@ -145,7 +205,11 @@ func Blackbox(ctx context.Context, params BlackboxParams) error {
 		return fmt.Errorf("revision %d not found", params.RevisionID)
 	}
 
-	stages, err := client.GetRevisionResources(ctx, release.History[params.RevisionID-1])
+	if revision := release.History[params.RevisionID-1]; revision.Failed() {
+		fmt.Fprintf(internal.Stderr(ctx), "revision %d failed at stage %d: %s\n\n", params.RevisionID, revision.FailedStage, revision.Error)
+	}
+
+	stages, err := store.GetRevisionResources(ctx, release.History[params.RevisionID-1])
 	if err != nil {
 		return fmt.Errorf("failed to get resources for revision %d: %w", params.RevisionID, err)
 	}
@@ -153,6 +217,13 @@ func Blackbox(ctx context.Context, params BlackboxParams) error {
 	primaryRevision := internal.CanonicalObjectMap(stages.Flatten())
 
 	if params.DiffRevisionID == 0 {
+		if params.Output.Structured() {
+			return internal.WriteOutput(internal.Stdout(ctx), params.Output, RevisionResources{
+				Revision:  params.RevisionID,
+				Resources: primaryRevision,
+			})
+		}
+
 		encoder := yaml.NewEncoder(os.Stdout)
 		encoder.SetIndent(2)
 
@@ -166,13 +237,17 @@ func Blackbox(ctx context.Context, params BlackboxParams) error {
 		return fmt.Errorf("revision %d not found", params.DiffRevisionID)
 	}
 
-	stages, err = client.GetRevisionResources(ctx, release.History[params.DiffRevisionID-1])
+	stages, err = store.GetRevisionResources(ctx, release.History[params.DiffRevisionID-1])
 	if err != nil {
 		return fmt.Errorf("failed to get resources for revision %d: %w", params.DiffRevisionID, err)
 	}
 
 	diffRevision := internal.CanonicalObjectMap(stages.Flatten())
 
+	if params.Output.Structured() {
+		return internal.WriteOutput(internal.Stdout(ctx), params.Output, yoke.DiffResources(primaryRevision, diffRevision))
+	}
+
 	a, err := text.ToYamlFile(fmt.Sprintf("revision %d", params.RevisionID), primaryRevision)
 	if err != nil {
 		return err
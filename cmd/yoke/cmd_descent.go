@@ -77,7 +77,7 @@ func GetDescentfParams(settings GlobalSettings, args []string) (*DescentParams,
 }
 
 func Descent(ctx context.Context, params DescentParams) error {
-	commander, err := yoke.FromKubeConfigFlags(params.Kube)
+	commander, err := NewCommander(params.GlobalSettings)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate k8 client: %w", err)
 	}
@@ -58,7 +58,7 @@ func GetMaydayParams(settings GlobalSettings, args []string) (*MaydayParams, err
 }
 
 func Mayday(ctx context.Context, params MaydayParams) error {
-	commander, err := yoke.FromKubeConfigFlags(params.Kube)
+	commander, err := NewCommander(params.GlobalSettings)
 	if err != nil {
 		return fmt.Errorf("failed to instantiate k8 client: %w", err)
 	}
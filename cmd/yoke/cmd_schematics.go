@@ -19,7 +19,7 @@ func init() {
 	schematicsHelp = strings.TrimSpace(internal.Colorize(schematicsHelp))
 }
 
-func SchematicsCommand(ctx context.Context, args []string) error {
+func SchematicsCommand(ctx context.Context, settings GlobalSettings, args []string) error {
 	flagset := flag.NewFlagSet("schematics", flag.ExitOnError)
 
 	flagset.Usage = func() {
@@ -29,6 +29,10 @@ func SchematicsCommand(ctx context.Context, args []string) error {
 
 	var wasmPath string
 	flagset.StringVar(&wasmPath, "wasm", "", "path to wasm file. http(s), and oci urls are supported")
+	flagset.Func("output", "output format for ls and get: json or yaml. Defaults to raw text", func(value string) (err error) {
+		settings.Output, err = internal.ParseOutputFormat(value)
+		return err
+	})
 
 	flagset.Parse(args)
 
@@ -51,6 +55,9 @@ func SchematicsCommand(ctx context.Context, args []string) error {
 			if err != nil {
 				return fmt.Errorf("failed to list schematics: %w", err)
 			}
+			if settings.Output.Structured() {
+				return internal.WriteOutput(internal.Stdout(ctx), settings.Output, append([]string{}, schematics...))
+			}
 			for _, schematic := range schematics {
 				fmt.Fprintln(internal.Stdout(ctx), schematic)
 			}
@@ -68,6 +75,12 @@ func SchematicsCommand(ctx context.Context, args []string) error {
 			if err != nil {
 				return fmt.Errorf("failed to get schematics: %w", err)
 			}
+			if settings.Output.Structured() {
+				return internal.WriteOutput(internal.Stdout(ctx), settings.Output, map[string]string{
+					"name": subargs[0],
+					"data": string(data),
+				})
+			}
 			_, err = internal.Stdout(ctx).Write(data)
 			return err
 		}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
+)
+
+// AnnotateParams are the parameters of the commands that set ATC annotations on a Flight, ClusterFlight, or Airway instance.
+type AnnotateParams struct {
+	GlobalSettings
+	Namespace string
+	// Resource is the target of the command formatted as <resource>/<name>, for example flights.yoke.cd/foo.
+	Resource string
+}
+
+var (
+	//go:embed cmd_suspend_help.txt
+	suspendHelp string
+
+	//go:embed cmd_resume_help.txt
+	resumeHelp string
+
+	//go:embed cmd_reconcile_help.txt
+	reconcileHelp string
+)
+
+func init() {
+	suspendHelp = strings.TrimSpace(internal.Colorize(suspendHelp))
+	resumeHelp = strings.TrimSpace(internal.Colorize(resumeHelp))
+	reconcileHelp = strings.TrimSpace(internal.Colorize(reconcileHelp))
+}
+
+func GetAnnotateParams(settings GlobalSettings, name, help string, args []string) (*AnnotateParams, error) {
+	flagset := flag.NewFlagSet(name, flag.ExitOnError)
+
+	flagset.Usage = func() {
+		fmt.Fprintln(flagset.Output(), help)
+		flagset.PrintDefaults()
+	}
+
+	params := AnnotateParams{GlobalSettings: settings}
+
+	RegisterGlobalFlags(flagset, &params.GlobalSettings)
+
+	flagset.StringVar(&params.Namespace, "namespace", "", "namespace of the resource, defaults to context namespace if not provided")
+
+	flagset.Parse(args)
+
+	params.Resource = flagset.Arg(0)
+	if params.Resource == "" {
+		return nil, fmt.Errorf("resource is required")
+	}
+
+	return &params, nil
+}
+
+// Suspend stops the ATC from taking off the resource and fixing its drift until it is resumed.
+func Suspend(ctx context.Context, params AnnotateParams) error {
+	return annotate(ctx, params, map[string]any{v1alpha1.AnnotationSuspend: "true"})
+}
+
+// Resume removes the suspension of the resource set by Suspend.
+func Resume(ctx context.Context, params AnnotateParams) error {
+	return annotate(ctx, params, map[string]any{v1alpha1.AnnotationSuspend: nil})
+}
+
+// Reconcile requests the immediate evaluation of the resource by the ATC.
+func Reconcile(ctx context.Context, params AnnotateParams) error {
+	return annotate(ctx, params, map[string]any{v1alpha1.AnnotationReconcileRequestedAt: time.Now().UTC().Format(time.RFC3339Nano)})
+}
+
+// annotate merge patches the annotations of the resource. Annotations with a nil value are removed.
+func annotate(ctx context.Context, params AnnotateParams, annotations map[string]any) error {
+	client, err := k8s.NewClientFromConfigFlags(params.Kube)
+	if err != nil {
+		return fmt.Errorf("failed to initialize kube client: %w", err)
+	}
+
+	resourceArg, name, ok := strings.Cut(params.Resource, "/")
+	if !ok || resourceArg == "" || name == "" {
+		return fmt.Errorf("invalid resource %q: expected <resource>/<name>", params.Resource)
+	}
+
+	intf, err := resourceInterface(client, resourceArg, cmp.Or(params.Namespace, client.DefaultNamespace))
+	if err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"annotations": annotations,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to serialize patch: %w", err)
+	}
+
+	if _, err := intf.Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to annotate %s: %w", params.Resource, err)
+	}
+
+	return nil
+}
+
+// resourceInterface returns the interface of the resource type such as flights or flights.yoke.cd, scoped to the namespace if the type is namespaced.
+func resourceInterface(client *k8s.Client, resource string, namespace string) (dynamic.ResourceInterface, error) {
+	partial := schema.ParseGroupResource(resource).WithVersion("")
+	if gvr, _ := schema.ParseResourceArg(resource); gvr != nil {
+		partial = *gvr
+	}
+
+	gvk, err := client.Mapper.KindFor(partial)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup kind of %q: %w", resource, err)
+	}
+
+	mapping, err := client.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lookup mapping of %s: %w", gvk.GroupKind(), err)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return client.Dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+	}
+
+	return client.Dynamic.Resource(mapping.Resource), nil
+}
@@ -13,6 +13,7 @@ import (
 	"golang.org/x/term"
 
 	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/wasi/host"
 	"github.com/yokecd/yoke/pkg/yoke"
 )
 
@@ -51,15 +52,20 @@ func GetTakeoffParams(settings GlobalSettings, source io.Reader, args []string)
 	flagset.BoolVar(&params.ForceConflicts, "force-conflicts", false, "force apply changes on field manager conflicts")
 	flagset.BoolVar(&params.ForceOwnership, "force-ownership", false, "take ownership of resources during takeoff of resources even if they belong to another release")
 
+	flagset.BoolVar(&params.Atomic, "atomic", false, "if a stage fails to apply or become ready, rollback to the previous revision and prune any resources created by the failed attempt")
 	flagset.BoolVar(&params.Lock, "lock", false, "if enabled does locks release before deploying revision (only prevents other locked runs from running).")
 	flagset.BoolVar(&params.CreateNamespace, "create-namespace", false, "create namespace of target release if not present")
 	flagset.BoolVar(&params.CrossNamespace, "cross-namespace", false, "allows releases to create resources in other namespaces than the target namespace")
 	flagset.BoolVar(&params.ClusterAccess.Enabled, "cluster-access", false, "allows flight access to the cluster during takeoff. Only applies when not directing output to stdout or to a local destination.")
 	flagset.BoolVar(&params.Flight.Insecure, "insecure", false, "allows image references to be fetched without TLS (only applies to oci urls)")
 	flagset.Uint64Var(&params.Flight.MaxMemoryMib, "max-memory-mib", 128, "max memory a flight is allowed to allocate at runtime. Max is 4096.")
+	flagset.Uint64Var(&params.Flight.MaxFunctionCalls, "max-function-calls", 0, "max number of wasm function calls a flight is allowed to execute. Zero means unlimited.")
+	flagset.BoolVar(&params.Flight.Deterministic, "deterministic", false, "run the flight with seeded randomness and a fixed clock such that identical inputs produce identical outputs")
+	flagset.BoolVar(&params.VerifyDeterminism, "verify-determinism", false, "evaluate the flight twice and fail if the outputs differ")
 	flagset.DurationVar(&params.Flight.Timeout, "timeout", 10*time.Second, "timeout for flight execution. Setting to 0 keeps the default 10 seconds. To remove timeouts completely use a negative duration")
 
 	flagset.BoolVar(&params.DiffOnly, "diff-only", false, "show diff between current revision and would be applied state. Does not apply anything to cluster")
+	flagset.BoolVar(&params.ServerSideDiff, "server-side-diff", false, "diff live cluster state against a server-side apply dry-run and list resources that would be pruned or change ownership (ignored if not using --diff-only)")
 	flagset.BoolVar(&params.Color, "color", term.IsTerminal(int(os.Stdout.Fd())), "use colored output in diffs")
 	flagset.IntVar(&params.Context, "context", 4, "number of lines of context in diff (ignored if not using --diff-only)")
 	flagset.StringVar(&params.Out, "out", "", "if present outputs flight resources to directory specified, if out is - outputs to standard out")
@@ -82,6 +88,15 @@ func GetTakeoffParams(settings GlobalSettings, source io.Reader, args []string)
 		},
 	)
 
+	flagset.Func(
+		"http-access",
+		"allows flights to fetch urls matching glob pattern. This flag can be set many times and patterns can be comma separated.",
+		func(s string) error {
+			params.HTTPAccess.URLMatchers = append(params.HTTPAccess.URLMatchers, strings.Split(s, ",")...)
+			return nil
+		},
+	)
+
 	flagset.BoolVar(&params.LoadCustomReadiness, "load-custom-readiness", false, "loads custom resource readiness validation functions from cluster")
 
 	var removeAll bool
@@ -108,11 +123,13 @@ func GetTakeoffParams(settings GlobalSettings, source io.Reader, args []string)
 		return nil, fmt.Errorf("flight-path is required as second position arg")
 	}
 
+	params.OutputFormat = params.Output
+
 	return &params, nil
 }
 
 func TakeOff(ctx context.Context, params TakeoffParams) error {
-	commander, err := yoke.FromKubeConfigFlags(params.Kube)
+	commander, err := NewCommander(params.GlobalSettings)
 	if err != nil {
 		return err
 	}
@@ -120,5 +137,14 @@ func TakeOff(ctx context.Context, params TakeoffParams) error {
 	// We want the CLI to stream stderr back to the user instead of buffering.
 	params.Flight.Stderr = internal.Stderr(ctx)
 
+	ctx = host.WithDiagnostics(ctx)
+
+	// Diagnostics are printed regardless of the outcome of the takeoff as they may help explain a failure.
+	defer func() {
+		if diagnostics := host.Diagnostics(ctx); len(diagnostics) > 0 {
+			fmt.Fprintf(internal.Stderr(ctx), "\nflight diagnostics:\n%s\n", host.FormatDiagnostics(diagnostics))
+		}
+	}()
+
 	return commander.Takeoff(ctx, params.TakeoffParams)
 }
@@ -60,12 +60,13 @@ func GetTurbulenceParams(settings GlobalSettings, args []string) (*TurbulencePar
 	}
 
 	params.ConflictsOnly = params.ConflictsOnly || params.Fix
+	params.OutputFormat = params.Output
 
 	return &params, nil
 }
 
 func Turbulence(ctx context.Context, params TurbulenceParams) error {
-	commander, err := yoke.FromKubeConfigFlags(params.Kube)
+	commander, err := NewCommander(params.GlobalSettings)
 	if err != nil {
 		return err
 	}
@@ -48,7 +48,7 @@ func GetUnlatchParams(settings GlobalSettings, args []string) (*UnlatchParams, e
 }
 
 func Unlatch(ctx context.Context, params UnlatchParams) error {
-	commander, err := yoke.FromKubeConfigFlags(params.Kube)
+	commander, err := NewCommander(params.GlobalSettings)
 	if err != nil {
 		return err
 	}
@@ -19,6 +19,7 @@ import (
 
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/home"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/pkg/yoke"
 )
 
@@ -60,6 +61,16 @@ func run() error {
 
 	ctx = internal.WithDebugFlag(ctx, settings.Debug)
 
+	shutdownTracing, err := tracing.Setup(ctx, "yoke")
+	if err != nil {
+		return fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to flush traces: %v\n", err)
+		}
+	}()
+
 	if len(flag.Args()) == 0 {
 		flag.Usage()
 		return fmt.Errorf("no command provided")
@@ -130,9 +141,33 @@ func run() error {
 			}
 			return Unlatch(ctx, *params)
 		}
+	case "suspend":
+		{
+			params, err := GetAnnotateParams(settings, "suspend", suspendHelp, subcmdArgs)
+			if err != nil {
+				return err
+			}
+			return Suspend(ctx, *params)
+		}
+	case "resume":
+		{
+			params, err := GetAnnotateParams(settings, "resume", resumeHelp, subcmdArgs)
+			if err != nil {
+				return err
+			}
+			return Resume(ctx, *params)
+		}
+	case "reconcile":
+		{
+			params, err := GetAnnotateParams(settings, "reconcile", reconcileHelp, subcmdArgs)
+			if err != nil {
+				return err
+			}
+			return Reconcile(ctx, *params)
+		}
 	case "schematics", "meta":
 		{
-			return SchematicsCommand(ctx, subcmdArgs)
+			return SchematicsCommand(ctx, settings, subcmdArgs)
 		}
 
 	case "sign":
@@ -162,12 +197,37 @@ func run() error {
 }
 
 type GlobalSettings struct {
-	Kube  *genericclioptions.ConfigFlags
-	Debug *bool
+	Kube         *genericclioptions.ConfigFlags
+	Debug        *bool
+	Output       internal.OutputFormat
+	ReleaseStore string
 }
 
 func RegisterGlobalFlags(flagset *flag.FlagSet, settings *GlobalSettings) {
 	flagset.StringVar(settings.Kube.KubeConfig, "kubeconfig", cmp.Or(*settings.Kube.KubeConfig, os.Getenv("KUBECONFIG"), home.Kubeconfig), "path to kube config")
 	flagset.StringVar(settings.Kube.Context, "kube-context", *settings.Kube.Context, "kubernetes context to use")
 	flagset.BoolVar(settings.Debug, "debug", *settings.Debug, "debug output mode")
+	flagset.Func("output", "output format for command results: json or yaml. Defaults to human readable text", func(value string) (err error) {
+		settings.Output, err = internal.ParseOutputFormat(value)
+		return err
+	})
+	flagset.StringVar(
+		&settings.ReleaseStore,
+		"release-store",
+		cmp.Or(settings.ReleaseStore, os.Getenv("YOKE_RELEASE_STORE")),
+		"where release history is stored: secret, chunked-secret, or file://<dir>",
+	)
+}
+
+// NewCommander returns a commander for the kube config and release store of the global settings.
+func NewCommander(settings GlobalSettings) (*yoke.Commander, error) {
+	commander, err := yoke.FromKubeConfigFlags(settings.Kube)
+	if err != nil {
+		return nil, err
+	}
+	store, err := commander.ParseReleaseStore(settings.ReleaseStore)
+	if err != nil {
+		return nil, err
+	}
+	return commander.WithReleaseStore(store), nil
 }
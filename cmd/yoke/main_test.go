@@ -300,6 +300,359 @@ func TestCreateWithWait(t *testing.T) {
 	// either directly within yoke or within client-go, hence we capture the cause and the top level message only
 	require.Contains(t, err.Error(), "release did not become ready within wait period: to rollback use `yoke descent`: failed to get readiness for default/apps/v1/deployment/sample-app")
 	require.Contains(t, err.Error(), "1ns timeout reached")
+
+	// The failed attempt is recorded in the release history and its resources must be cleaned up.
+	require.NoError(t, mayday())
+}
+
+func TestTakeoffAtomic(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client)
+
+	_ = commander.Mayday(background, yoke.MaydayParams{Release: "foo"})
+	defer func() {
+		require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "foo"}))
+	}()
+
+	configmap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+			Data: map[string]string{"key": value},
+		}
+	}
+
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "foo",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(configmap("a")),
+		},
+	}))
+
+	var deployment appsv1.Deployment
+	require.NoError(t, json.NewDecoder(createBasicDeployment(t, "sample-app", "")).Decode(&deployment))
+
+	// An image that cannot be pulled guarantees the deployment never becomes ready.
+	deployment.Spec.Template.Spec.Containers[0].Image = "yokecd/does-not-exist:latest"
+
+	err = commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "foo",
+		Atomic:  true,
+		Wait:    5 * time.Second,
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader([]any{configmap("b"), deployment}),
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "release did not become ready within wait period")
+	require.Contains(t, err.Error(), "rolled back to previous revision")
+
+	cm, err := client.Clientset.CoreV1().ConfigMaps("default").Get(background, "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "a", cm.Data["key"])
+
+	_, err = client.Clientset.AppsV1().Deployments("default").Get(background, "sample-app", metav1.GetOptions{})
+	require.True(t, kerrors.IsNotFound(err), "expected deployment to be pruned but got: %v", err)
+
+	release, err := client.GetRelease(background, "foo", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 2)
+
+	require.Equal(t, internal.RevisionStatusActive, release.History[0].Status)
+	require.Equal(t, internal.RevisionStatusFailed, release.History[1].Status)
+	require.Equal(t, 1, release.History[1].FailedStage)
+	require.Contains(t, release.History[1].Error, "release did not become ready within wait period")
+}
+
+func TestTakeoffAtomicForceOwnership(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client)
+
+	for _, release := range []string{"foo", "bar"} {
+		_ = commander.Mayday(background, yoke.MaydayParams{Release: release})
+		defer func() {
+			require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: release}))
+		}()
+	}
+
+	shared := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "shared",
+		},
+		Data: map[string]string{"key": "value"},
+	}
+
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "bar",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(shared),
+		},
+	}))
+
+	var deployment appsv1.Deployment
+	require.NoError(t, json.NewDecoder(createBasicDeployment(t, "sample-app", "")).Decode(&deployment))
+
+	deployment.Spec.Template.Spec.Containers[0].Image = "yokecd/does-not-exist:latest"
+
+	err = commander.Takeoff(background, yoke.TakeoffParams{
+		Release:        "foo",
+		Atomic:         true,
+		ForceOwnership: true,
+		Wait:           5 * time.Second,
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader([]any{shared, deployment}),
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rolled back by removing all applied resources")
+
+	// The configmap did not belong to foo before the attempt and must not be removed by its rollback.
+	_, err = client.Clientset.CoreV1().ConfigMaps("default").Get(background, "shared", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// The configmap is now owned by foo which has no active revision, and must be cleaned up explicitly.
+	defer func() {
+		require.NoError(t, client.Clientset.CoreV1().ConfigMaps("default").Delete(background, "shared", metav1.DeleteOptions{}))
+	}()
+
+	_, err = client.Clientset.AppsV1().Deployments("default").Get(background, "sample-app", metav1.GetOptions{})
+	require.True(t, kerrors.IsNotFound(err), "expected deployment to be pruned but got: %v", err)
+}
+
+func TestFailedRevisionHistory(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client)
+
+	_ = commander.Mayday(background, yoke.MaydayParams{Release: "foo"})
+
+	configmap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "foo",
+			},
+			Data: map[string]string{"key": value},
+		}
+	}
+
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "foo",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(configmap("a")),
+		},
+	}))
+
+	var deployment appsv1.Deployment
+	require.NoError(t, json.NewDecoder(createBasicDeployment(t, "sample-app", "")).Decode(&deployment))
+
+	deployment.Spec.Template.Spec.Containers[0].Image = "yokecd/does-not-exist:latest"
+
+	require.ErrorContains(
+		t,
+		commander.Takeoff(background, yoke.TakeoffParams{
+			Release: "foo",
+			Wait:    5 * time.Second,
+			Flight: yoke.FlightParams{
+				Input: internal.JSONReader([]any{configmap("b"), deployment}),
+			},
+		}),
+		"release did not become ready within wait period: to rollback use `yoke descent`",
+	)
+
+	release, err := client.GetRelease(background, "foo", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 2)
+
+	require.Equal(t, internal.RevisionStatusActive, release.History[0].Status)
+	require.Equal(t, internal.RevisionStatusFailed, release.History[1].Status)
+	require.Equal(t, 0, release.ActiveIndex())
+
+	require.EqualError(
+		t,
+		commander.Descent(background, yoke.DescentParams{Release: "foo", RevisionID: 2}),
+		"cannot descend to revision 2: revision failed at stage 1",
+	)
+
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "foo",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(configmap("c")),
+		},
+	}))
+
+	release, err = client.GetRelease(background, "foo", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 3)
+
+	require.Equal(
+		t,
+		[]internal.RevisionStatus{
+			internal.RevisionStatusSuperseded,
+			internal.RevisionStatusFailed,
+			internal.RevisionStatusActive,
+		},
+		[]internal.RevisionStatus{
+			release.History[0].Status,
+			release.History[1].Status,
+			release.History[2].Status,
+		},
+	)
+
+	// The deployment from the failed attempt is not part of the new revision and must have been pruned.
+	_, err = client.Clientset.AppsV1().Deployments("default").Get(background, "sample-app", metav1.GetOptions{})
+	require.True(t, kerrors.IsNotFound(err), "expected deployment to be pruned but got: %v", err)
+
+	require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "foo"}))
+}
+
+func TestRepeatedFailedTakeoffsKeepHistory(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client)
+
+	_ = commander.Mayday(background, yoke.MaydayParams{Release: "foo"})
+
+	configmap := func(namespace, value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: namespace,
+			},
+			Data: map[string]string{"key": value},
+		}
+	}
+
+	for _, value := range []string{"a", "b"} {
+		require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+			Release:        "foo",
+			HistoryCapSize: 3,
+			Flight: yoke.FlightParams{
+				Input: internal.JSONReader(configmap("", value)),
+			},
+		}))
+	}
+
+	// The second stage fails its dry-run on every attempt after the first stage has been applied.
+	for range 5 {
+		require.ErrorContains(
+			t,
+			commander.Takeoff(background, yoke.TakeoffParams{
+				Release:        "foo",
+				HistoryCapSize: 3,
+				Flight: yoke.FlightParams{
+					Input: internal.JSONReader([][]any{
+						{configmap("", "c")},
+						{configmap("does-not-exist", "c")},
+					}),
+				},
+			}),
+			`namespaces "does-not-exist" not found`,
+		)
+	}
+
+	release, err := client.GetRelease(background, "foo", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 3)
+
+	require.Equal(
+		t,
+		[]internal.RevisionStatus{
+			internal.RevisionStatusSuperseded,
+			internal.RevisionStatusActive,
+			internal.RevisionStatusFailed,
+		},
+		[]internal.RevisionStatus{
+			release.History[0].Status,
+			release.History[1].Status,
+			release.History[2].Status,
+		},
+	)
+	require.Equal(t, 2, release.History[2].FailedStage)
+
+	require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "foo"}))
+}
+
+func TestRetakeoffAfterFailedAttemptPrunesAttemptedResources(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client)
+
+	_ = commander.Mayday(background, yoke.MaydayParams{Release: "foo"})
+
+	configmap := func(namespace, name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Data: map[string]string{"key": "value"},
+		}
+	}
+
+	takeoff := func(input any) error {
+		return commander.Takeoff(background, yoke.TakeoffParams{
+			Release: "foo",
+			Flight:  yoke.FlightParams{Input: internal.JSONReader(input)},
+		})
+	}
+
+	require.NoError(t, takeoff([]any{configmap("", "kept")}))
+	defer func() {
+		require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "foo"}))
+	}()
+
+	// The first stage creates a new resource before the second stage fails.
+	require.ErrorContains(
+		t,
+		takeoff([][]any{
+			{configmap("", "kept"), configmap("", "attempted")},
+			{configmap("does-not-exist", "kept")},
+		}),
+		`namespaces "does-not-exist" not found`,
+	)
+
+	_, err = client.Clientset.CoreV1().ConfigMaps("default").Get(background, "attempted", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// Taking off with the resources of the active revision again removes what the failed attempt created.
+	require.NoError(t, takeoff([]any{configmap("", "kept")}))
+
+	_, err = client.Clientset.CoreV1().ConfigMaps("default").Get(background, "attempted", metav1.GetOptions{})
+	require.True(t, kerrors.IsNotFound(err), "expected attempted configmap to be pruned but got: %v", err)
+
+	_, err = client.Clientset.CoreV1().ConfigMaps("default").Get(background, "kept", metav1.GetOptions{})
+	require.NoError(t, err)
+
+	// Once nothing is left to prune, the takeoff is a noop again.
+	require.True(t, internal.IsNoopErr(takeoff([]any{configmap("", "kept")})))
 }
 
 func TestFailApplyDryRun(t *testing.T) {
@@ -805,6 +1158,87 @@ func TestTakeoffDiffOnly(t *testing.T) {
 	require.Equal(t, "--- current\n+++ next\n@@ -4 +4 @@\n-    foo: bar\n+    baz: boop\n", stdout.String())
 }
 
+func TestTakeoffServerSideDiff(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client)
+
+	configmap := func(name string, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: "v1",
+				Kind:       "ConfigMap",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name: name,
+			},
+			Data: data,
+		}
+	}
+
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "foo",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader([]any{
+				configmap("kept", map[string]string{"foo": "bar"}),
+				configmap("removed", map[string]string{"foo": "bar"}),
+				configmap("stolen", map[string]string{"foo": "bar"}),
+			}),
+		},
+	}))
+	defer func() {
+		require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "foo"}))
+	}()
+
+	// Resources of the previous revision since taken over by another release are not pruned.
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release:        "bar",
+		ForceOwnership: true,
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader([]any{configmap("stolen", map[string]string{"foo": "bar"})}),
+		},
+	}))
+	defer func() {
+		require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "bar"}))
+	}()
+
+	// Resources that pre-exist outside of any release are adopted by the release.
+	_, err = client.Clientset.CoreV1().ConfigMaps("default").Create(background, configmap("adopted", nil), metav1.CreateOptions{})
+	require.NoError(t, err)
+	defer func() {
+		_ = client.Clientset.CoreV1().ConfigMaps("default").Delete(background, "adopted", metav1.DeleteOptions{})
+	}()
+
+	var stdout bytes.Buffer
+
+	require.NoError(t, commander.Takeoff(internal.WithStdout(background, &stdout), yoke.TakeoffParams{
+		Release:        "foo",
+		DiffOnly:       true,
+		ServerSideDiff: true,
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader([]any{
+				configmap("kept", map[string]string{"baz": "boop"}),
+				configmap("adopted", map[string]string{"hello": "world"}),
+			}),
+		},
+	}))
+
+	output := stdout.String()
+
+	require.Contains(t, output, "--- live\n+++ dry-run\n")
+	require.Contains(t, output, "-    foo: bar\n+    baz: boop\n")
+	require.Contains(t, output, "+    hello: world\n")
+	require.Contains(t, output, "resources to be pruned:\n  - default/core/v1/configmap/removed\n")
+	require.NotContains(t, output, "configmap/stolen")
+	require.Contains(t, output, "resources changing ownership:\n  - default/core/v1/configmap/adopted: \"\" -> \"default/foo\"\n")
+
+	// A diff must not change any state in the cluster.
+	kept, err := client.Clientset.CoreV1().ConfigMaps("default").Get(background, "kept", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"foo": "bar"}, kept.Data)
+}
+
 func TestDescent(t *testing.T) {
 	rest, err := clientcmd.BuildConfigFromFlags("", home.Kubeconfig)
 	require.NoError(t, err)
@@ -954,6 +1388,28 @@ func TestTurbulenceFix(t *testing.T) {
 		stdout.String(),
 	)
 
+	stdout.Reset()
+
+	require.NoError(
+		t,
+		Turbulence(ctx, TurbulenceParams{
+			GlobalSettings: settings,
+			TurbulenceParams: yoke.TurbulenceParams{
+				Release:       "foo",
+				ConflictsOnly: true,
+				OutputFormat:  internal.OutputFormatJSON,
+			},
+		}),
+	)
+
+	var report yoke.TurbulenceReport
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &report))
+	require.Equal(t, "foo", report.Release)
+	require.Len(t, report.Resources, 1)
+	require.Equal(t, "default/core/v1/configmap/test", report.Resources[0].Resource)
+	require.True(t, report.Resources[0].Drifted)
+	require.Equal(t, map[string]any{"key": "corrupt"}, report.Resources[0].Actual["data"])
+
 	require.NoError(
 		t,
 		Turbulence(
@@ -1834,6 +2290,43 @@ func TestReleasePrefix(t *testing.T) {
 	require.Equal(t, "ConfigMap", resources[0].GetKind())
 }
 
+func TestChunkedSecretReleaseStore(t *testing.T) {
+	client, err := k8s.NewClientFromKubeConfig(home.Kubeconfig)
+	require.NoError(t, err)
+
+	commander := yoke.FromK8Client(client).WithReleaseStore(k8s.NewChunkedSecretStore(client, 64))
+
+	require.NoError(t, commander.Takeoff(background, yoke.TakeoffParams{
+		Release: "foo",
+		Flight: yoke.FlightParams{
+			Input: internal.JSONReader(map[string]any{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]any{"name": "chunked"},
+				"data":       map[string]any{"key": strings.Repeat("value", 100)},
+			}),
+		},
+	}))
+	defer func() {
+		require.NoError(t, commander.Mayday(background, yoke.MaydayParams{Release: "foo"}))
+	}()
+
+	release, err := client.GetRelease(background, "foo", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 1)
+
+	secret, err := client.Clientset.CoreV1().Secrets("default").Get(background, release.History[0].Name, metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotEqual(t, "", secret.Annotations[k8s.AnnotationChunks])
+	require.NotEqual(t, "1", secret.Annotations[k8s.AnnotationChunks])
+
+	// The default store reads chunked revisions.
+	stages, err := client.GetRevisionResources(background, release.ActiveRevision())
+	require.NoError(t, err)
+	require.Len(t, stages.Flatten(), 1)
+	require.Equal(t, "chunked", stages.Flatten()[0].GetName())
+}
+
 func TestDefaultNamespace(t *testing.T) {
 	require.NoError(t, x.X("go build -o ./test_output/name.wasm ./internal/testing/flights/name", x.Env("GOOS=wasip1", "GOARCH=wasm")))
 
@@ -34,6 +34,7 @@ type Parameters struct {
 	Args             []string
 	ResourceMatchers []string
 	ClusterAccess    bool
+	HTTPAccess       []string
 	MaxMemoryMib     uint32
 	Timeout          time.Duration
 	Checksum         string
@@ -105,6 +106,9 @@ func (parameters *Parameters) UnmarshalText(data []byte) (err error) {
 	clusterAccess, _ := internal.Find(elems, func(param CmpParam) bool { return param.Name == "clusterAccess" })
 	parameters.ClusterAccess, _ = strconv.ParseBool(clusterAccess.String)
 
+	httpAccess, _ := internal.Find(elems, func(param CmpParam) bool { return param.Name == "httpAccess" })
+	parameters.HTTPAccess = httpAccess.Array
+
 	parameters.MaxMemoryMib, err = func() (uint32, error) {
 		param, ok := internal.Find(elems, func(param CmpParam) bool { return param.Name == "maxMemoryMib" })
 		if !ok {
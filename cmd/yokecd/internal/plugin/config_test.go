@@ -99,6 +99,17 @@ func TestConfigUnmarshalling(t *testing.T) {
 				ResourceMatchers: []string{"ConfigMap", "default/apps.Deployment"},
 			},
 		},
+		{
+			Name: "http access",
+			Input: `[
+				{ name: build, string: 'true' },
+				{ name: httpAccess, array: ['https://config.internal/*'] }
+			]`,
+			Expected: Parameters{
+				Build:      true,
+				HTTPAccess: []string{"https://config.internal/*"},
+			},
+		},
 		{
 			Name: "set max memory in range",
 			Input: `[
@@ -62,7 +62,7 @@ func Run(ctx context.Context, cfg Config) (err error) {
 		return fmt.Errorf("failed to lookup secret references: %w", err)
 	}
 
-	data, err := func() ([]byte, error) {
+	resp, err := func() (*svr.ExecResponse, error) {
 		if cfg.Flight.Build {
 			cfg.Flight.Wasm, err = goBuild()
 			if err != nil {
@@ -142,6 +142,9 @@ func Run(ctx context.Context, cfg Config) (err error) {
 				Enabled:          cfg.Flight.ClusterAccess,
 				ResourceMatchers: cfg.Flight.ResourceMatchers,
 			},
+			HTTPAccess: host.HTTPAccessParams{
+				URLMatchers: cfg.Flight.HTTPAccess,
+			},
 			Insecure: cfg.Flight.Insecure,
 		})
 	}()
@@ -149,9 +152,15 @@ func Run(ctx context.Context, cfg Config) (err error) {
 		return fmt.Errorf("failed to execute flight wasm: %w", err)
 	}
 
-	stages, err := internal.ParseStages(data)
+	// ArgoCD captures the stderr of config management plugins, and it is the only channel through which
+	// diagnostics can be returned without altering the generated manifests.
+	for _, diagnostic := range resp.Diagnostics {
+		fmt.Fprintf(internal.Stderr(ctx), "flight diagnostic: %s\n", diagnostic)
+	}
+
+	stages, err := internal.ParseStages(resp.Stdout)
 	if err != nil {
-		return fmt.Errorf("failed to parse output into valid flight output: %w\n\nGot: %q", err, data)
+		return fmt.Errorf("failed to parse output into valid flight output: %w\n\nGot: %q", err, resp.Stdout)
 	}
 
 	addSyncWaveAnnotations(stages)
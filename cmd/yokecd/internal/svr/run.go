@@ -134,6 +134,7 @@ type Mod struct {
 type ExecuteReq struct {
 	Source        []byte
 	ClusterAccess yoke.ClusterAccessParams
+	HTTPAccess    yoke.HTTPAccessParams
 
 	Path         string
 	Checksum     string
@@ -148,8 +149,9 @@ type ExecuteReq struct {
 }
 
 type ExecResponse struct {
-	Stdout json.RawMessage
-	Stderr string
+	Stdout      json.RawMessage
+	Stderr      string
+	Diagnostics []host.Diagnostic
 }
 
 func Handler(mods *cache.ModuleCache, logger *slog.Logger, client *k8s.Client) http.Handler {
@@ -167,7 +169,7 @@ func Handler(mods *cache.ModuleCache, logger *slog.Logger, client *k8s.Client) h
 		mod, err := func() (*wasi.Module, error) {
 			attrs := cache.ModuleAttrs{
 				MaxMemoryMib:    ex.MaxMemoryMib,
-				HostFunctionMap: host.BuildFunctionMap(client),
+				HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(client)),
 			}
 			if len(ex.Source) > 0 {
 				return mods.FromSource(r.Context(), ex.Source, attrs)
@@ -191,9 +193,12 @@ func Handler(mods *cache.ModuleCache, logger *slog.Logger, client *k8s.Client) h
 			return
 		}
 
-		output, err := yoke.EvalFlight(r.Context(), yoke.EvalParams{
+		ctx := host.WithDiagnostics(r.Context())
+
+		output, err := yoke.EvalFlight(ctx, yoke.EvalParams{
 			Client:        client,
 			ClusterAccess: ex.ClusterAccess,
+			HTTPAccess:    ex.HTTPAccess,
 			Release:       ex.Release,
 			Namespace:     ex.Namespace,
 			Flight: yoke.FlightParams{
@@ -209,7 +214,12 @@ func Handler(mods *cache.ModuleCache, logger *slog.Logger, client *k8s.Client) h
 			return
 		}
 
-		if err := json.NewEncoder(w).Encode(json.RawMessage(output)); err != nil {
+		resp := ExecResponse{
+			Stdout:      output,
+			Diagnostics: host.Diagnostics(ctx),
+		}
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
 			xhttp.AddRequestAttrs(
 				r.Context(),
 				slog.String("error", fmt.Sprintf("failed to write to http.ResponseWriter: %v", err)),
@@ -227,7 +237,7 @@ func Handler(mods *cache.ModuleCache, logger *slog.Logger, client *k8s.Client) h
 	return handler
 }
 
-func Exec(ctx context.Context, ex ExecuteReq) (json.RawMessage, error) {
+func Exec(ctx context.Context, ex ExecuteReq) (*ExecResponse, error) {
 	defer internal.DebugTimer(ctx, "http::exec")()
 
 	data, err := json.Marshal(ex)
@@ -255,5 +265,10 @@ func Exec(ctx context.Context, ex ExecuteReq) (json.RawMessage, error) {
 		return nil, fmt.Errorf("error: %s", result)
 	}
 
-	return result, nil
+	var response ExecResponse
+	if err := json.Unmarshal(result, &response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return &response, nil
 }
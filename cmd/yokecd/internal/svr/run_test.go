@@ -93,7 +93,7 @@ func TestPluginServer(t *testing.T) {
 			 },
 			"input":"banana hamock"
 		}`,
-		string(echo),
+		string(echo.Stdout),
 	)
 
 	require.Equal(t, 1, modCount())
@@ -211,7 +211,7 @@ func TestPluginServerLookup(t *testing.T) {
 	})
 	require.ErrorContains(t, err, "forbidden: cannot access resource outside of target release ownership")
 
-	data, err := Exec(context.Background(), ExecuteReq{
+	resp, err := Exec(context.Background(), ExecuteReq{
 		Path:          sourceServer.URL,
 		Release:       "foo",
 		Namespace:     "bar",
@@ -221,7 +221,7 @@ func TestPluginServerLookup(t *testing.T) {
 	require.NoError(t, err)
 
 	var actual corev1.ConfigMap
-	require.NoError(t, json.Unmarshal(data, &actual))
+	require.NoError(t, json.Unmarshal(resp.Stdout, &actual))
 
 	require.Equal(t, "value", actual.Data["key"])
 }
@@ -2,6 +2,7 @@ package atc
 
 import (
 	"sync"
+	"time"
 
 	"github.com/davidmdm/x/xsync"
 
@@ -17,16 +18,27 @@ const (
 	AnnotationInstanceRef  = "instance.atc.yoke.cd/instanceRef"
 )
 
+// DefaultHTTPRefreshInterval is the interval at which instances in dynamic mode that fetched URLs are requeued,
+// unless set by the Airway.
+const DefaultHTTPRefreshInterval = 5 * time.Minute
+
 type InstanceState struct {
 	Mode             v1alpha1.AirwayMode
 	Mutex            *sync.RWMutex
 	ClusterAccess    bool
 	TrackedResources *xsync.Set[string]
+	// Suspended instances are not taken off, hence their child resources are not checked against desired state at admission.
+	Suspended bool
+	// ReconcileRequestedAt is the last value of the v1alpha1.AnnotationReconcileRequestedAt annotation seen by the reconciler.
+	ReconcileRequestedAt string
+	// ReleaseMigrated is set once the release of the instance is known to use its resource reference name. See RenameRelease.
+	ReleaseMigrated bool
 }
 
-func GetAirwayReconciler(service ServiceDef, cache *cache.ModuleCache, dispatcher *EventDispatcher, states *xsync.Map[string, InstanceState]) ctrl.Funcs {
+func GetAirwayReconciler(service ServiceDef, shard Shard, cache *cache.ModuleCache, dispatcher *EventDispatcher, states *xsync.Map[string, InstanceState]) ctrl.Funcs {
 	atc := atc{
 		service:      service,
+		shard:        shard,
 		cleanups:     map[string]func(){},
 		moduleCache:  cache,
 		dispatcher:   dispatcher,
@@ -42,6 +54,7 @@ type atc struct {
 	dispatcher   *EventDispatcher
 	flightStates *xsync.Map[string, InstanceState]
 	service      ServiceDef
+	shard        Shard
 	cleanups     map[string]func()
 	moduleCache  *cache.ModuleCache
 }
@@ -0,0 +1,54 @@
+package atc
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/yokecd/yoke/internal/wasi/host"
+)
+
+// recordDiagnostics emits an event for every diagnostic reported by a flight.
+// Informational diagnostics are normal events, warnings and deprecations are warning events.
+func recordDiagnostics(recorder record.EventRecorder, ref *corev1.ObjectReference, diagnostics []host.Diagnostic) {
+	for _, diagnostic := range diagnostics {
+		eventType := corev1.EventTypeWarning
+		if diagnostic.Severity == host.SeverityInfo {
+			eventType = corev1.EventTypeNormal
+		}
+		recorder.Event(ref, eventType, ReasonFlightDiagnostic, diagnostic.String())
+	}
+}
+
+// diagnosticsCondition returns the Diagnostics condition reflecting the diagnostics reported by the last evaluation of a flight.
+// Its message only changes when the diagnostics do such that setting it on every reconciliation does not trigger new ones.
+func diagnosticsCondition(generation int64, diagnostics []host.Diagnostic) metav1.Condition {
+	if len(diagnostics) == 0 {
+		return metav1.Condition{
+			Type:               "Diagnostics",
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: generation,
+			Reason:             "NoDiagnostics",
+			Message:            "Flight reported no diagnostics",
+		}
+	}
+
+	reason := "Info"
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity == host.SeverityDeprecation {
+			reason = "Deprecation"
+			break
+		}
+		if diagnostic.Severity == host.SeverityWarning {
+			reason = "Warning"
+		}
+	}
+
+	return metav1.Condition{
+		Type:               "Diagnostics",
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            host.FormatDiagnostics(diagnostics),
+	}
+}
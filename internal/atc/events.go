@@ -0,0 +1,67 @@
+package atc
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+// Reasons of the kubernetes events emitted by the atc reconcilers.
+const (
+	ReasonTakeoffStarted       = "TakeoffStarted"
+	ReasonTakeoffSucceeded     = "TakeoffSucceeded"
+	ReasonTakeoffFailed        = "TakeoffFailed"
+	ReasonReady                = "Ready"
+	ReasonNotReady             = "NotReady"
+	ReasonMayday               = "Mayday"
+	ReasonMaydayFailed         = "MaydayFailed"
+	ReasonModuleFetchFailed    = "ModuleFetchFailed"
+	ReasonAdmissionDenied      = "AdmissionDenied"
+	ReasonControllerLaunched   = "ControllerLaunched"
+	ReasonControllerRelaunched = "ControllerRelaunched"
+	ReasonFlightDiagnostic     = "FlightDiagnostic"
+	ReasonRolloutPaused        = "RolloutPaused"
+	ReasonSuspended            = "Suspended"
+	ReasonReconcileRequested   = "ReconcileRequested"
+)
+
+// EventRef returns the reference used as the involved object of events about the given object.
+// Using a reference instead of the object itself avoids needing the object's type to be registered with a scheme.
+func EventRef(apiVersion, kind string, obj metav1.Object) *corev1.ObjectReference {
+	return &corev1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		UID:        obj.GetUID(),
+	}
+}
+
+// recordTakeoffResult emits the event for the result of a takeoff.
+// Warnings, including noop takeoffs where the desired state was already applied, are successful takeoffs.
+func recordTakeoffResult(recorder record.EventRecorder, ref *corev1.ObjectReference, err error) {
+	switch {
+	case err == nil:
+		recorder.Event(ref, corev1.EventTypeNormal, ReasonTakeoffSucceeded, "Successfully applied release")
+	case internal.IsNoopErr(err):
+		recorder.Event(ref, corev1.EventTypeNormal, ReasonTakeoffSucceeded, "Release is up to date")
+	case internal.IsWarning(err):
+		recorder.Eventf(ref, corev1.EventTypeNormal, ReasonTakeoffSucceeded, "Successfully applied release with warning: %v", err)
+	default:
+		recorder.Eventf(ref, corev1.EventTypeWarning, ReasonTakeoffFailed, "%v", err)
+	}
+}
+
+// recordReadiness emits an event when the Ready condition transitions to true, or from true to false.
+// Transitions between two not ready reasons are not emitted as they are reflected by the condition itself.
+func recordReadiness(recorder record.EventRecorder, ref *corev1.ObjectReference, previous *metav1.Condition, next metav1.Condition) {
+	wasReady := previous != nil && previous.Status == metav1.ConditionTrue
+	switch {
+	case next.Status == metav1.ConditionTrue && !wasReady:
+		recorder.Event(ref, corev1.EventTypeNormal, ReasonReady, next.Message)
+	case next.Status != metav1.ConditionTrue && wasReady:
+		recorder.Eventf(ref, corev1.EventTypeWarning, ReasonNotReady, "%s: %s", next.Reason, next.Message)
+	}
+}
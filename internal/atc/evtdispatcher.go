@@ -2,18 +2,26 @@ package atc
 
 import (
 	"slices"
+	"sync"
 
 	"github.com/davidmdm/x/xsync"
 
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/wasi/host"
 	"github.com/yokecd/yoke/pkg/k8s/ctrl"
 )
 
-type _dispatcher = xsync.Map[string, *xsync.Set[ctrl.Event]]
-
-type EventDispatcher _dispatcher
+// EventDispatcher maps external resources to the events of the instances that depend on them.
+// Instances depend on resources either by reference, or via list queries that resources created later may match.
+type EventDispatcher struct {
+	refs    xsync.Map[string, *xsync.Set[ctrl.Event]]
+	queries xsync.Map[host.ListQuery, *xsync.Set[ctrl.Event]]
 
-func (dispatcher *EventDispatcher) raw() *_dispatcher {
-	return (*_dispatcher)(dispatcher)
+	// mu serializes registrations and removals, such that an entry emptied by RemoveEvent is never deleted
+	// while an event is being registered to it.
+	mu sync.Mutex
 }
 
 type DispatchEvent struct {
@@ -22,21 +30,66 @@ type DispatchEvent struct {
 	Namespace string
 }
 
-func (dispatcher *EventDispatcher) Dispatch(resource string) []ctrl.Event {
-	mapping, loaded := dispatcher.raw().Load(resource)
-	if !loaded {
-		return nil
+func (dispatcher *EventDispatcher) Dispatch(resource *unstructured.Unstructured) []ctrl.Event {
+	var events xsync.Set[ctrl.Event]
+
+	if mapping, loaded := dispatcher.refs.Load(internal.ResourceRef(resource)); loaded {
+		for evt := range mapping.All() {
+			events.Add(evt)
+		}
+	}
+
+	for query, mapping := range dispatcher.queries.All() {
+		if !query.Matches(resource) {
+			continue
+		}
+		for evt := range mapping.All() {
+			events.Add(evt)
+		}
 	}
-	return slices.Collect(mapping.All())
+
+	return slices.Collect(events.All())
 }
 
 func (dispatcher *EventDispatcher) Register(resource string, evt ctrl.Event) {
-	mappings, _ := dispatcher.raw().LoadOrStore(resource, new(xsync.Set[ctrl.Event]))
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+
+	mappings, _ := dispatcher.refs.LoadOrStore(resource, new(xsync.Set[ctrl.Event]))
 	mappings.Add(evt)
 }
 
+func (dispatcher *EventDispatcher) RegisterQuery(query host.ListQuery, evt ctrl.Event) {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+
+	mappings, _ := dispatcher.queries.LoadOrStore(query, new(xsync.Set[ctrl.Event]))
+	mappings.Add(evt)
+}
+
+// RemoveEvent removes the event from all mappings. Mappings left without events are deleted, as every query
+// is evaluated against each dispatched resource.
 func (dispatcher *EventDispatcher) RemoveEvent(evt ctrl.Event) {
-	for _, mapping := range dispatcher.raw().All() {
+	dispatcher.mu.Lock()
+	defer dispatcher.mu.Unlock()
+
+	for resource, mapping := range dispatcher.refs.All() {
+		mapping.Del(evt)
+		if isEmpty(mapping) {
+			dispatcher.refs.Delete(resource)
+		}
+	}
+	for query, mapping := range dispatcher.queries.All() {
 		mapping.Del(evt)
+		if isEmpty(mapping) {
+			dispatcher.queries.Delete(query)
+		}
+	}
+}
+
+func isEmpty(set *xsync.Set[ctrl.Event]) bool {
+	for range set.All() {
+		return false
 	}
+	return true
 }
@@ -0,0 +1,44 @@
+package atc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/yokecd/yoke/internal/wasi/host"
+	"github.com/yokecd/yoke/pkg/k8s/ctrl"
+)
+
+func TestEventDispatcherRemoveEvent(t *testing.T) {
+	var dispatcher EventDispatcher
+
+	foo := ctrl.Event{Name: "foo", Namespace: "default", GroupKind: schema.GroupKind{Group: "examples.com", Kind: "Backend"}}
+	bar := ctrl.Event{Name: "bar", Namespace: "default", GroupKind: schema.GroupKind{Group: "examples.com", Kind: "Backend"}}
+
+	secrets := host.ListQuery{Namespace: "default", GroupKind: "Secret"}
+	configmaps := host.ListQuery{Namespace: "default", GroupKind: "ConfigMap"}
+
+	dispatcher.RegisterQuery(secrets, foo)
+	dispatcher.RegisterQuery(secrets, bar)
+	dispatcher.RegisterQuery(configmaps, foo)
+
+	secret := &unstructured.Unstructured{}
+	secret.SetKind("Secret")
+	secret.SetName("creds")
+	secret.SetNamespace("default")
+
+	require.ElementsMatch(t, []ctrl.Event{foo, bar}, dispatcher.Dispatch(secret))
+
+	dispatcher.RemoveEvent(foo)
+
+	require.ElementsMatch(t, []ctrl.Event{bar}, dispatcher.Dispatch(secret))
+
+	var queries []host.ListQuery
+	for query := range dispatcher.queries.All() {
+		queries = append(queries, query)
+	}
+	require.Equal(t, []host.ListQuery{secrets}, queries)
+}
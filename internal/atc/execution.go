@@ -0,0 +1,29 @@
+package atc
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/wasi"
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// executionStatus returns the stats of the flight execution collected by ctx if the takeoff that returned err created a new revision.
+// Noop takeoffs are not reported: their stats would differ on every reconciliation, and updating the status would trigger
+// yet another reconciliation.
+func executionStatus(ctx context.Context, err error) *flight.Execution {
+	if err != nil && (!internal.IsWarning(err) || internal.IsNoopErr(err)) {
+		return nil
+	}
+	stats, ok := wasi.GetExecStats(ctx)
+	if !ok {
+		return nil
+	}
+	return &flight.Execution{
+		FunctionCalls: stats.FunctionCalls,
+		MemoryBytes:   stats.MemoryBytes,
+		Duration:      metav1.Duration{Duration: stats.Duration},
+	}
+}
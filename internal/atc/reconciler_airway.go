@@ -4,12 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"reflect"
 	"runtime"
 	"slices"
 	"strings"
 	"time"
 
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -38,10 +40,11 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 	}()
 
 	var (
-		client      = (*k8s.Client)(ctrl.Client(ctx))
-		airwayIntf  = client.AirwayIntf()
-		airwayCache = ctrl.CacheFromEvent[v1alpha1.Airway](ctx, event)
-		webhookIntf = ctrl.Client(ctx).Clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+		client       = (*k8s.Client)(ctrl.Client(ctx))
+		airwayIntf   = client.AirwayIntf()
+		airwayCache  = ctrl.CacheFromEvent[v1alpha1.Airway](ctx, event)
+		webhookIntf  = ctrl.Client(ctx).Clientset.AdmissionregistrationV1().ValidatingWebhookConfigurations()
+		mutationIntf = ctrl.Client(ctx).Clientset.AdmissionregistrationV1().MutatingWebhookConfigurations()
 	)
 
 	airway, err := airwayCache.Get(event.Name)
@@ -53,7 +56,17 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		return ctrl.Result{}, fmt.Errorf("failed to get airway %s: %w", event.Name, err)
 	}
 
+	recorder := ctrl.Recorder(ctx)
+	ref := EventRef(v1alpha1.APIVersion, v1alpha1.KindAirway, airway)
+
+	// Every shard runs a flight controller for the airway's instances that it owns, but only the shards owning the airway
+	// manage its finalizer, status and custom resource definition.
+	owner := atc.shard.OwnsAirway(airway.Labels)
+
 	airwayStatus := func(status metav1.ConditionStatus, reason string, msg any) {
+		if !owner {
+			return
+		}
 		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 			current, err := airwayIntf.Get(ctx, airway.GetName(), metav1.GetOptions{})
 			if err != nil {
@@ -65,13 +78,18 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 			if current.GetGeneration() != airway.GetGeneration() {
 				return nil
 			}
-			if changed := meta.SetStatusCondition((*[]metav1.Condition)(&current.Status.Conditions), metav1.Condition{
+			previous := meta.FindStatusCondition(current.Status.Conditions, "Ready")
+			if previous != nil {
+				previous = previous.DeepCopy()
+			}
+			condition := metav1.Condition{
 				Type:               "Ready",
 				Status:             status,
 				ObservedGeneration: current.Generation,
 				Reason:             reason,
 				Message:            fmt.Sprintf("%v", msg),
-			}); !changed {
+			}
+			if changed := meta.SetStatusCondition((*[]metav1.Condition)(&current.Status.Conditions), condition); !changed {
 				return nil
 			}
 			updated, err := airwayIntf.UpdateStatus(ctx, current, metav1.UpdateOptions{FieldManager: fieldManager})
@@ -81,6 +99,7 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 				}
 				return err
 			}
+			recordReadiness(recorder, ref, previous, condition)
 			airway = updated
 			return nil
 		}); err != nil {
@@ -88,7 +107,7 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		}
 	}
 
-	if airway.DeletionTimestamp == nil && !slices.Contains(airway.Finalizers, cleanupAirwayFinalizer) {
+	if owner && airway.DeletionTimestamp == nil && !slices.Contains(airway.Finalizers, cleanupAirwayFinalizer) {
 		finalizers := append(airway.Finalizers, cleanupAirwayFinalizer)
 		airway.SetFinalizers(finalizers)
 		if _, err := airwayIntf.Update(ctx, airway, metav1.UpdateOptions{FieldManager: fieldManager}); err != nil {
@@ -97,13 +116,29 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		return ctrl.Result{}, nil
 	}
 
+	if !airway.DeletionTimestamp.IsZero() && !owner {
+		if err := webhookIntf.Delete(ctx, atc.shard.ResourceName(airway.CRGroupResource().String()), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to remove admission validation webhook: %w", err)
+		}
+		if err := mutationIntf.Delete(ctx, atc.shard.ResourceName(airway.CRGroupResource().String()), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, fmt.Errorf("failed to remove admission mutation webhook: %w", err)
+		}
+		if cleanup := atc.cleanups[airway.Name]; cleanup != nil {
+			cleanup()
+		}
+		return ctrl.Result{}, nil
+	}
+
 	if !airway.DeletionTimestamp.IsZero() {
 		airwayStatus(metav1.ConditionFalse, "Terminating", "cleaning up resources")
 
 		if slices.Contains(airway.Finalizers, cleanupAirwayFinalizer) {
-			if err := webhookIntf.Delete(ctx, airway.CRGroupResource().String(), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			if err := webhookIntf.Delete(ctx, atc.shard.ResourceName(airway.CRGroupResource().String()), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
 				return ctrl.Result{}, fmt.Errorf("failed to remove admission validation webhook: %w", err)
 			}
+			if err := mutationIntf.Delete(ctx, atc.shard.ResourceName(airway.CRGroupResource().String()), metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+				return ctrl.Result{}, fmt.Errorf("failed to remove admission mutation webhook: %w", err)
+			}
 
 			crdIntf := ctrl.Client(ctx).Dynamic.Resource(schema.GroupVersionResource{
 				Group:    apiextv1.SchemeGroupVersion.Group,
@@ -154,6 +189,8 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		}
 	}
 
+	relaunch := atc.cleanups[airway.Name] != nil
+
 	if cleanup := atc.cleanups[airway.Name]; cleanup != nil {
 		airwayStatus(metav1.ConditionFalse, "InProgress", "Cleaning up previous flight controller")
 		cleanup()
@@ -180,6 +217,8 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		}{
 			{airway.Spec.WasmURLs.Flight, airway.Spec.WasmURLs.FlightChecksum},
 			{airway.Spec.WasmURLs.Converter, airway.Spec.WasmURLs.ConverterChecksum},
+			{airway.Spec.WasmURLs.Validator, airway.Spec.WasmURLs.ValidatorChecksum},
+			{airway.Spec.WasmURLs.Defaulter, airway.Spec.WasmURLs.DefaulterChecksum},
 		} {
 			if value.URL == "" {
 				continue
@@ -192,10 +231,12 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 					Insecure: airway.Spec.Insecure,
 					Attrs: cache.ModuleAttrs{
 						MaxMemoryMib:    airway.Spec.MaxMemoryMib,
-						HostFunctionMap: host.BuildFunctionMap(client),
+						HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(client)),
+						Metered:         airway.Spec.MaxFunctionCalls > 0,
 					},
 				},
 			); err != nil {
+				recorder.Eventf(ref, corev1.EventTypeWarning, ReasonModuleFetchFailed, "%s: %v", value.URL, err)
 				return fmt.Errorf("failed to warm cache: %w", err)
 			}
 
@@ -234,6 +275,8 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		if !ok {
 			version.Schema.OpenAPIV3Schema.Properties["status"] = *openapi.SchemaFor[struct {
 				Conditions flight.Conditions `json:"conditions,omitempty"`
+				Execution  *flight.Execution `json:"execution,omitempty"`
+				Flight     *flight.Module    `json:"flight,omitempty"`
 			}]()
 		} else {
 			if statusSchema.Type != "object" {
@@ -248,6 +291,12 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 			if err := openapi.Satisfies(statusSchema.Properties["conditions"], *openapi.SchemaFor[flight.Conditions]()); err != nil {
 				return ctrl.Result{}, fmt.Errorf("invalid airway: invalid status: conditions does not have expected schema: %v", err)
 			}
+			if _, ok := statusSchema.Properties["execution"]; !ok {
+				statusSchema.Properties["execution"] = *openapi.SchemaFor[flight.Execution]()
+			}
+			if _, ok := statusSchema.Properties["flight"]; !ok {
+				statusSchema.Properties["flight"] = *openapi.SchemaFor[flight.Module]()
+			}
 
 			if idx := slices.Index(version.Schema.OpenAPIV3Schema.Required, "status"); idx >= 0 {
 				version.Schema.OpenAPIV3Schema.Required = slices.Delete(version.Schema.OpenAPIV3Schema.Required, idx, idx+1)
@@ -278,8 +327,10 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		return ctrl.Result{}, fmt.Errorf("failed to convert airway CRD to unstructured object: %v", err)
 	}
 
-	if err := client.ApplyResource(ctx, crd, k8s.ApplyOpts{}); err != nil {
-		return ctrl.Result{}, fmt.Errorf("failed to apply airway's template crd: %w", err)
+	if owner {
+		if err := client.ApplyResource(ctx, crd, k8s.ApplyOpts{}); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to apply airway's template crd: %w", err)
+		}
 	}
 
 	if err := client.WaitForReady(ctx, crd, k8s.WaitOptions{Timeout: time.Minute, Interval: time.Second}); err != nil {
@@ -288,13 +339,19 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 
 	ctrl.Client(ctx).Mapper.Reset()
 
+	if airway.Spec.Template.Scope == apiextv1.ClusterScoped && !atc.shard.OwnsClusterScope() {
+		ctrl.Logger(ctx).Info("Skipping flight controller: cluster scoped instances are not owned by this shard")
+		airwayStatus(metav1.ConditionTrue, "Ready", "Flight-Controller skipped: cluster scoped instances are not owned by this shard")
+		return ctrl.Result{}, nil
+	}
+
 	validationWebhook := admissionregistrationv1.ValidatingWebhookConfiguration{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: admissionregistrationv1.SchemeGroupVersion.Identifier(),
 			Kind:       "ValidatingWebhookConfiguration",
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name: airway.GetName(),
+			Name: atc.shard.ResourceName(airway.GetName()),
 			OwnerReferences: []metav1.OwnerReference{
 				{
 					APIVersion: airway.APIVersion,
@@ -319,6 +376,8 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 				SideEffects:             ptr.To(admissionregistrationv1.SideEffectClassNone),
 				AdmissionReviewVersions: []string{"v1"},
 				MatchPolicy:             ptr.To(admissionregistrationv1.Exact),
+				NamespaceSelector:       atc.shard.NamespaceSelector(),
+				ObjectSelector:          atc.shard.ObjectSelector(),
 				MatchConditions: []admissionregistrationv1.MatchCondition{
 					{
 						Name: "not-atc-service-account",
@@ -361,11 +420,83 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		return ctrl.Result{}, fmt.Errorf("failed to create validation webhook: %w", err)
 	}
 
+	if airway.Spec.WasmURLs.Defaulter != "" {
+		// The mutation webhook matches the same instances as the validation webhook.
+		validation := validationWebhook.Webhooks[0]
+
+		mutationWebhook := admissionregistrationv1.MutatingWebhookConfiguration{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: admissionregistrationv1.SchemeGroupVersion.Identifier(),
+				Kind:       "MutatingWebhookConfiguration",
+			},
+			ObjectMeta: validationWebhook.ObjectMeta,
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{
+					Name: validation.Name,
+					ClientConfig: admissionregistrationv1.WebhookClientConfig{
+						Service: &admissionregistrationv1.ServiceReference{
+							Namespace: atc.service.Namespace,
+							Name:      atc.service.Name,
+							Path:      new("/mutations/" + airway.Name),
+							Port:      &atc.service.Port,
+						},
+						CABundle: atc.service.CABundle,
+					},
+					FailurePolicy:           validation.FailurePolicy,
+					TimeoutSeconds:          validation.TimeoutSeconds,
+					SideEffects:             validation.SideEffects,
+					AdmissionReviewVersions: validation.AdmissionReviewVersions,
+					MatchPolicy:             validation.MatchPolicy,
+					NamespaceSelector:       validation.NamespaceSelector,
+					ObjectSelector:          validation.ObjectSelector,
+					MatchConditions:         validation.MatchConditions,
+					Rules:                   validation.Rules,
+				},
+			},
+		}
+
+		rawMutationWebhook, err := json.Marshal(mutationWebhook)
+		if err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to serialize mutation webhook: %w", err)
+		}
+
+		if _, err := mutationIntf.Patch(ctx, mutationWebhook.Name, types.ApplyPatchType, rawMutationWebhook, metav1.PatchOptions{FieldManager: fieldManager}); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to create mutation webhook: %w", err)
+		}
+	} else if err := mutationIntf.Delete(ctx, validationWebhook.Name, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		return ctrl.Result{}, fmt.Errorf("failed to remove admission mutation webhook: %w", err)
+	}
+
 	flightGK := schema.GroupKind{
 		Group: airway.Spec.Template.Group,
 		Kind:  airway.Spec.Template.Names.Kind,
 	}
 
+	rolloutStatus := nextRolloutStatus(*airway)
+
+	if owner && !reflect.DeepEqual(airway.Status.Rollout, &rolloutStatus) {
+		if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+			current, err := airwayIntf.Get(ctx, airway.GetName(), metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			current.Status.Rollout = &rolloutStatus
+			updated, err := airwayIntf.UpdateStatus(ctx, current, metav1.UpdateOptions{FieldManager: fieldManager})
+			if err != nil {
+				return err
+			}
+			airway = updated
+			return nil
+		}); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to update rollout status: %w", err)
+		}
+	}
+
+	rollout, err := newRollout(*airway, flightGK, rolloutStatus)
+	if err != nil {
+		return ctrl.Result{}, ctrl.Terminal(fmt.Errorf("invalid airway: invalid rollout policy: %w", err))
+	}
+
 	atc.cleanups[airway.Name] = func() {
 		ctrl.Logger(ctx).Info("Flight controller canceled. Shutdown in progress.")
 		ctrl.Inst(ctx).ShutdownGK(flightGK)
@@ -380,21 +511,36 @@ func (atc atc) Reconcile(ctx context.Context, event ctrl.Event) (result ctrl.Res
 		Airway:  *airway,
 		Version: storageVersion,
 		States:  atc.flightStates,
+		Rollout: rollout,
 	}
 
 	if err := ctrl.Inst(ctx).Register(ctrl.Entry{
 		GroupKind: flightGK,
 		Funcs:     atc.InstanceReconciler(reconcilerParams),
+		Scope:     atc.shard.Scope(),
 	}); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to register flight controller for gk: %w", err)
 	}
 
+	if relaunch {
+		recorder.Eventf(ref, corev1.EventTypeNormal, ReasonControllerRelaunched, "Relaunched flight controller for %s", flightGK)
+	} else {
+		recorder.Eventf(ref, corev1.EventTypeNormal, ReasonControllerLaunched, "Launched flight controller for %s", flightGK)
+	}
+
 	airwayStatus(metav1.ConditionTrue, "Ready", "Flight-Controller launched")
 
 	return ctrl.Result{}, nil
 }
 
+// ReleaseName returns the name of the release of an airway instance: the resource reference of the instance, namespace/Kind.group:name.
 func ReleaseName(resource *unstructured.Unstructured) string {
+	return fmt.Sprintf("%s/%s:%s", resource.GetNamespace(), resource.GroupVersionKind().GroupKind(), resource.GetName())
+}
+
+// DeprecatedReleaseName returns the release name of an airway instance as formatted by previous versions of the ATC: group.Kind.namespace?.name.
+// It cannot be parsed back unambiguously and is only used to migrate releases to ReleaseName.
+func DeprecatedReleaseName(resource *unstructured.Unstructured) string {
 	gvk := resource.GroupVersionKind()
 	elems := []string{
 		gvk.Group,
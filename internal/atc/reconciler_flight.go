@@ -5,10 +5,13 @@ import (
 	"context"
 	"fmt"
 	"slices"
+	"sync"
 	"time"
 
 	"github.com/davidmdm/x/xerr"
+	"github.com/davidmdm/x/xsync"
 
+	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -17,6 +20,7 @@ import (
 
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/wasi/cache"
 	"github.com/yokecd/yoke/internal/wasi/host"
 	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
@@ -26,15 +30,26 @@ import (
 
 type TeardownFunc func()
 
-func FlightReconciler(modules *cache.ModuleCache) ctrl.Funcs {
-	return flightReconciler(modules, false)
+// FlightReconcilerParams are the dependencies of the Flight and ClusterFlight reconcilers.
+// The dispatcher and states are shared with the airway instance reconcilers and the admission webhooks,
+// such that flights support the same modes as airway instances.
+type FlightReconcilerParams struct {
+	Modules    *cache.ModuleCache
+	Dispatcher *EventDispatcher
+	States     *xsync.Map[string, InstanceState]
 }
 
-func ClusterFlightReconsiler(modules *cache.ModuleCache) ctrl.Funcs {
-	return flightReconciler(modules, true)
+func FlightReconciler(params FlightReconcilerParams) ctrl.Funcs {
+	return flightReconciler(params, false)
 }
 
-func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs {
+func ClusterFlightReconsiler(params FlightReconcilerParams) ctrl.Funcs {
+	return flightReconciler(params, true)
+}
+
+func flightReconciler(params FlightReconcilerParams, clusterScope bool) ctrl.Funcs {
+	modules := params.Modules
+
 	gvr := func() schema.GroupVersionResource {
 		if clusterScope {
 			return v1alpha1.ClusterFlightGVR()
@@ -67,6 +82,36 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 			return ctrl.Result{}, fmt.Errorf("failed to get flight instance: %w", err)
 		}
 
+		flightState, loaded := params.States.LoadOrStore(evt.String(), InstanceState{Mutex: new(sync.RWMutex)})
+
+		// This lock ensures that admission cannot update subresources while this control loop is running.
+		flightState.Mutex.Lock()
+		defer flightState.Mutex.Unlock()
+
+		defer func() {
+			if flight.DeletionTimestamp.IsZero() {
+				params.States.Store(evt.String(), flightState)
+			}
+		}()
+
+		flightState.ClusterAccess = flight.Spec.ClusterAccess
+		flightState.Mode = cmp.Or(flight.Spec.Mode, v1alpha1.AirwayModeStandard)
+		flightState.Suspended = flight.Spec.Suspend || v1alpha1.IsSuspended(flight)
+
+		recorder := ctrl.Recorder(ctx)
+		ref := EventRef(flight.APIVersion, flight.Kind, flight)
+
+		// A new reconcile request is acknowledged and the resource is enqueued again explicitly, such that the request
+		// is honoured by a full evaluation even when this one was triggered by another change.
+		// Requests seen for the first time after a restart of the ATC are not reported.
+		if requested := flight.GetAnnotations()[v1alpha1.AnnotationReconcileRequestedAt]; requested != flightState.ReconcileRequestedAt {
+			if loaded && requested != "" && !flightState.Suspended {
+				recorder.Eventf(ref, corev1.EventTypeNormal, ReasonReconcileRequested, "Reconcile requested at %s", requested)
+				ctrl.Inst(ctx).SendEvent(evt.WithoutMeta())
+			}
+			flightState.ReconcileRequestedAt = requested
+		}
+
 		setReadyCondition := func(status metav1.ConditionStatus, reason string, msg any) {
 			if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
 				current, err := flightIntf.Get(ctx, flight.GetName(), metav1.GetOptions{})
@@ -80,13 +125,20 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 					return nil
 				}
 
-				if changed := meta.SetStatusCondition((*[]metav1.Condition)(&current.Status.Conditions), metav1.Condition{
+				previous := meta.FindStatusCondition(current.Status.Conditions, "Ready")
+				if previous != nil {
+					previous = previous.DeepCopy()
+				}
+
+				condition := metav1.Condition{
 					Type:               "Ready",
 					Status:             status,
 					ObservedGeneration: flight.Generation,
 					Reason:             reason,
 					Message:            fmt.Sprintf("%v", msg),
-				}); !changed {
+				}
+
+				if changed := meta.SetStatusCondition((*[]metav1.Condition)(&current.Status.Conditions), condition); !changed {
 					return nil
 				}
 
@@ -98,6 +150,8 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 					return err
 				}
 
+				recordReadiness(recorder, ref, previous, condition)
+
 				flight = updated
 				return nil
 			}); err != nil {
@@ -123,6 +177,7 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 
 		if !flight.DeletionTimestamp.IsZero() {
 			setReadyCondition(metav1.ConditionFalse, "Terminating", "mayday is being performed")
+			recorder.Event(ref, corev1.EventTypeNormal, ReasonMayday, "Removing release")
 			if err := commander.Mayday(ctx, yoke.MaydayParams{
 				Release:   releasePrefix + flight.Name,
 				Namespace: flight.Namespace,
@@ -131,6 +186,7 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 					RemoveNamespaces: flight.Spec.Prune.Namespaces,
 				},
 			}); err != nil && !internal.IsWarning(err) {
+				recorder.Eventf(ref, corev1.EventTypeWarning, ReasonMaydayFailed, "%v", err)
 				return ctrl.Result{}, fmt.Errorf("failed to perform mayday: %w", err)
 			}
 			if idx := slices.Index(flight.Finalizers, cleanupFinalizer); idx >= 0 {
@@ -139,6 +195,17 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 					return ctrl.Result{}, fmt.Errorf("failed to remove cleanup finalizer: %w", err)
 				}
 			}
+
+			params.States.Delete(evt.String())
+			params.Dispatcher.RemoveEvent(evt.WithoutMeta())
+
+			return ctrl.Result{}, nil
+		}
+
+		if flightState.Suspended {
+			// Suspended flights stop receiving events from the dispatcher. They register again with their next takeoff once resumed.
+			params.Dispatcher.RemoveEvent(evt.WithoutMeta())
+			recorder.Event(ref, corev1.EventTypeNormal, ReasonSuspended, "Flight is suspended: skipping takeoff")
 			return ctrl.Result{}, nil
 		}
 
@@ -152,11 +219,13 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 				Insecure: flight.Spec.Insecure,
 				Attrs: cache.ModuleAttrs{
 					MaxMemoryMib:    flight.Spec.MaxMemoryMib,
-					HostFunctionMap: host.BuildFunctionMap(client),
+					HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(client)),
+					Metered:         flight.Spec.MaxFunctionCalls > 0,
 				},
 			},
 		)
 		if err != nil {
+			recorder.Eventf(ref, corev1.EventTypeWarning, ReasonModuleFetchFailed, "%v", err)
 			return ctrl.Result{}, fmt.Errorf("failed to get wasm module: %w", err)
 		}
 
@@ -178,10 +247,12 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 						Checksum: mod.Checksum(),
 					},
 				},
-				Args:         flight.Spec.Args,
-				MaxMemoryMib: uint64(flight.Spec.MaxMemoryMib),
-				Timeout:      flight.Spec.Timeout.Duration,
-				Input:        v1alpha1.FlightInputStream(flight.Spec),
+				Args:             flight.Spec.Args,
+				MaxMemoryMib:     uint64(flight.Spec.MaxMemoryMib),
+				Timeout:          flight.Spec.Timeout.Duration,
+				MaxFunctionCalls: flight.Spec.MaxFunctionCalls,
+				Deterministic:    flight.Spec.Deterministic,
+				Input:            v1alpha1.FlightInputStream(flight.Spec),
 			},
 			OwnerReferences: []metav1.OwnerReference{
 				{
@@ -195,19 +266,66 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 				Enabled:          flight.Spec.ClusterAccess,
 				ResourceMatchers: flight.Spec.ResourceAccessMatchers,
 			},
+			HTTPAccess: yoke.HTTPAccessParams{
+				URLMatchers: flight.Spec.HTTPAccessMatchers,
+			},
 			HistoryCapSize: cmp.Or(flight.Spec.HistoryCapSize, 2),
 			ManagedBy:      "atc.yoke",
 			PruneOpts: yoke.PruneOpts{
 				RemoveCRDs:       flight.Spec.Prune.CRDs,
 				RemoveNamespaces: flight.Spec.Prune.Namespaces,
 			},
+			// The admission webhook of subresources uses the instance reference to find the state of the flight.
+			ExtraAnnotations: map[string]string{AnnotationInstanceRef: evt.String()},
+		}
+
+		if flightState.Mode.IsDynamic() {
+			ctx = host.WithResourceTracking(ctx)
+			defer func() {
+				if err == nil {
+					// Takeoff succeeded, hence we drop all previous references to tracked resources and build a fresh list.
+					// On error we keep the old references as well as whatever else was registered.
+					params.Dispatcher.RemoveEvent(evt.WithoutMeta())
+				}
+				for _, resource := range host.ExternalResources(ctx) {
+					params.Dispatcher.Register(resource, evt.WithoutMeta())
+				}
+				for _, query := range host.ListQueries(ctx) {
+					params.Dispatcher.RegisterQuery(query, evt.WithoutMeta())
+				}
+				// Fetched URLs cannot be watched for changes, so flights depending on them are polled instead.
+				if len(host.FetchedURLs(ctx)) > 0 {
+					refresh := cmp.Or(flight.Spec.HTTPRefreshInterval.Duration, DefaultHTTPRefreshInterval)
+					result.RequeueAfter = min(cmp.Or(result.RequeueAfter, refresh), refresh)
+				}
+			}()
+		} else {
+			// The flight may have left dynamic mode, in which case it must stop receiving events from the dispatcher.
+			params.Dispatcher.RemoveEvent(evt.WithoutMeta())
 		}
 
 		readinessByRef := map[string]bool{}
 
 		ctx = host.WithReleaseTracking(ctx)
 
+		if flightState.Mode == v1alpha1.AirwayModeSubscription {
+			defer func() {
+				if err != nil {
+					flightState.TrackedResources = flightState.TrackedResources.Union(host.InternalResources(ctx))
+				} else {
+					flightState.TrackedResources = host.InternalResources(ctx).Union(host.CandidateResources(ctx).Intersection(host.ReleaseResourcesRefs(ctx)))
+				}
+			}()
+		} else {
+			flightState.TrackedResources = nil
+		}
+		ctx = wasi.WithExecStats(ctx)
+		ctx = host.WithDiagnostics(ctx)
+
 		defer func() {
+			execution := executionStatus(ctx, err)
+			diagnostics := diagnosticsCondition(flight.Generation, host.Diagnostics(ctx))
+
 			if err != nil {
 				if !internal.IsWarning(err) {
 					return
@@ -240,6 +358,10 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 					return nil
 				}
 				current.Status.Inventory = items
+				if execution != nil {
+					current.Status.Execution = execution
+				}
+				meta.SetStatusCondition((*[]metav1.Condition)(&current.Status.Conditions), diagnostics)
 				_, err = flightIntf.UpdateStatus(ctx, current, metav1.UpdateOptions{FieldManager: fieldManager})
 				return err
 			}); updateErr != nil {
@@ -283,8 +405,13 @@ func flightReconciler(modules *cache.ModuleCache, clusterScope bool) ctrl.Funcs
 		}()
 
 		setReadyCondition(metav1.ConditionFalse, "InProgress", "Flight is taking off")
+		recorder.Event(ref, corev1.EventTypeNormal, ReasonTakeoffStarted, "Flight is taking off")
+
+		err = commander.Takeoff(ctx, takeoffParams)
+		recordTakeoffResult(recorder, ref, err)
+		recordDiagnostics(recorder, ref, host.Diagnostics(ctx))
 
-		return ctrl.Result{RequeueAfter: flight.Spec.FixDriftInterval.Duration}, commander.Takeoff(ctx, takeoffParams)
+		return ctrl.Result{RequeueAfter: flight.Spec.FixDriftInterval.Duration}, err
 	}
 
 	return ctrl.Funcs{
@@ -14,6 +14,7 @@ import (
 	"github.com/davidmdm/x/xerr"
 	"github.com/davidmdm/x/xsync"
 
+	corev1 "k8s.io/api/core/v1"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -25,6 +26,7 @@ import (
 
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/wasi/cache"
 	"github.com/yokecd/yoke/internal/wasi/host"
 	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
@@ -38,6 +40,7 @@ type InstanceReconcilerParams struct {
 	Version string
 	Airway  v1alpha1.Airway
 	States  *xsync.Map[string, InstanceState]
+	Rollout *rollout
 }
 
 func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
@@ -93,7 +96,7 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 			return override
 		}()
 
-		flightState, _ := params.States.LoadOrStore(event.String(), InstanceState{Mutex: new(sync.RWMutex)})
+		flightState, loaded := params.States.LoadOrStore(event.String(), InstanceState{Mutex: new(sync.RWMutex)})
 
 		// This lock ensures that admission cannot update subresources while this control loop is running.
 		flightState.Mutex.Lock()
@@ -105,6 +108,21 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 
 		flightState.ClusterAccess = params.Airway.Spec.ClusterAccess
 		flightState.Mode = cmp.Or(overrideMode, params.Airway.Spec.Mode, v1alpha1.AirwayModeStandard)
+		flightState.Suspended = params.Airway.Spec.Suspend || v1alpha1.IsSuspended(resource)
+
+		recorder := ctrl.Recorder(ctx)
+		ref := EventRef(resource.GetAPIVersion(), resource.GetKind(), resource)
+
+		// A new reconcile request is acknowledged and the resource is enqueued again explicitly, such that the request
+		// is honoured by a full evaluation even when this one was triggered by another change.
+		// Requests seen for the first time after a restart of the ATC are not reported.
+		if requested := resource.GetAnnotations()[v1alpha1.AnnotationReconcileRequestedAt]; requested != flightState.ReconcileRequestedAt {
+			if loaded && requested != "" && !flightState.Suspended {
+				recorder.Eventf(ref, corev1.EventTypeNormal, ReasonReconcileRequested, "Reconcile requested at %s", requested)
+				ctrl.Inst(ctx).SendEvent(event.WithoutMeta())
+			}
+			flightState.ReconcileRequestedAt = requested
+		}
 
 		setReadyCondition := func(status metav1.ConditionStatus, reason string, msg any) {
 			if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
@@ -123,13 +141,20 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 
 				conditions := internal.GetFlightConditions(current)
 
-				if changed := meta.SetStatusCondition(&conditions, metav1.Condition{
+				previous := meta.FindStatusCondition(conditions, "Ready")
+				if previous != nil {
+					previous = previous.DeepCopy()
+				}
+
+				condition := metav1.Condition{
 					Type:               "Ready",
 					Status:             status,
 					ObservedGeneration: current.GetGeneration(),
 					Reason:             reason,
 					Message:            fmt.Sprintf("%v", msg),
-				}); !changed {
+				}
+
+				if changed := meta.SetStatusCondition(&conditions, condition); !changed {
 					return nil
 				}
 
@@ -140,6 +165,8 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 					return err
 				}
 
+				recordReadiness(recorder, ref, previous, condition)
+
 				resource = updated
 
 				return nil
@@ -181,8 +208,18 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 			return ctrl.Result{}, nil
 		}
 
+		// Releases of instances created by previous versions of the ATC used a release name that cannot be parsed back into a resource reference.
+		// Renaming them is idempotent, but looks up the history of the deprecated release, hence it is only done once per instance.
+		if !flightState.ReleaseMigrated {
+			if err := client.RenameRelease(ctx, DeprecatedReleaseName(resource), ReleaseName(resource), cmp.Or(event.Namespace, client.DefaultNamespace)); err != nil {
+				return ctrl.Result{}, fmt.Errorf("failed to migrate release to resource reference name: %w", err)
+			}
+			flightState.ReleaseMigrated = true
+		}
+
 		if !resource.GetDeletionTimestamp().IsZero() {
 			setReadyCondition(metav1.ConditionFalse, "Terminating", "Mayday: Flight is being removed")
+			recorder.Event(ref, corev1.EventTypeNormal, ReasonMayday, "Removing release")
 
 			if err := yoke.FromK8Client(client).Mayday(ctx, yoke.MaydayParams{
 				Release:   ReleaseName(resource),
@@ -193,6 +230,7 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 				},
 			}); err != nil {
 				if !internal.IsWarning(err) {
+					recorder.Eventf(ref, corev1.EventTypeWarning, ReasonMaydayFailed, "%v", err)
 					return ctrl.Result{}, fmt.Errorf("failed to run atc cleanup: %w", err)
 				}
 				ctrl.Logger(ctx).Warn("mayday succeeded despite a warning", "warning", err)
@@ -220,6 +258,13 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 			return ctrl.Result{}, nil
 		}
 
+		if flightState.Suspended {
+			// Suspended instances stop receiving events from the dispatcher. They register again with their next takeoff once resumed.
+			atc.dispatcher.RemoveEvent(event.WithoutMeta())
+			recorder.Event(ref, corev1.EventTypeNormal, ReasonSuspended, "Instance is suspended: skipping takeoff")
+			return ctrl.Result{}, nil
+		}
+
 		object, _, err := unstructured.NestedFieldNoCopy(resource.Object, params.Airway.Spec.ObjectPath...)
 		if err != nil {
 			return ctrl.Result{}, ctrl.Terminal(fmt.Errorf("failed to get object path from: %q: %v", strings.Join(params.Airway.Spec.ObjectPath, ","), err))
@@ -232,7 +277,57 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 
 		commander := yoke.FromK8Client(client)
 
-		release := ReleaseName(resource)
+		var (
+			execution   *flight.Execution
+			diagnostics *metav1.Condition
+			module      *flight.Module
+		)
+
+		defer func() {
+			if execution == nil && diagnostics == nil && module == nil {
+				return
+			}
+			if err := retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+				current, err := resourceIntf.Get(ctx, resource.GetName(), metav1.GetOptions{})
+				if err != nil {
+					return fmt.Errorf("failed to get resource: %w", err)
+				}
+				if current.GetGeneration() != resource.GetGeneration() {
+					return nil
+				}
+				changed := false
+				if execution != nil {
+					_ = unstructured.SetNestedField(current.Object, internal.MustUnstructuredObject[any](execution), "status", "execution")
+					changed = true
+				}
+				if diagnostics != nil {
+					conditions := internal.GetFlightConditions(current)
+					if meta.SetStatusCondition(&conditions, *diagnostics) {
+						_ = unstructured.SetNestedField(current.Object, internal.MustUnstructuredObject[any](conditions), "status", "conditions")
+						changed = true
+					}
+				}
+				if module != nil && InstanceModule(current) != *module {
+					_ = unstructured.SetNestedField(current.Object, internal.MustUnstructuredObject[any](*module), "status", "flight")
+					changed = true
+				}
+				if !changed {
+					return nil
+				}
+
+				updated, err := resourceIntf.UpdateStatus(ctx, current, metav1.UpdateOptions{FieldManager: fieldManager})
+				if err != nil {
+					return err
+				}
+				resource = updated
+				return nil
+			}); err != nil {
+				if kerrors.IsNotFound(err) {
+					return
+				}
+				ctrl.Logger(ctx).Error("failed to update status with execution stats, diagnostics, and flight", "error", err)
+			}
+		}()
 
 		var identity *unstructured.Unstructured
 
@@ -254,8 +349,19 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 				// spawn a readiness process.
 				identity := identity.DeepCopy()
 
+				// The execution stats and flight module are not part of the identity's status and must be preserved.
+				executionStatus, _, _ := unstructured.NestedFieldNoCopy(current.Object, "status", "execution")
+				flightStatus, _, _ := unstructured.NestedFieldNoCopy(current.Object, "status", "flight")
+
 				current.Object["status"] = identity.Object["status"]
 
+				if executionStatus != nil {
+					_ = unstructured.SetNestedField(current.Object, executionStatus, "status", "execution")
+				}
+				if flightStatus != nil {
+					_ = unstructured.SetNestedField(current.Object, flightStatus, "status", "flight")
+				}
+
 				conditions := internal.GetFlightConditions(resource)
 				for _, cond := range internal.GetFlightConditions(identity) {
 					meta.SetStatusCondition(&conditions, cond)
@@ -277,15 +383,36 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 			}
 		}()
 
+		overrideURL, _, _ := unstructured.NestedString(resource.Object, "metadata", "annotations", flight.AnnotationOverrideFlight)
+
+		// Instances are upgraded to a new flight as allowed by the rollout policy of the airway. Overrides are not subject to it.
+		targetModule := airwayModule(params.Airway)
+		takeoffErr := errUpgradeNotAttempted
+		if overrideURL == "" {
+			var (
+				pending bool
+				done    func(error)
+			)
+			targetModule, pending, done = params.Rollout.Module(ctx, event, resource)
+			defer func() { done(takeoffErr) }()
+			if pending {
+				defer func() {
+					result.RequeueAfter = cmp.Or(min(result.RequeueAfter, rolloutRetryInterval), rolloutRetryInterval)
+				}()
+			}
+		}
+
 		takeoffParams := yoke.TakeoffParams{
-			Release:   release,
+			Release:   ReleaseName(resource),
 			Namespace: event.Namespace,
-			Checksum:  params.Airway.Spec.WasmURLs.FlightChecksum,
+			Checksum:  targetModule.Checksum,
 			Flight: yoke.FlightParams{
-				Path:     params.Airway.Spec.WasmURLs.Flight,
-				Insecure: params.Airway.Spec.Insecure,
-				Input:    bytes.NewReader(data),
-				Timeout:  params.Airway.Spec.Timeout.Duration,
+				Path:             targetModule.URL,
+				Insecure:         params.Airway.Spec.Insecure,
+				Input:            bytes.NewReader(data),
+				Timeout:          params.Airway.Spec.Timeout.Duration,
+				MaxFunctionCalls: params.Airway.Spec.MaxFunctionCalls,
+				Deterministic:    params.Airway.Spec.Deterministic,
 			},
 			ManagedBy:      "atc.yoke",
 			Lock:           false,
@@ -296,6 +423,9 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 				Enabled:          params.Airway.Spec.ClusterAccess,
 				ResourceMatchers: params.Airway.Spec.ResourceAccessMatchers,
 			},
+			HTTPAccess: yoke.HTTPAccessParams{
+				URLMatchers: params.Airway.Spec.HTTPAccessMatchers,
+			},
 			ExtraAnnotations: map[string]string{AnnotationInstanceRef: internal.ResourceRef(resource)},
 			CrossNamespace:   params.Airway.Spec.Template.Scope == apiextv1.ClusterScoped,
 			PruneOpts: k8s.PruneOpts{
@@ -320,7 +450,7 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 			},
 		}
 
-		if overrideURL, _, _ := unstructured.NestedString(resource.Object, "metadata", "annotations", flight.AnnotationOverrideFlight); overrideURL != "" {
+		if overrideURL != "" {
 			ctrl.Logger(ctx).Warn("using override module", "url", overrideURL)
 			// Simply set the override URL as the flight path and let yoke load and execute the wasm module as if called from the command line.
 			// We do not want to manually compile the module here or cache it, since this feature is for overrides that will be most often used in testing;
@@ -330,22 +460,24 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 			mod, err := atc.moduleCache.FromURL(
 				ctx,
 				cache.FromURLParams{
-					URL:      params.Airway.Spec.WasmURLs.Flight,
-					Checksum: params.Airway.Spec.WasmURLs.FlightChecksum,
+					URL:      targetModule.URL,
+					Checksum: targetModule.Checksum,
 					Insecure: params.Airway.Spec.Insecure,
 					Attrs: cache.ModuleAttrs{
 						MaxMemoryMib:    params.Airway.Spec.MaxMemoryMib,
-						HostFunctionMap: host.BuildFunctionMap(client),
+						HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(client)),
+						Metered:         params.Airway.Spec.MaxFunctionCalls > 0,
 					},
 				},
 			)
 			if err != nil {
+				recorder.Eventf(ref, corev1.EventTypeWarning, ReasonModuleFetchFailed, "%v", err)
 				return ctrl.Result{}, fmt.Errorf("failed to fetch flight module from cache: %w", err)
 			}
 			takeoffParams.Flight.Module = yoke.Module{
 				Instance: mod,
 				SourceMetadata: yoke.ModuleSourcetadata{
-					Ref:      params.Airway.Spec.WasmURLs.Flight,
+					Ref:      targetModule.URL,
 					Checksum: mod.Checksum(),
 				},
 			}
@@ -363,6 +495,14 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 				for _, resource := range host.ExternalResources(ctx) {
 					atc.dispatcher.Register(resource, event.WithoutMeta())
 				}
+				for _, query := range host.ListQueries(ctx) {
+					atc.dispatcher.RegisterQuery(query, event.WithoutMeta())
+				}
+				// Fetched URLs cannot be watched for changes, so instances depending on them are polled instead.
+				if len(host.FetchedURLs(ctx)) > 0 {
+					refresh := cmp.Or(params.Airway.Spec.HTTPRefreshInterval.Duration, DefaultHTTPRefreshInterval)
+					result.RequeueAfter = min(cmp.Or(result.RequeueAfter, refresh), refresh)
+				}
 			}()
 		} else {
 			// if we are not in dynamic mode, either via an update to the Airway or a removed annotation,
@@ -425,8 +565,28 @@ func (atc atc) InstanceReconciler(params InstanceReconcilerParams) ctrl.Funcs {
 		}()
 
 		setReadyCondition(metav1.ConditionFalse, "InProgress", "Flight is taking off")
+		recorder.Event(ref, corev1.EventTypeNormal, ReasonTakeoffStarted, "Flight is taking off")
+
+		ctx = wasi.WithExecStats(ctx)
+		ctx = host.WithDiagnostics(ctx)
+
+		err = commander.Takeoff(ctx, takeoffParams)
+		takeoffErr = err
+		recordTakeoffResult(recorder, ref, err)
+		recordDiagnostics(recorder, ref, host.Diagnostics(ctx))
+
+		execution = executionStatus(ctx, err)
+
+		// The condition reflects the diagnostics of the last successful evaluation of the flight.
+		if err == nil || internal.IsWarning(err) {
+			condition := diagnosticsCondition(resource.GetGeneration(), host.Diagnostics(ctx))
+			diagnostics = &condition
+			if overrideURL == "" {
+				module = &targetModule
+			}
+		}
 
-		return ctrl.Result{RequeueAfter: params.Airway.Spec.FixDriftInterval.Duration}, commander.Takeoff(ctx, takeoffParams)
+		return ctrl.Result{RequeueAfter: params.Airway.Spec.FixDriftInterval.Duration}, err
 	}
 
 	return ctrl.Funcs{
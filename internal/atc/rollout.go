@@ -0,0 +1,290 @@
+package atc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilnet "k8s.io/apimachinery/pkg/util/net"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
+	"github.com/yokecd/yoke/pkg/flight"
+	"github.com/yokecd/yoke/pkg/k8s/ctrl"
+)
+
+// rolloutRetryInterval is the interval at which instances waiting to be upgraded are requeued.
+// Instances are requeued as soon as an upgrade completes, but upgrades completed by other shards are not observed.
+const rolloutRetryInterval = 30 * time.Second
+
+// errUpgradeNotAttempted is passed to the done function of an upgrading instance whose reconcile failed before its takeoff.
+var errUpgradeNotAttempted = errors.New("upgrade not attempted")
+
+// isTransient reports whether the error stems from the availability of the cluster or from a concurrent update
+// rather than from the evaluation or the resources of the flight.
+func isTransient(err error) bool {
+	return errors.Is(err, context.Canceled) ||
+		kerrors.IsConflict(err) ||
+		kerrors.IsServerTimeout(err) ||
+		kerrors.IsTimeout(err) ||
+		kerrors.IsTooManyRequests(err) ||
+		kerrors.IsServiceUnavailable(err) ||
+		kerrors.IsInternalError(err) ||
+		utilnet.IsConnectionRefused(err) ||
+		utilnet.IsConnectionReset(err)
+}
+
+// airwayModule returns the flight module of the airway.
+func airwayModule(airway v1alpha1.Airway) flight.Module {
+	return flight.Module{
+		URL:      airway.Spec.WasmURLs.Flight,
+		Checksum: airway.Spec.WasmURLs.FlightChecksum,
+	}
+}
+
+// InstanceModule returns the flight module reported under status.flight by an airway instance, or the zero module if it reported none.
+func InstanceModule(resource *unstructured.Unstructured) flight.Module {
+	url, _, _ := unstructured.NestedString(resource.Object, "status", "flight", "url")
+	checksum, _, _ := unstructured.NestedString(resource.Object, "status", "flight", "checksum")
+	return flight.Module{URL: url, Checksum: checksum}
+}
+
+// nextRolloutStatus returns the rollout status of the airway given its spec. A new rollout is started when the flight of the airway changes,
+// and the failures of the current rollout are cleared when it is resumed via the v1alpha1.AnnotationResumeRollout annotation.
+func nextRolloutStatus(airway v1alpha1.Airway) v1alpha1.RolloutStatus {
+	target := airwayModule(airway)
+
+	var status v1alpha1.RolloutStatus
+	switch current := airway.Status.Rollout; {
+	case current == nil:
+		status = v1alpha1.RolloutStatus{Flight: target}
+	case current.Flight != target:
+		status = v1alpha1.RolloutStatus{Flight: target, Previous: current.Flight, Resumed: current.Resumed}
+	default:
+		status = *current
+		status.Failed = slices.Clone(current.Failed)
+	}
+
+	if resume := airway.Annotations[v1alpha1.AnnotationResumeRollout]; resume != "" && resume != status.Resumed {
+		status.Failed = nil
+		status.Resumed = resume
+	}
+
+	status.Paused = airway.Spec.Rollout != nil && airway.Spec.Rollout.MaxFailures > 0 && len(status.Failed) >= airway.Spec.Rollout.MaxFailures
+
+	return status
+}
+
+// rollout coordinates the upgrade of the instances of an airway to a new flight as described by the airway's rollout policy.
+// It is shared by the reconcilers of the airway's instances for the lifetime of their flight controller.
+//
+// The progress of the rollout is not held in memory: instances report the flight they run in their status,
+// and failed upgrades are recorded in the status of the airway. Hence a rollout resumes where it left off when the ATC restarts.
+type rollout struct {
+	airway   string
+	ref      *corev1.ObjectReference
+	gk       schema.GroupKind
+	policy   *v1alpha1.RolloutPolicy
+	canary   labels.Selector
+	target   flight.Module
+	previous flight.Module
+
+	mutex     sync.Mutex
+	failed    []string
+	paused    bool
+	upgrading map[string]struct{}
+	upgraded  map[string]struct{}
+	waiting   map[string]ctrl.Event
+}
+
+func newRollout(airway v1alpha1.Airway, gk schema.GroupKind, status v1alpha1.RolloutStatus) (*rollout, error) {
+	result := &rollout{
+		airway:    airway.Name,
+		ref:       EventRef(v1alpha1.APIVersion, v1alpha1.KindAirway, &airway),
+		gk:        gk,
+		policy:    airway.Spec.Rollout,
+		target:    status.Flight,
+		previous:  status.Previous,
+		failed:    slices.Clone(status.Failed),
+		paused:    status.Paused,
+		upgrading: map[string]struct{}{},
+		upgraded:  map[string]struct{}{},
+		waiting:   map[string]ctrl.Event{},
+	}
+
+	if result.policy != nil && result.policy.Canary != nil {
+		selector, err := metav1.LabelSelectorAsSelector(result.policy.Canary)
+		if err != nil {
+			return nil, fmt.Errorf("invalid canary selector: %w", err)
+		}
+		result.canary = selector
+	}
+
+	return result, nil
+}
+
+// Module returns the flight module the instance must be evaluated with. Instances that are not upgraded yet keep running the flight they last ran
+// successfully, in which case pending reports whether the instance is waiting to be upgraded. Instances that never ran successfully run the target flight.
+//
+// If the instance is being upgraded, done must be called with the result of its takeoff, or with errUpgradeNotAttempted if the reconcile failed before it.
+func (rollout *rollout) Module(ctx context.Context, event ctrl.Event, instance *unstructured.Unstructured) (module flight.Module, pending bool, done func(error)) {
+	noop := func(error) {}
+
+	current := InstanceModule(instance)
+	if rollout.policy == nil || current == (flight.Module{}) || current == rollout.target {
+		return rollout.target, false, noop
+	}
+
+	rollout.mutex.Lock()
+	defer rollout.mutex.Unlock()
+
+	ref := event.String()
+
+	if rollout.paused || slices.Contains(rollout.failed, ref) {
+		return current, false, noop
+	}
+
+	if _, ok := rollout.upgrading[ref]; !ok {
+		if rollout.policy.MaxConcurrent > 0 && len(rollout.upgrading) >= rollout.policy.MaxConcurrent {
+			rollout.waiting[ref] = event.WithoutMeta()
+			return current, true, noop
+		}
+		if rollout.canary != nil && !rollout.canary.Matches(labels.Set(instance.GetLabels())) && rollout.canariesPending(ctx) {
+			rollout.waiting[ref] = event.WithoutMeta()
+			return current, true, noop
+		}
+		rollout.upgrading[ref] = struct{}{}
+	}
+
+	return rollout.target, false, func(err error) { rollout.done(ctx, ref, err) }
+}
+
+// canariesPending reports whether any canary instance does not run the target flight yet.
+func (rollout *rollout) canariesPending(ctx context.Context) bool {
+	instances := ctrl.Cache[unstructured.Unstructured](ctx, rollout.gk, "")
+	if instances == nil {
+		return true
+	}
+	canaries, err := instances.List(rollout.canary)
+	if err != nil {
+		ctrl.Logger(ctx).Error("failed to list canary instances", "error", err)
+		return true
+	}
+	for _, canary := range canaries {
+		ref := ctrl.Event{Name: canary.GetName(), Namespace: canary.GetNamespace(), GroupKind: rollout.gk}.String()
+		if _, ok := rollout.upgraded[ref]; ok {
+			continue
+		}
+		if InstanceModule(canary) != rollout.target {
+			return true
+		}
+	}
+	return false
+}
+
+func (rollout *rollout) done(ctx context.Context, ref string, err error) {
+	failed, paused := rollout.settle(ctx, ref, err)
+
+	// The failures are persisted without holding the lock, such that a slow or conflicting update of the airway status
+	// does not stall the reconciles of the other instances of the airway.
+	if failed != nil {
+		if err := rollout.recordFailures(ctx, failed); err != nil {
+			ctrl.Logger(ctx).Error("failed to record failed instance upgrade", "instance", ref, "error", err)
+		}
+	}
+
+	if paused {
+		ctrl.Recorder(ctx).Eventf(
+			rollout.ref,
+			corev1.EventTypeWarning,
+			ReasonRolloutPaused,
+			"Rollout of %s paused after %d failed instance upgrades",
+			rollout.target.URL,
+			len(failed),
+		)
+	}
+}
+
+// settle records the result of the upgrade of the instance and requeues the instances waiting for it unless the rollout is paused.
+// If the upgrade failed, it returns the failed instances of the rollout to persist, and whether the failure paused the rollout.
+func (rollout *rollout) settle(ctx context.Context, ref string, err error) (failed []string, paused bool) {
+	rollout.mutex.Lock()
+	defer rollout.mutex.Unlock()
+
+	delete(rollout.upgrading, ref)
+
+	switch {
+	case err == nil || internal.IsWarning(err):
+		rollout.upgraded[ref] = struct{}{}
+	case errors.Is(err, errUpgradeNotAttempted) || isTransient(err):
+		// The target flight was not at fault: the upgrade is retried when the instance is next reconciled.
+	case !slices.Contains(rollout.failed, ref):
+		rollout.failed = append(rollout.failed, ref)
+
+		wasPaused := rollout.paused
+		rollout.paused = rollout.policy.MaxFailures > 0 && len(rollout.failed) >= rollout.policy.MaxFailures
+
+		failed = slices.Clone(rollout.failed)
+		paused = rollout.paused && !wasPaused
+	}
+
+	if rollout.paused {
+		return failed, paused
+	}
+
+	for key, event := range rollout.waiting {
+		ctrl.Inst(ctx).SendEvent(event)
+		delete(rollout.waiting, key)
+	}
+
+	return failed, paused
+}
+
+// recordFailures adds the failed instances to the status of the airway. Failures recorded by other shards or by concurrent calls are preserved.
+func (rollout *rollout) recordFailures(ctx context.Context, failed []string) error {
+	airwayIntf := (*k8s.Client)(ctrl.Client(ctx)).AirwayIntf()
+
+	return retry.RetryOnConflict(retry.DefaultBackoff, func() error {
+		airway, err := airwayIntf.Get(ctx, rollout.airway, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		status := airway.Status.Rollout
+		if status == nil || status.Flight != rollout.target {
+			// The rollout has been superseded.
+			return nil
+		}
+
+		changed := false
+		for _, ref := range failed {
+			if !slices.Contains(status.Failed, ref) {
+				status.Failed = append(status.Failed, ref)
+				changed = true
+			}
+		}
+
+		if paused := rollout.policy.MaxFailures > 0 && len(status.Failed) >= rollout.policy.MaxFailures; paused != status.Paused {
+			status.Paused = paused
+			changed = true
+		}
+
+		if !changed {
+			return nil
+		}
+
+		_, err = airwayIntf.UpdateStatus(ctx, airway, metav1.UpdateOptions{FieldManager: fieldManager})
+		return err
+	})
+}
@@ -0,0 +1,97 @@
+package atc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
+	"github.com/yokecd/yoke/pkg/flight"
+	"github.com/yokecd/yoke/pkg/k8s/ctrl"
+)
+
+func TestRolloutModule(t *testing.T) {
+	previous := flight.Module{URL: "https://example.com/v1.wasm", Checksum: "v1"}
+	target := flight.Module{URL: "https://example.com/v2.wasm", Checksum: "v2"}
+
+	gk := schema.GroupKind{Group: "examples.com", Kind: "Backend"}
+
+	rollout, err := newRollout(
+		v1alpha1.Airway{Spec: v1alpha1.AirwaySpec{Rollout: &v1alpha1.RolloutPolicy{MaxConcurrent: 1}}},
+		gk,
+		v1alpha1.RolloutStatus{Flight: target, Previous: previous},
+	)
+	require.NoError(t, err)
+
+	instance := func(name string, module *flight.Module) (ctrl.Event, *unstructured.Unstructured) {
+		resource := &unstructured.Unstructured{Object: map[string]any{}}
+		resource.SetName(name)
+		if module != nil {
+			require.NoError(t, unstructured.SetNestedStringMap(resource.Object, map[string]string{"url": module.URL, "checksum": module.Checksum}, "status", "flight"))
+		}
+		return ctrl.Event{Name: name, GroupKind: gk}, resource
+	}
+
+	ctx := context.Background()
+
+	t.Run("never run instances run the target", func(t *testing.T) {
+		event, resource := instance("new", nil)
+
+		module, pending, _ := rollout.Module(ctx, event, resource)
+		require.Equal(t, target, module)
+		require.False(t, pending)
+		require.Empty(t, rollout.upgrading)
+	})
+
+	t.Run("failures unrelated to the target flight are retried", func(t *testing.T) {
+		event, resource := instance("existing", &previous)
+
+		for _, cause := range []error{
+			errUpgradeNotAttempted,
+			fmt.Errorf("failed to apply resources: %w", kerrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, "x", errors.New("conflict"))),
+			kerrors.NewServiceUnavailable("unavailable"),
+		} {
+			module, pending, done := rollout.Module(ctx, event, resource)
+			require.Equal(t, target, module)
+			require.False(t, pending)
+			require.Len(t, rollout.upgrading, 1)
+
+			done(cause)
+
+			require.Empty(t, rollout.upgrading)
+			require.Empty(t, rollout.failed)
+			require.Empty(t, rollout.upgraded)
+		}
+	})
+
+	t.Run("failures are returned to be persisted outside of the lock", func(t *testing.T) {
+		rollout, err := newRollout(
+			v1alpha1.Airway{Spec: v1alpha1.AirwaySpec{Rollout: &v1alpha1.RolloutPolicy{MaxFailures: 1}}},
+			gk,
+			v1alpha1.RolloutStatus{Flight: target, Previous: previous},
+		)
+		require.NoError(t, err)
+
+		event, _ := instance("failing", &previous)
+
+		failed, paused := rollout.settle(ctx, event.String(), errors.New("flight failed"))
+		require.Equal(t, []string{event.String()}, failed)
+		require.True(t, paused)
+		require.True(t, rollout.paused)
+
+		require.True(t, rollout.mutex.TryLock(), "rollout must not be locked once the upgrade is settled")
+		rollout.mutex.Unlock()
+
+		// Failing again neither records the instance twice nor reports the pause again.
+		failed, paused = rollout.settle(ctx, event.String(), errors.New("flight failed"))
+		require.Nil(t, failed)
+		require.False(t, paused)
+	})
+}
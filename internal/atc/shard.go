@@ -0,0 +1,99 @@
+package atc
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/yokecd/yoke/pkg/k8s/ctrl"
+)
+
+// Shard describes the subset of Flights, ClusterFlights and Airway instances owned by an ATC deployment,
+// allowing multiple ATCs to share a cluster. The zero value is an unsharded ATC owning every resource.
+type Shard struct {
+	// Name uniquely identifies the shard within the cluster.
+	// Cluster wide resources created by the ATC such as its webhooks are suffixed with it so that shards do not conflict.
+	Name string
+	// Namespaces restricts the shard to namespaced resources within these namespaces.
+	// A shard restricted to namespaces does not own cluster scoped resources, but still manages Airways. See OwnsAirway.
+	Namespaces []string
+	// Selector restricts the shard to resources matching the label selector.
+	Selector *metav1.LabelSelector
+}
+
+// ResourceName returns name suffixed by the shard's name.
+func (shard Shard) ResourceName(name string) string {
+	if shard.Name == "" {
+		return name
+	}
+	return name + "." + shard.Name
+}
+
+// OwnsClusterScope reports whether the shard owns cluster scoped resources.
+func (shard Shard) OwnsClusterScope() bool {
+	return len(shard.Namespaces) == 0
+}
+
+// Owns reports whether a resource with the given namespace and labels belongs to the shard.
+// Cluster scoped resources have an empty namespace.
+func (shard Shard) Owns(namespace string, lbls map[string]string) bool {
+	if namespace == "" && !shard.OwnsClusterScope() {
+		return false
+	}
+	if namespace != "" && len(shard.Namespaces) > 0 && !slices.Contains(shard.Namespaces, namespace) {
+		return false
+	}
+	return shard.selector().Matches(labels.Set(lbls))
+}
+
+// OwnsAirway reports whether the shard manages the custom resource definition, finalizer and status of an Airway with the given labels.
+// Airways are cluster scoped but unlike Owns, only the selector is matched: shards restricted to namespaces must still manage the Airways
+// of their instances, otherwise no shard would when every shard is restricted to namespaces. Shards with overlapping selectors all manage
+// the Airway, which is safe as applying its definition and updating its finalizer and status are idempotent.
+func (shard Shard) OwnsAirway(lbls map[string]string) bool {
+	return shard.selector().Matches(labels.Set(lbls))
+}
+
+// Scope returns the controller scope watching only the resources owned by the shard.
+func (shard Shard) Scope() ctrl.Scope {
+	return ctrl.Scope{
+		Namespaces: shard.Namespaces,
+		Selector:   shard.selector(),
+	}
+}
+
+// NamespaceSelector returns the webhook namespace selector matching the namespaces of the shard.
+// It is nil when the shard is not restricted to namespaces.
+func (shard Shard) NamespaceSelector() *metav1.LabelSelector {
+	if len(shard.Namespaces) == 0 {
+		return nil
+	}
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      corev1.LabelMetadataName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   shard.Namespaces,
+			},
+		},
+	}
+}
+
+// ObjectSelector returns the webhook object selector matching the resources of the shard.
+func (shard Shard) ObjectSelector() *metav1.LabelSelector {
+	return shard.Selector
+}
+
+func (shard Shard) selector() labels.Selector {
+	if shard.Selector == nil {
+		return labels.Everything()
+	}
+	// The selector is validated when the configuration is loaded.
+	selector, err := metav1.LabelSelectorAsSelector(shard.Selector)
+	if err != nil {
+		return labels.Nothing()
+	}
+	return selector
+}
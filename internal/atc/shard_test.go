@@ -0,0 +1,52 @@
+package atc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestShardOwnership(t *testing.T) {
+	t.Run("namespace only shards", func(t *testing.T) {
+		shards := []Shard{
+			{Name: "a", Namespaces: []string{"foo"}},
+			{Name: "b", Namespaces: []string{"bar"}},
+		}
+
+		for _, shard := range shards {
+			require.False(t, shard.OwnsClusterScope(), shard.Name)
+			require.False(t, shard.Owns("", nil), shard.Name)
+			require.True(t, shard.OwnsAirway(nil), "shard %s must manage airways", shard.Name)
+			require.True(t, shard.OwnsAirway(map[string]string{"team": "x"}), "shard %s must manage airways", shard.Name)
+		}
+
+		require.True(t, shards[0].Owns("foo", nil))
+		require.False(t, shards[0].Owns("bar", nil))
+		require.True(t, shards[1].Owns("bar", nil))
+		require.False(t, shards[1].Owns("foo", nil))
+	})
+
+	t.Run("selector shards", func(t *testing.T) {
+		shard := Shard{
+			Name:       "x",
+			Namespaces: []string{"foo"},
+			Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"team": "x"}},
+		}
+
+		require.True(t, shard.OwnsAirway(map[string]string{"team": "x"}))
+		require.False(t, shard.OwnsAirway(map[string]string{"team": "y"}))
+		require.False(t, shard.OwnsAirway(nil))
+
+		require.True(t, shard.Owns("foo", map[string]string{"team": "x"}))
+		require.False(t, shard.Owns("foo", map[string]string{"team": "y"}))
+	})
+
+	t.Run("unsharded", func(t *testing.T) {
+		var shard Shard
+		require.True(t, shard.OwnsClusterScope())
+		require.True(t, shard.Owns("", nil))
+		require.True(t, shard.OwnsAirway(nil))
+	})
+}
@@ -67,11 +67,7 @@ func MakeFlightListView(title string, refresh *RefreshConfig, dim tea.WindowSize
 					},
 					Cmd: func() tea.Msg {
 						return ExecMsg(func(cmds Commands) tea.Cmd {
-							name := resource.GetName()
-							if namespace := resource.GetNamespace(); namespace != "" {
-								name = atc.ReleaseName(&resource)
-							}
-							return cmds.GetRevisionResources(name, resource.GetNamespace())
+							return cmds.GetRevisionResources(atc.ReleaseName(&resource), resource.GetNamespace())
 						})
 					},
 					Desc: "view resources",
@@ -18,6 +18,8 @@ import (
 
 	"github.com/davidmdm/x/xerr"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
@@ -36,6 +38,7 @@ import (
 	"k8s.io/utils/ptr"
 
 	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/pkg/apis/v1alpha1"
 )
 
@@ -111,6 +114,15 @@ func NewClient(cfg *rest.Config, ns string) (*Client, error) {
 	}, nil
 }
 
+// DryRunError is returned by ApplyResources when resources fail to apply in dry-run mode.
+// When this error is returned no changes have been made to the cluster.
+type DryRunError struct {
+	Err error
+}
+
+func (err DryRunError) Error() string { return err.Err.Error() }
+func (err DryRunError) Unwrap() error { return err.Err }
+
 type ApplyResourcesOpts struct {
 	SkipDryRun bool
 	ApplyOpts
@@ -125,15 +137,23 @@ func (client Client) ApplyResources(ctx context.Context, resources []*unstructur
 		applyOpts := applyOpts
 		applyOpts.DryRun = true
 
-		if err := xerr.MultiErrOrderedFrom("dry run", client.applyMany(ctx, resources, applyOpts)...); err != nil {
-			return err
+		ctx, span := tracing.Start(ctx, "dry run", attribute.Int("resources", len(resources)))
+		err := xerr.MultiErrOrderedFrom("dry run", client.applyMany(ctx, resources, applyOpts)...)
+		tracing.End(span, err)
+
+		if err != nil {
+			return DryRunError{Err: err}
 		}
 		if opts.DryRun {
 			return nil
 		}
 	}
 
-	return xerr.JoinOrdered(client.applyMany(ctx, resources, applyOpts)...)
+	ctx, span := tracing.Start(ctx, "apply", attribute.Int("resources", len(resources)))
+	err := xerr.JoinOrdered(client.applyMany(ctx, resources, applyOpts)...)
+	tracing.End(span, err)
+
+	return err
 }
 
 func (client Client) applyMany(ctx context.Context, resources []*unstructured.Unstructured, opts ApplyOpts) []error {
@@ -170,6 +190,18 @@ type ApplyOpts struct {
 }
 
 func (client Client) ApplyResource(ctx context.Context, resource *unstructured.Unstructured, opts ApplyOpts) error {
+	_, err := client.applyResource(ctx, resource, opts)
+	return err
+}
+
+// DryRunApplyResource applies the resource in dry-run mode and returns the state the resource would have after being applied.
+// The result accounts for server-side defaulting, mutating webhooks, and fields owned by other field managers.
+func (client Client) DryRunApplyResource(ctx context.Context, resource *unstructured.Unstructured, opts ApplyOpts) (*unstructured.Unstructured, error) {
+	opts.DryRun = true
+	return client.applyResource(ctx, resource, opts)
+}
+
+func (client Client) applyResource(ctx context.Context, resource *unstructured.Unstructured, opts ApplyOpts) (*unstructured.Unstructured, error) {
 	defer internal.DebugTimer(
 		ctx,
 		fmt.Sprintf(
@@ -187,11 +219,11 @@ func (client Client) ApplyResource(ctx context.Context, resource *unstructured.U
 
 	intf, err := client.GetDynamicResourceInterface(resource)
 	if err != nil {
-		return fmt.Errorf("failed to resolve resource: %w", err)
+		return nil, fmt.Errorf("failed to resolve resource: %w", err)
 	}
 
 	if err := client.checkOwnership(ctx, resource, opts); err != nil {
-		return fmt.Errorf("failed to validate resource release: %w", err)
+		return nil, fmt.Errorf("failed to validate resource release: %w", err)
 	}
 
 	dryRun := func() []string {
@@ -203,10 +235,10 @@ func (client Client) ApplyResource(ctx context.Context, resource *unstructured.U
 
 	data, err := json.Marshal(resource)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = intf.Patch(
+	return intf.Patch(
 		ctx,
 		resource.GetName(),
 		types.ApplyPatchType,
@@ -217,7 +249,6 @@ func (client Client) ApplyResource(ctx context.Context, resource *unstructured.U
 			DryRun:       dryRun,
 		},
 	)
-	return err
 }
 
 func (client Client) checkOwnership(ctx context.Context, resource *unstructured.Unstructured, opts ApplyOpts) error {
@@ -252,6 +283,12 @@ type PruneOpts struct {
 func (client Client) PruneReleaseDiff(ctx context.Context, previous, next internal.Stages, opts PruneOpts) (removed, orphaned []*unstructured.Unstructured, err error) {
 	defer internal.DebugTimer(ctx, "prune release diff")()
 
+	ctx, span := tracing.Start(ctx, "prune")
+	defer func() {
+		span.SetAttributes(attribute.Int("removed", len(removed)), attribute.Int("orphaned", len(orphaned)))
+		tracing.End(span, err)
+	}()
+
 	curentSet := make(map[string]*unstructured.Unstructured)
 	for _, resource := range next.Flatten() {
 		curentSet[internal.CanonicalWithoutVersion(resource)] = resource
@@ -388,16 +425,17 @@ func (client Client) GetRelease(ctx context.Context, name, ns string) (*internal
 				Ref:      item.Annotations[internal.AnnotationSourceURL],
 				Checksum: item.Annotations[internal.AnnotationSourceChecksum],
 			},
-			CreatedAt: internal.MustParseTime(item.Annotations[internal.AnnotationCreatedAt]),
-			ActiveAt:  internal.MustParseTime(item.Annotations[internal.AnnotationActiveAt]),
-			Resources: internal.MustParseInt(item.Annotations[internal.AnnotationResourceCount]),
+			CreatedAt:   internal.MustParseTime(item.Annotations[internal.AnnotationCreatedAt]),
+			ActiveAt:    internal.MustParseTime(item.Annotations[internal.AnnotationActiveAt]),
+			Resources:   internal.MustParseInt(item.Annotations[internal.AnnotationResourceCount]),
+			Status:      internal.RevisionStatus(item.Annotations[internal.AnnotationStatus]),
+			Error:       item.Annotations[internal.AnnotationError],
+			FailedStage: internal.MustParseInt(item.Annotations[internal.AnnotationFailedStage]),
 		})
 	}
 
 	// The kubernetes API lists object in roughly creation order as per etcd but does not guarantee it.
-	slices.SortStableFunc(release.History, func(a, b internal.Revision) int {
-		return a.CreatedAt.Compare(b.CreatedAt)
-	})
+	release.ResolveStatuses()
 
 	return &release, nil
 }
@@ -495,44 +533,27 @@ func (client Client) GetReleasesByNS(ctx context.Context, ns string) ([]internal
 	return result, nil
 }
 
-func (client Client) CreateRevision(ctx context.Context, release, ns string, revision internal.Revision, stages internal.Stages) error {
-	data, err := json.Marshal(stages)
+func (client Client) CreateRevision(ctx context.Context, release, ns string, revision internal.Revision, stages internal.Stages, state internal.State) error {
+	data, err := encodeStages(stages)
 	if err != nil {
-		return fmt.Errorf("failed to marshal resources: %w", err)
-	}
-
-	data = func() []byte {
-		var buffer bytes.Buffer
-		w := gzip.NewWriter(&buffer)
-		// Since we are only reading to in memory data structures it is safe to ignore potential
-		// write errors. The only way they can fail is if the system is out of memory. If that is the case,
-		// we have bigger fish to fry.
-		_, _ = w.Write(data)
-		_ = w.Close()
-		return buffer.Bytes()
-	}()
+		return err
+	}
+
+	secretData, err := encodeState(state)
+	if err != nil {
+		return err
+	}
+	secretData[internal.KeyResources] = data
 
 	_, err = client.Clientset.CoreV1().Secrets(ns).Create(
 		ctx,
 		&corev1.Secret{
 			ObjectMeta: metav1.ObjectMeta{
-				Name: "yoke." + internal.RandomString(),
-				Labels: map[string]string{
-					internal.LabelKind:    "revision",
-					internal.LabelRelease: internal.SHA1HexFromString(release),
-				},
-				Annotations: map[string]string{
-					internal.AnnotationCreatedAt:      revision.CreatedAt.Format(time.RFC3339Nano),
-					internal.AnnotationActiveAt:       revision.ActiveAt.Format(time.RFC3339Nano),
-					internal.AnnotationResourceCount:  strconv.Itoa(revision.Resources),
-					internal.AnnotationSourceURL:      revision.Source.Ref,
-					internal.AnnotationSourceChecksum: revision.Source.Checksum,
-					internal.AnnotationReleaseName:    release,
-				},
-			},
-			Data: map[string][]byte{
-				internal.KeyResources: data,
+				Name:        "yoke." + internal.RandomString(),
+				Labels:      revisionLabels(release),
+				Annotations: revisionAnnotations(release, revision),
 			},
+			Data: secretData,
 		},
 		metav1.CreateOptions{FieldManager: yoke},
 	)
@@ -540,6 +561,85 @@ func (client Client) CreateRevision(ctx context.Context, release, ns string, rev
 	return err
 }
 
+// encodeState returns the secret data holding the state of a revision. Empty states are not stored.
+func encodeState(state internal.State) (map[string][]byte, error) {
+	data := map[string][]byte{}
+	if len(state) == 0 {
+		return data, nil
+	}
+	encoded, err := json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal state: %w", err)
+	}
+	data[internal.KeyState] = encoded
+	return data, nil
+}
+
+func revisionLabels(release string) map[string]string {
+	return map[string]string{
+		internal.LabelKind:    "revision",
+		internal.LabelRelease: internal.SHA1HexFromString(release),
+	}
+}
+
+func revisionAnnotations(release string, revision internal.Revision) map[string]string {
+	annotations := map[string]string{
+		internal.AnnotationCreatedAt:      revision.CreatedAt.Format(time.RFC3339Nano),
+		internal.AnnotationActiveAt:       revision.ActiveAt.Format(time.RFC3339Nano),
+		internal.AnnotationResourceCount:  strconv.Itoa(revision.Resources),
+		internal.AnnotationSourceURL:      revision.Source.Ref,
+		internal.AnnotationSourceChecksum: revision.Source.Checksum,
+		internal.AnnotationReleaseName:    release,
+	}
+
+	if revision.Failed() {
+		annotations[internal.AnnotationStatus] = string(internal.RevisionStatusFailed)
+		annotations[internal.AnnotationError] = revision.Error
+		annotations[internal.AnnotationFailedStage] = strconv.Itoa(revision.FailedStage)
+	}
+
+	return annotations
+}
+
+// encodeStages returns the gzipped json representation of the stages as stored in revisions.
+func encodeStages(stages internal.Stages) ([]byte, error) {
+	data, err := json.Marshal(stages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resources: %w", err)
+	}
+
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	// Since we are only reading to in memory data structures it is safe to ignore potential
+	// write errors. The only way they can fail is if the system is out of memory. If that is the case,
+	// we have bigger fish to fry.
+	_, _ = w.Write(data)
+	_ = w.Close()
+
+	return buffer.Bytes(), nil
+}
+
+// decodeStages is the inverse of encodeStages.
+func decodeStages(raw []byte) (internal.Stages, error) {
+	data, err := func() ([]byte, error) {
+		r, err := gzip.NewReader(bytes.NewReader(raw))
+		if err != nil {
+			// If it fails to decode the bytes as valid gzip, the secret is from a previous version of yoke
+			// that did not gzip resource content. Use as is; this way we maintain backward compatibility.
+			return raw, nil
+		}
+		return io.ReadAll(r)
+	}()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secret data: %w", err)
+	}
+
+	var stages internal.Stages
+	err = json.Unmarshal(data, &stages)
+
+	return stages, err
+}
+
 func (client Client) UpdateRevisionActiveState(ctx context.Context, revision internal.Revision) error {
 	secrets := client.Clientset.CoreV1().Secrets(revision.Namespace)
 
@@ -559,19 +659,11 @@ func (client Client) CapReleaseHistory(ctx context.Context, name, ns string, siz
 	if err != nil {
 		return fmt.Errorf("failed to load release: %w", err)
 	}
-	slices.SortStableFunc(release.History, func(a, b internal.Revision) int {
-		return b.ActiveAt.Compare(a.ActiveAt)
-	})
-
-	// Nothing to delete
-	if size >= len(release.History) {
-		return nil
-	}
 
 	secretIntf := client.Clientset.CoreV1().Secrets(ns)
 
 	var errs []error
-	for _, revision := range release.History[size:] {
+	for _, revision := range release.ExcessHistory(size) {
 		if err := secretIntf.Delete(ctx, revision.Name, metav1.DeleteOptions{}); err != nil {
 			if kerrors.IsNotFound(err) {
 				continue
@@ -624,6 +716,11 @@ func (client Client) UnlockRelease(ctx context.Context, release internal.Release
 }
 
 func (client Client) GetRevisionResources(ctx context.Context, revision internal.Revision) (internal.Stages, error) {
+	if revision.Name == "" {
+		// The zero revision is returned when looking up the active revision of a release that has none.
+		return nil, nil
+	}
+
 	secret, err := client.Clientset.CoreV1().Secrets(revision.Namespace).Get(ctx, revision.Name, metav1.GetOptions{})
 	if err != nil {
 		if kerrors.IsNotFound(err) {
@@ -632,24 +729,41 @@ func (client Client) GetRevisionResources(ctx context.Context, revision internal
 		return nil, err
 	}
 
-	data, err := func() ([]byte, error) {
-		raw := secret.Data[internal.KeyResources]
-		r, err := gzip.NewReader(bytes.NewReader(raw))
-		if err != nil {
-			// If it fails to decode the bytes as valid gzip, the secret is from a previous version of yoke
-			// that did not gzip resource content. Use as is; this way we maintain backward compatibility.
-			return raw, nil
+	raw := secret.Data[internal.KeyResources]
+
+	if _, ok := secret.Annotations[AnnotationChunks]; ok {
+		if raw, err = client.readChunks(ctx, secret); err != nil {
+			return nil, fmt.Errorf("failed to read revision chunks: %w", err)
 		}
-		return io.ReadAll(r)
-	}()
+	}
+
+	return decodeStages(raw)
+}
+
+func (client Client) GetRevisionState(ctx context.Context, revision internal.Revision) (internal.State, error) {
+	if revision.Name == "" {
+		return nil, nil
+	}
+
+	secret, err := client.Clientset.CoreV1().Secrets(revision.Namespace).Get(ctx, revision.Name, metav1.GetOptions{})
 	if err != nil {
-		return nil, fmt.Errorf("failed to read secret data: %w", err)
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
 	}
 
-	var stages internal.Stages
-	err = json.Unmarshal(data, &stages)
+	raw, ok := secret.Data[internal.KeyState]
+	if !ok {
+		return nil, nil
+	}
 
-	return stages, err
+	var state internal.State
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+
+	return state, nil
 }
 
 func (client Client) GetDynamicResourceInterface(resource *unstructured.Unstructured) (dynamic.ResourceInterface, error) {
@@ -752,9 +866,12 @@ func (client Client) IsReady(ctx context.Context, resource *unstructured.Unstruc
 	return client.isReady(ctx, state)
 }
 
-func (client Client) WaitForReadyMany(ctx context.Context, resources []*unstructured.Unstructured, opts WaitOptions) error {
+func (client Client) WaitForReadyMany(ctx context.Context, resources []*unstructured.Unstructured, opts WaitOptions) (err error) {
 	defer internal.DebugTimer(ctx, "waiting for resources to become ready")()
 
+	ctx, span := tracing.Start(ctx, "wait for ready", attribute.Int("resources", len(resources)))
+	defer func() { tracing.End(span, err) }()
+
 	var wg sync.WaitGroup
 	wg.Add(len(resources))
 	defer wg.Wait()
@@ -918,6 +1035,10 @@ func (client Client) PatchMany(ctx context.Context, resources []*unstructured.Un
 func (client Client) OrhpanResource(ctx context.Context, resource *unstructured.Unstructured) error {
 	resource, err := client.GetInClusterState(ctx, resource)
 	if err != nil {
+		if kerrors.IsNotFound(err) {
+			// Nothing to orphan if the resource does not exist.
+			return nil
+		}
 		return fmt.Errorf("failed to get in cluster state: %w", err)
 	}
 	if labels := resource.GetLabels(); labels != nil {
@@ -0,0 +1,147 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+// RenameRelease moves the revision history of the release named from to the release named to.
+//
+// The resources of the active revision are annotated with the new release name in place, such that they
+// remain owned by the release without being redeployed. The resources stored in revisions are rewritten as well
+// so that the next takeoff of the release is not a diff, and descending to a previous revision does not revert the rename.
+//
+// RenameRelease is idempotent: it does nothing if the release named from has no revisions,
+// and renaming a partially renamed release completes the rename.
+func (client Client) RenameRelease(ctx context.Context, from, to, ns string) error {
+	release, err := client.GetRelease(ctx, from, ns)
+	if err != nil {
+		return fmt.Errorf("failed to get release: %w", err)
+	}
+	if len(release.History) == 0 {
+		return nil
+	}
+
+	stages, err := client.GetRevisionResources(ctx, release.ActiveRevision())
+	if err != nil {
+		return fmt.Errorf("failed to get resources of active revision: %w", err)
+	}
+
+	// Resources are annotated before revisions are relabelled: once relabelled, the release cannot be found under its previous name
+	// and resources that were not annotated would no longer be owned by either release.
+	for _, resource := range stages.Flatten() {
+		if internal.GetAnnotation(resource, internal.AnnotationYokeRelease) != from {
+			continue
+		}
+		patch := []PatchAction{
+			{
+				Op:    PatchOpAdd,
+				Path:  "/metadata/annotations/" + PatchEscape(internal.AnnotationYokeRelease),
+				Value: to,
+			},
+		}
+		if err := client.Patch(ctx, resource, patch); err != nil && !kerrors.IsNotFound(err) {
+			return fmt.Errorf("failed to annotate %s: %w", internal.Canonical(resource), err)
+		}
+	}
+
+	target, err := client.GetRelease(ctx, to, ns)
+	if err != nil {
+		return fmt.Errorf("failed to get renamed release: %w", err)
+	}
+
+	for _, revision := range release.History {
+		if err := client.renameRevision(ctx, revision, *target, from, to); err != nil {
+			return fmt.Errorf("failed to rename revision %s: %w", revision.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// renameRevision rewrites the resources of the revision owned by the release named from to be owned by the release named to,
+// and relabels the revision as part of the release named to.
+func (client Client) renameRevision(ctx context.Context, revision internal.Revision, target internal.Release, from, to string) error {
+	secrets := client.Clientset.CoreV1().Secrets(revision.Namespace)
+
+	secret, err := secrets.Get(ctx, revision.Name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get revision secret: %w", err)
+	}
+
+	if _, chunked := secret.Annotations[AnnotationChunks]; chunked {
+		return client.renameChunkedRevision(ctx, revision, target, from, to)
+	}
+
+	stages, err := decodeStages(secret.Data[internal.KeyResources])
+	if err != nil {
+		return fmt.Errorf("failed to decode revision resources: %w", err)
+	}
+
+	data, err := encodeStages(renameResources(stages, from, to))
+	if err != nil {
+		return err
+	}
+	secret.Data[internal.KeyResources] = data
+
+	// Updating the revision secret is the commit point of the rename: it moves the revision to the release named to.
+	maps.Copy(secret.Labels, revisionLabels(to))
+	secret.Annotations[internal.AnnotationReleaseName] = to
+
+	if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{FieldManager: yoke}); err != nil {
+		return fmt.Errorf("failed to update revision secret: %w", err)
+	}
+
+	return nil
+}
+
+// renameChunkedRevision moves a chunked revision to the release named to. Its chunks cannot be rewritten atomically in place,
+// hence the revision is recreated as part of the release named to before being deleted. A revision that the release named to
+// already holds, identified by its creation time, is not recreated such that an interrupted rename can be completed.
+func (client Client) renameChunkedRevision(ctx context.Context, revision internal.Revision, target internal.Release, from, to string) error {
+	if !slices.ContainsFunc(target.History, func(existing internal.Revision) bool { return existing.CreatedAt.Equal(revision.CreatedAt) }) {
+		stages, err := client.GetRevisionResources(ctx, revision)
+		if err != nil {
+			return fmt.Errorf("failed to get revision resources: %w", err)
+		}
+
+		state, err := client.GetRevisionState(ctx, revision)
+		if err != nil {
+			return fmt.Errorf("failed to get revision state: %w", err)
+		}
+
+		store := NewChunkedSecretStore(&client, DefaultChunkSize)
+		if err := store.CreateRevision(ctx, to, revision.Namespace, revision, renameResources(stages, from, to), state); err != nil {
+			return fmt.Errorf("failed to recreate revision: %w", err)
+		}
+	}
+
+	if err := client.Clientset.CoreV1().Secrets(revision.Namespace).Delete(ctx, revision.Name, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete revision secret: %w", err)
+	}
+
+	return nil
+}
+
+// renameResources sets the release of the resources owned by the release named from to the release named to.
+func renameResources(stages internal.Stages, from, to string) internal.Stages {
+	for _, resource := range stages.Flatten() {
+		annotations := resource.GetAnnotations()
+		if annotations[internal.AnnotationYokeRelease] != from {
+			continue
+		}
+		annotations[internal.AnnotationYokeRelease] = to
+		resource.SetAnnotations(annotations)
+	}
+	return stages
+}
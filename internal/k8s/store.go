@@ -0,0 +1,161 @@
+package k8s
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+// ReleaseStore persists the revision history of releases.
+//
+// The Client is the default ReleaseStore and stores every revision as a single gzipped Secret labelled internal.yoke/kind=revision.
+//
+// Revisions are created with a snapshot of the release's key/value state, such that the state of the active revision
+// is always consistent with its resources.
+type ReleaseStore interface {
+	GetRelease(ctx context.Context, name, ns string) (*internal.Release, error)
+	GetReleases(ctx context.Context) ([]internal.Release, error)
+	GetReleasesByNS(ctx context.Context, ns string) ([]internal.Release, error)
+	GetRevisionResources(ctx context.Context, revision internal.Revision) (internal.Stages, error)
+	GetRevisionState(ctx context.Context, revision internal.Revision) (internal.State, error)
+	CreateRevision(ctx context.Context, release, ns string, revision internal.Revision, stages internal.Stages, state internal.State) error
+	UpdateRevisionActiveState(ctx context.Context, revision internal.Revision) error
+	CapReleaseHistory(ctx context.Context, name, ns string, size int) error
+	DeleteRevisions(ctx context.Context, release internal.Release) error
+	LockRelease(ctx context.Context, release internal.Release) error
+	UnlockRelease(ctx context.Context, release internal.Release) error
+}
+
+var (
+	_ ReleaseStore = (*Client)(nil)
+	_ ReleaseStore = (*ChunkedSecretStore)(nil)
+	_ ReleaseStore = (*FileSystemStore)(nil)
+)
+
+const (
+	// AnnotationChunks is set on revision secrets whose resources are split across chunk secrets.
+	AnnotationChunks = "internal.yoke/chunks"
+
+	// DefaultChunkSize leaves ample room below the 1MiB Secret limit for metadata.
+	DefaultChunkSize = 512 * 1024
+)
+
+// ChunkedSecretStore stores revisions like the default Secret store but splits the gzipped resources of a revision
+// across as many Secrets as needed. This allows for releases whose compressed resources exceed the 1MiB Secret limit.
+//
+// The revision secret holds the metadata of the revision and owns its chunk secrets, such that they are garbage collected with it.
+// Revisions created by the default Secret store remain readable, and the Client can read chunked revisions.
+type ChunkedSecretStore struct {
+	*Client
+	ChunkSize int
+}
+
+func NewChunkedSecretStore(client *Client, chunkSize int) *ChunkedSecretStore {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	return &ChunkedSecretStore{Client: client, ChunkSize: chunkSize}
+}
+
+func (store ChunkedSecretStore) CreateRevision(ctx context.Context, release, ns string, revision internal.Revision, stages internal.Stages, state internal.State) error {
+	data, err := encodeStages(stages)
+	if err != nil {
+		return err
+	}
+
+	stateData, err := encodeState(state)
+	if err != nil {
+		return err
+	}
+
+	chunks := slices.Collect(slices.Chunk(data, store.ChunkSize))
+
+	annotations := revisionAnnotations(release, revision)
+	annotations[AnnotationChunks] = strconv.Itoa(len(chunks))
+
+	secrets := store.Clientset.CoreV1().Secrets(ns)
+
+	head, err := secrets.Create(
+		ctx,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "yoke." + internal.RandomString(),
+				Labels:      revisionLabels(release),
+				Annotations: annotations,
+			},
+			Data: stateData,
+		},
+		metav1.CreateOptions{FieldManager: yoke},
+	)
+	if err != nil {
+		return err
+	}
+
+	for i, chunk := range chunks {
+		_, err := secrets.Create(
+			ctx,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: chunkName(head.Name, i),
+					// Chunks must not carry the release label, otherwise they would be listed as revisions of the release.
+					Labels: map[string]string{
+						internal.LabelKind: "revision-chunk",
+					},
+					OwnerReferences: []metav1.OwnerReference{
+						{
+							APIVersion:         "v1",
+							Kind:               "Secret",
+							Name:               head.Name,
+							UID:                head.UID,
+							BlockOwnerDeletion: ptr.To(true),
+						},
+					},
+				},
+				Data: map[string][]byte{
+					internal.KeyResources: chunk,
+				},
+			},
+			metav1.CreateOptions{FieldManager: yoke},
+		)
+		if err != nil {
+			// Do not leave a revision whose resources cannot be read. Its chunks are garbage collected with it.
+			_ = secrets.Delete(ctx, head.Name, metav1.DeleteOptions{})
+			return fmt.Errorf("failed to create chunk %d of %d: %w", i+1, len(chunks), err)
+		}
+	}
+
+	return nil
+}
+
+// readChunks concatenates the data of the chunk secrets of a chunked revision.
+func (client Client) readChunks(ctx context.Context, secret *corev1.Secret) ([]byte, error) {
+	count, err := strconv.Atoi(secret.Annotations[AnnotationChunks])
+	if err != nil {
+		return nil, fmt.Errorf("invalid chunk count: %w", err)
+	}
+
+	secrets := client.Clientset.CoreV1().Secrets(secret.Namespace)
+
+	var buffer bytes.Buffer
+	for i := range count {
+		chunk, err := secrets.Get(ctx, chunkName(secret.Name, i), metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get chunk %d of %d: %w", i+1, count, err)
+		}
+		buffer.Write(chunk.Data[internal.KeyResources])
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func chunkName(revision string, i int) string {
+	return revision + "." + strconv.Itoa(i)
+}
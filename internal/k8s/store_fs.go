@@ -0,0 +1,353 @@
+package k8s
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/davidmdm/x/xerr"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+// FileSystemStore stores release history in a local directory instead of the cluster.
+// It is intended for offline testing and for environments where history must not live in the cluster.
+//
+// The layout of the directory is: <root>/<namespace>/<sha1 of release name>/<revision>.json
+// with the resources of each revision stored next to it as <revision>.resources.json, and its state as <revision>.state.json.
+type FileSystemStore struct {
+	Root string
+}
+
+func NewFileSystemStore(root string) *FileSystemStore {
+	return &FileSystemStore{Root: root}
+}
+
+type fsRevision struct {
+	Release  string            `json:"release"`
+	Revision internal.Revision `json:"revision"`
+}
+
+const (
+	fsRevisionExt  = ".json"
+	fsResourcesExt = ".resources.json"
+	fsStateExt     = ".state.json"
+	fsLockFile     = "lock"
+)
+
+func (store FileSystemStore) releaseDir(name, ns string) string {
+	return filepath.Join(store.Root, ns, internal.SHA1HexFromString(name))
+}
+
+func (store FileSystemStore) revisionPath(revision internal.Revision, release string) string {
+	return filepath.Join(store.releaseDir(release, revision.Namespace), revision.Name)
+}
+
+func (store FileSystemStore) GetRelease(ctx context.Context, name, ns string) (*internal.Release, error) {
+	defer internal.DebugTimer(ctx, "get revisions for "+name)()
+
+	release := internal.Release{Name: name, Namespace: ns}
+
+	revisions, err := store.readRevisions(store.releaseDir(name, ns))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, revision := range revisions {
+		release.Add(revision.Revision)
+	}
+
+	release.ResolveStatuses()
+
+	return &release, nil
+}
+
+func (store FileSystemStore) GetReleases(ctx context.Context) ([]internal.Release, error) {
+	entries, err := os.ReadDir(store.Root)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read release store: %w", err)
+	}
+
+	var result []internal.Release
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		releases, err := store.GetReleasesByNS(ctx, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+		result = append(result, releases...)
+	}
+
+	return result, nil
+}
+
+func (store FileSystemStore) GetReleasesByNS(ctx context.Context, ns string) ([]internal.Release, error) {
+	entries, err := os.ReadDir(filepath.Join(store.Root, ns))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read namespace directory: %w", err)
+	}
+
+	var result []internal.Release
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		revisions, err := store.readRevisions(filepath.Join(store.Root, ns, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(revisions) == 0 {
+			continue
+		}
+
+		release, err := store.GetRelease(ctx, revisions[0].Release, ns)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get revisions for release %s: %w", revisions[0].Release, err)
+		}
+		result = append(result, *release)
+	}
+
+	return result, nil
+}
+
+func (store FileSystemStore) readRevisions(dir string) ([]fsRevision, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read release directory: %w", err)
+	}
+
+	var result []fsRevision
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), fsRevisionExt) || strings.HasSuffix(entry.Name(), fsResourcesExt) || strings.HasSuffix(entry.Name(), fsStateExt) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read revision: %w", err)
+		}
+
+		var revision fsRevision
+		if err := json.Unmarshal(data, &revision); err != nil {
+			return nil, fmt.Errorf("failed to decode revision %s: %w", entry.Name(), err)
+		}
+
+		result = append(result, revision)
+	}
+
+	return result, nil
+}
+
+func (store FileSystemStore) GetRevisionResources(ctx context.Context, revision internal.Revision) (internal.Stages, error) {
+	if revision.Name == "" {
+		return nil, nil
+	}
+
+	release, err := store.releaseOf(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(store.revisionPath(revision, release) + fsResourcesExt)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read revision resources: %w", err)
+	}
+
+	var stages internal.Stages
+	err = json.Unmarshal(data, &stages)
+
+	return stages, err
+}
+
+func (store FileSystemStore) GetRevisionState(ctx context.Context, revision internal.Revision) (internal.State, error) {
+	if revision.Name == "" {
+		return nil, nil
+	}
+
+	release, err := store.releaseOf(revision)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(store.revisionPath(revision, release) + fsStateExt)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read revision state: %w", err)
+	}
+
+	var state internal.State
+	err = json.Unmarshal(data, &state)
+
+	return state, err
+}
+
+// releaseOf finds the release name of a revision. Revisions only know their name and namespace,
+// and revision names are unique within the store.
+func (store FileSystemStore) releaseOf(revision internal.Revision) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(store.Root, revision.Namespace, "*", revision.Name+fsRevisionExt))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("revision %s not found", revision.Name)
+	}
+
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		return "", fmt.Errorf("failed to read revision: %w", err)
+	}
+
+	var value fsRevision
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", fmt.Errorf("failed to decode revision: %w", err)
+	}
+
+	return value.Release, nil
+}
+
+func (store FileSystemStore) CreateRevision(ctx context.Context, release, ns string, revision internal.Revision, stages internal.Stages, state internal.State) error {
+	revision.Name = "yoke." + internal.RandomString()
+	revision.Namespace = ns
+
+	if err := os.MkdirAll(store.releaseDir(release, ns), 0o755); err != nil {
+		return fmt.Errorf("failed to create release directory: %w", err)
+	}
+
+	resources, err := json.Marshal(stages)
+	if err != nil {
+		return fmt.Errorf("failed to marshal resources: %w", err)
+	}
+
+	path := store.revisionPath(revision, release)
+
+	// Write the resources and state first such that a revision is never visible without them.
+	if err := os.WriteFile(path+fsResourcesExt, resources, 0o644); err != nil {
+		return fmt.Errorf("failed to write revision resources: %w", err)
+	}
+
+	if len(state) > 0 {
+		data, err := json.Marshal(state)
+		if err != nil {
+			return fmt.Errorf("failed to marshal state: %w", err)
+		}
+		if err := os.WriteFile(path+fsStateExt, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write revision state: %w", err)
+		}
+	}
+
+	return store.writeRevision(path, fsRevision{Release: release, Revision: revision})
+}
+
+func (store FileSystemStore) writeRevision(path string, revision fsRevision) error {
+	// Like the Secret store, only failed statuses are persisted.
+	if !revision.Revision.Failed() {
+		revision.Revision.Status = ""
+	}
+
+	data, err := json.Marshal(revision)
+	if err != nil {
+		return fmt.Errorf("failed to marshal revision: %w", err)
+	}
+	if err := os.WriteFile(path+fsRevisionExt, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write revision: %w", err)
+	}
+	return nil
+}
+
+func (store FileSystemStore) UpdateRevisionActiveState(ctx context.Context, revision internal.Revision) error {
+	release, err := store.releaseOf(revision)
+	if err != nil {
+		return fmt.Errorf("failed to get revision: %w", err)
+	}
+
+	revision.ActiveAt = time.Now()
+
+	return store.writeRevision(store.revisionPath(revision, release), fsRevision{Release: release, Revision: revision})
+}
+
+func (store FileSystemStore) CapReleaseHistory(ctx context.Context, name, ns string, size int) error {
+	release, err := store.GetRelease(ctx, name, ns)
+	if err != nil {
+		return fmt.Errorf("failed to load release: %w", err)
+	}
+
+	var errs []error
+	for _, revision := range release.ExcessHistory(size) {
+		if err := store.removeRevision(name, revision); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", revision.Name, err))
+		}
+	}
+
+	return xerr.MultiErrFrom("deleting release history", errs...)
+}
+
+func (store FileSystemStore) DeleteRevisions(ctx context.Context, release internal.Release) error {
+	defer internal.DebugTimer(ctx, "delete revision history "+release.Name)()
+
+	var errs []error
+	for _, revision := range release.History {
+		if err := store.removeRevision(release.Name, revision); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", revision.Name, err))
+		}
+	}
+
+	return xerr.MultiErrOrderedFrom("removing revision history files", errs...)
+}
+
+func (store FileSystemStore) removeRevision(release string, revision internal.Revision) error {
+	path := store.revisionPath(revision, release)
+	for _, file := range []string{path + fsRevisionExt, path + fsResourcesExt, path + fsStateExt} {
+		if err := os.Remove(file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store FileSystemStore) LockRelease(ctx context.Context, release internal.Release) error {
+	dir := store.releaseDir(release.Name, release.Namespace)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create release directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, fsLockFile), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return ErrLockTaken
+		}
+		return fmt.Errorf("failed to create lock file: %w", err)
+	}
+
+	return file.Close()
+}
+
+func (store FileSystemStore) UnlockRelease(ctx context.Context, release internal.Release) error {
+	err := os.Remove(filepath.Join(store.releaseDir(release.Name, release.Namespace), fsLockFile))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
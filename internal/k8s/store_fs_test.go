@@ -0,0 +1,102 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+func TestFileSystemStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewFileSystemStore(t.TempDir())
+
+	stages := func(name string) internal.Stages {
+		return internal.Stages{
+			{
+				&unstructured.Unstructured{
+					Object: map[string]any{
+						"apiVersion": "v1",
+						"kind":       "ConfigMap",
+						"metadata":   map[string]any{"name": name},
+					},
+				},
+			},
+		}
+	}
+
+	now := time.Now().Add(-time.Hour)
+
+	require.NoError(t, store.CreateRevision(ctx, "foo/bar", "default", internal.Revision{CreatedAt: now, ActiveAt: now, Resources: 1}, stages("a"), internal.State{"port": "8080"}))
+	require.NoError(t, store.CreateRevision(ctx, "foo/bar", "default", internal.Revision{CreatedAt: now.Add(time.Second), ActiveAt: now.Add(time.Second), Resources: 1}, stages("b"), internal.State{"port": "9090"}))
+	require.NoError(t, store.CreateRevision(ctx, "foo/bar", "default", internal.Revision{
+		CreatedAt:   now.Add(2 * time.Second),
+		Resources:   1,
+		Status:      internal.RevisionStatusFailed,
+		Error:       "boom",
+		FailedStage: 1,
+	}, stages("c"), nil))
+
+	release, err := store.GetRelease(ctx, "foo/bar", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 3)
+	require.Equal(t, internal.RevisionStatusSuperseded, release.History[0].Status)
+	require.Equal(t, internal.RevisionStatusActive, release.History[1].Status)
+	require.Equal(t, internal.RevisionStatusFailed, release.History[2].Status)
+	require.Equal(t, "boom", release.History[2].Error)
+
+	resources, err := store.GetRevisionResources(ctx, release.ActiveRevision())
+	require.NoError(t, err)
+	require.Equal(t, "b", resources.Flatten()[0].GetName())
+
+	state, err := store.GetRevisionState(ctx, release.ActiveRevision())
+	require.NoError(t, err)
+	require.Equal(t, internal.State{"port": "9090"}, state)
+
+	state, err = store.GetRevisionState(ctx, release.History[2])
+	require.NoError(t, err)
+	require.Empty(t, state)
+
+	require.NoError(t, store.UpdateRevisionActiveState(ctx, release.History[0]))
+
+	release, err = store.GetRelease(ctx, "foo/bar", "default")
+	require.NoError(t, err)
+	require.Equal(t, 0, release.ActiveIndex())
+
+	state, err = store.GetRevisionState(ctx, release.ActiveRevision())
+	require.NoError(t, err)
+	require.Equal(t, internal.State{"port": "8080"}, state)
+
+	releases, err := store.GetReleases(ctx)
+	require.NoError(t, err)
+	require.Len(t, releases, 1)
+	require.Equal(t, "foo/bar", releases[0].Name)
+
+	require.NoError(t, store.CapReleaseHistory(ctx, "foo/bar", "default", 2))
+
+	release, err = store.GetRelease(ctx, "foo/bar", "default")
+	require.NoError(t, err)
+	require.Len(t, release.History, 2)
+	require.Equal(t, "a", func() string {
+		resources, err := store.GetRevisionResources(ctx, release.ActiveRevision())
+		require.NoError(t, err)
+		return resources.Flatten()[0].GetName()
+	}())
+
+	require.NoError(t, store.LockRelease(ctx, *release))
+	require.ErrorIs(t, store.LockRelease(ctx, *release), ErrLockTaken)
+	require.NoError(t, store.UnlockRelease(ctx, *release))
+	require.NoError(t, store.LockRelease(ctx, *release))
+	require.NoError(t, store.UnlockRelease(ctx, *release))
+
+	require.NoError(t, store.DeleteRevisions(ctx, *release))
+
+	release, err = store.GetRelease(ctx, "foo/bar", "default")
+	require.NoError(t, err)
+	require.Empty(t, release.History)
+}
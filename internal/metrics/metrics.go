@@ -0,0 +1,152 @@
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "yoke"
+
+// Registry holds every metric exposed by the ATC. It is kept separate from the prometheus default registry
+// so that only the metrics defined here, and the standard go and process collectors, are exposed.
+var Registry = prometheus.NewRegistry()
+
+var (
+	Reconciles = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconcile_total",
+			Help:      "Number of reconciliations processed by the controller.",
+		},
+		[]string{"group_kind"},
+	)
+
+	ReconcileErrors = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reconcile_errors_total",
+			Help:      "Number of reconciliations that returned an error.",
+		},
+		[]string{"group_kind"},
+	)
+
+	ReconcileDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "reconcile_duration_seconds",
+			Help:      "Time taken to reconcile a resource.",
+			Buckets:   prometheus.ExponentialBuckets(0.01, 2, 15),
+		},
+		[]string{"group_kind"},
+	)
+
+	Requeues = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "queue_requeues_total",
+			Help:      "Number of events requeued by the controller after reconciliation.",
+		},
+		[]string{"group_kind"},
+	)
+
+	QueueDepth = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "queue_depth",
+			Help:      "Number of events waiting to be reconciled.",
+		},
+		func() float64 {
+			if depth := queueDepth.Load(); depth != nil {
+				return float64((*depth)())
+			}
+			return 0
+		},
+	)
+
+	ModuleCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "module_cache_hits_total",
+		Help:      "Number of wasm module lookups served from the module cache.",
+	})
+
+	ModuleCacheMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "module_cache_misses_total",
+		Help:      "Number of wasm module lookups that required compiling the module.",
+	})
+
+	ModuleCompileDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "module_compile_duration_seconds",
+		Help:      "Time taken to compile a wasm module.",
+		Buckets:   prometheus.ExponentialBuckets(0.01, 2, 15),
+	})
+
+	WasmExecutionDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "wasm_execution_duration_seconds",
+		Help:      "Time taken to execute a wasm module.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 15),
+	})
+
+	WasmMemory = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "wasm_memory_bytes",
+		Help:      "Size of the linear memory of a wasm module at the end of its execution.",
+		Buckets:   prometheus.ExponentialBuckets(1<<20, 2, 13),
+	})
+
+	AdmissionDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "admission_duration_seconds",
+			Help:      "Time taken to serve an admission review.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"webhook"},
+	)
+
+	AdmissionDenials = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "admission_denials_total",
+			Help:      "Number of admission reviews that were denied.",
+		},
+		[]string{"webhook"},
+	)
+)
+
+var queueDepth atomic.Pointer[func() int]
+
+// SetQueueDepthFunc sets the function used to report the queue depth when metrics are gathered.
+func SetQueueDepthFunc(fn func() int) {
+	queueDepth.Store(&fn)
+}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		Reconciles,
+		ReconcileErrors,
+		ReconcileDuration,
+		Requeues,
+		QueueDepth,
+		ModuleCacheHits,
+		ModuleCacheMisses,
+		ModuleCompileDuration,
+		WasmExecutionDuration,
+		WasmMemory,
+		AdmissionDuration,
+		AdmissionDenials,
+	)
+}
+
+// Handler serves the metrics of the Registry in the prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	SetQueueDepthFunc(func() int { return 3 })
+
+	Reconciles.WithLabelValues("Flight.yoke.cd").Inc()
+	AdmissionDenials.WithLabelValues("flights.yoke.cd").Inc()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	body := rec.Body.String()
+	require.Contains(t, body, "yoke_queue_depth 3")
+	require.Contains(t, body, `yoke_reconcile_total{group_kind="Flight.yoke.cd"} 1`)
+	require.Contains(t, body, `yoke_admission_denials_total{webhook="flights.yoke.cd"} 1`)
+	require.Contains(t, body, "go_goroutines")
+}
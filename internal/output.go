@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat is the format used by commands to write their results.
+// The zero value is the human readable default of each command such as tables or unified diffs.
+type OutputFormat string
+
+const (
+	OutputFormatText OutputFormat = ""
+	OutputFormatJSON OutputFormat = "json"
+	OutputFormatYAML OutputFormat = "yaml"
+)
+
+func ParseOutputFormat(value string) (OutputFormat, error) {
+	switch format := OutputFormat(value); format {
+	case OutputFormatText, OutputFormatJSON, OutputFormatYAML:
+		return format, nil
+	case "text":
+		return OutputFormatText, nil
+	default:
+		return "", fmt.Errorf("unknown output format %q: must be one of json or yaml", value)
+	}
+}
+
+// Structured reports whether the format is a machine readable format.
+func (format OutputFormat) Structured() bool {
+	return format == OutputFormatJSON || format == OutputFormatYAML
+}
+
+// WriteOutput encodes value to w in the given structured format.
+// YAML output is derived from the JSON encoding of the value so that both formats share the same schema.
+func WriteOutput(w io.Writer, format OutputFormat, value any) error {
+	switch format {
+	case OutputFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(value)
+	case OutputFormatYAML:
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		var generic any
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(w)
+		encoder.SetIndent(2)
+		return encoder.Encode(generic)
+	default:
+		return fmt.Errorf("output format %q is not a structured format", format)
+	}
+}
@@ -0,0 +1,48 @@
+package internal
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteOutput(t *testing.T) {
+	value := struct {
+		Name   string   `json:"name"`
+		Values []string `json:"values,omitempty"`
+		Hidden string   `json:"-"`
+	}{
+		Name:   "yoke",
+		Values: []string{"a", "b"},
+		Hidden: "hidden",
+	}
+
+	var buffer bytes.Buffer
+
+	require.NoError(t, WriteOutput(&buffer, OutputFormatJSON, value))
+	require.Equal(t, "{\n  \"name\": \"yoke\",\n  \"values\": [\n    \"a\",\n    \"b\"\n  ]\n}\n", buffer.String())
+
+	buffer.Reset()
+
+	require.NoError(t, WriteOutput(&buffer, OutputFormatYAML, value))
+	require.Equal(t, "name: yoke\nvalues:\n  - a\n  - b\n", buffer.String())
+
+	require.Error(t, WriteOutput(&buffer, OutputFormatText, value))
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for input, expected := range map[string]OutputFormat{
+		"":     OutputFormatText,
+		"text": OutputFormatText,
+		"json": OutputFormatJSON,
+		"yaml": OutputFormatYAML,
+	} {
+		format, err := ParseOutputFormat(input)
+		require.NoError(t, err)
+		require.Equal(t, expected, format)
+	}
+
+	_, err := ParseOutputFormat("xml")
+	require.EqualError(t, err, `unknown output format "xml": must be one of json or yaml`)
+}
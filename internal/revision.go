@@ -20,26 +20,81 @@ type Release struct {
 	History   []Revision `json:"history"`
 }
 
+// ActiveRevision returns the revision that was most recently made active.
+// Failed revisions are never active. If the release has no active revision the zero value is returned.
 func (release Release) ActiveRevision() Revision {
-	var active Revision
-	for _, revision := range release.History {
-		if revision.ActiveAt.After(active.ActiveAt) {
-			active = revision
-		}
+	if idx := release.ActiveIndex(); idx >= 0 {
+		return release.History[idx]
 	}
-	return active
+	return Revision{}
 }
 
+// ActiveIndex returns the index of the active revision within the release history or -1 if there is none.
 func (release Release) ActiveIndex() int {
-	var active int
+	active := -1
 	for i, revision := range release.History {
-		if revision.ActiveAt.After(release.History[active].ActiveAt) {
+		if revision.Failed() {
+			continue
+		}
+		if active < 0 || revision.ActiveAt.After(release.History[active].ActiveAt) {
 			active = i
 		}
 	}
 	return active
 }
 
+// ResolveStatuses sorts the history by creation time and sets the status of every revision that has not failed.
+// Only failed statuses are persisted. The status of successful revisions depends on which one is currently active.
+func (release *Release) ResolveStatuses() {
+	slices.SortStableFunc(release.History, func(a, b Revision) int {
+		return a.CreatedAt.Compare(b.CreatedAt)
+	})
+
+	active := release.ActiveIndex()
+	for i, revision := range release.History {
+		switch {
+		case revision.Failed():
+			continue
+		case i == active:
+			release.History[i].Status = RevisionStatusActive
+		default:
+			release.History[i].Status = RevisionStatusSuperseded
+		}
+	}
+}
+
+// ExcessHistory returns the revisions that must be removed for the history to contain at most size revisions.
+// The active revision is always kept. The remaining revisions are kept by most recent use:
+// when they were last active, or when they were attempted for failed revisions.
+func (release Release) ExcessHistory(size int) []Revision {
+	if size >= len(release.History) {
+		return nil
+	}
+
+	active := release.ActiveRevision().Name
+	recency := func(revision Revision) time.Time {
+		if revision.Failed() {
+			return revision.CreatedAt
+		}
+		return revision.ActiveAt
+	}
+
+	history := slices.Clone(release.History)
+
+	slices.SortStableFunc(history, func(a, b Revision) int {
+		switch {
+		case a.Name == active:
+			return -1
+		case b.Name == active:
+			return 1
+		default:
+			return recency(b).Compare(recency(a))
+		}
+	})
+
+	return history[size:]
+}
+
 type Source struct {
 	Ref      string `json:"ref"`
 	Checksum string `json:"checksum"`
@@ -76,14 +131,40 @@ func (release *Release) Add(revision Revision) {
 	release.History = slices.Insert(release.History, idx, revision)
 }
 
+// State is the key/value state persisted by a flight for its release.
+// Every revision holds a snapshot of the state as it was when the revision was created.
+type State map[string]string
+
+type RevisionStatus string
+
+const (
+	RevisionStatusActive     RevisionStatus = "active"
+	RevisionStatusSuperseded RevisionStatus = "superseded"
+	RevisionStatusFailed     RevisionStatus = "failed"
+)
+
 type Revision struct {
-	Name      string    `json:"-"`
-	Namespace string    `json:"-"`
+	Name      string    `json:"name"`
+	Namespace string    `json:"namespace"`
 	Source    Source    `json:"source"`
-	LockedBy  string    `json:"lockedBy"`
+	LockedBy  string    `json:"lockedBy,omitempty"`
 	CreatedAt time.Time `json:"createdAt"`
-	ActiveAt  time.Time `json:"-"`
+	ActiveAt  time.Time `json:"activeAt,omitzero"`
 	Resources int       `json:"resources"`
+
+	// Status is only persisted for failed revisions.
+	// Active and superseded statuses are derived from the release history when it is loaded.
+	Status RevisionStatus `json:"status"`
+
+	// Error is the error message of a failed takeoff attempt.
+	Error string `json:"error,omitempty"`
+
+	// FailedStage is the 1-indexed stage of the failed takeoff attempt that failed to apply or become ready.
+	FailedStage int `json:"failedStage,omitempty"`
+}
+
+func (revision Revision) Failed() bool {
+	return revision.Status == RevisionStatusFailed
 }
 
 const (
@@ -234,7 +315,11 @@ const (
 	AnnotationActiveAt       = "internal.yoke/active-at"
 	AnnotationResourceCount  = "internal.yoke/resources"
 	AnnotationReleaseName    = "internal.yoke/release-name"
+	AnnotationStatus         = "internal.yoke/status"
+	AnnotationError          = "internal.yoke/error"
+	AnnotationFailedStage    = "internal.yoke/failed-stage"
 	KeyResources             = "resources"
+	KeyState                 = "state"
 	KeyLockedBy              = "lockedBy"
 )
 
@@ -0,0 +1,31 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveRevisionSkipsFailed(t *testing.T) {
+	now := time.Now()
+
+	release := Release{
+		History: []Revision{
+			{Name: "a", CreatedAt: now, ActiveAt: now},
+			{Name: "b", CreatedAt: now.Add(time.Second), Status: RevisionStatusFailed},
+			{Name: "c", CreatedAt: now.Add(2 * time.Second), ActiveAt: now.Add(2 * time.Second)},
+			{Name: "d", CreatedAt: now.Add(3 * time.Second), Status: RevisionStatusFailed},
+		},
+	}
+
+	require.Equal(t, 2, release.ActiveIndex())
+	require.Equal(t, "c", release.ActiveRevision().Name)
+
+	failedOnly := Release{History: []Revision{{Name: "a", Status: RevisionStatusFailed}}}
+
+	require.Equal(t, -1, failedOnly.ActiveIndex())
+	require.Equal(t, Revision{}, failedOnly.ActiveRevision())
+
+	require.Equal(t, -1, Release{}.ActiveIndex())
+}
@@ -12,11 +12,13 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 	"weak"
 
 	"github.com/davidmdm/x/xsync"
 
 	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/metrics"
 	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/xcrypto"
 	"github.com/yokecd/yoke/pkg/yoke"
@@ -49,6 +51,11 @@ func NewModuleCache(fsRoot string, globs internal.Globs, keys xcrypto.PublicKeyS
 type ModuleAttrs struct {
 	MaxMemoryMib    uint32
 	HostFunctionMap map[string]any
+	Metered         bool
+}
+
+func (attrs ModuleAttrs) matches(instance *wasi.Module) bool {
+	return instance.MaxMemoryMib() == attrs.MaxMemoryMib && instance.Metered() == attrs.Metered
 }
 
 func (cache *ModuleCache) All() iter.Seq[*wasi.Module] {
@@ -71,19 +78,27 @@ func (cache *ModuleCache) All() iter.Seq[*wasi.Module] {
 func (cache *ModuleCache) FromSource(ctx context.Context, wasm []byte, attrs ModuleAttrs) (*wasi.Module, error) {
 	key := internal.SHA1HexString(wasm)
 	mod, _ := cache.mods.LoadOrStore(key, &CachedModule{mutex: sync.RWMutex{}})
-	if instance := mod.Instance.Value(); instance != nil && instance.MaxMemoryMib() == attrs.MaxMemoryMib {
+	if instance := mod.Instance.Value(); instance != nil && attrs.matches(instance) {
+		metrics.ModuleCacheHits.Inc()
 		return instance, nil
 	}
 
 	mod.mutex.Lock()
 	defer mod.mutex.Unlock()
 
+	metrics.ModuleCacheMisses.Inc()
+
+	start := time.Now()
+
 	instance, err := wasi.Compile(ctx, wasi.CompileParams{
 		Wasm:            wasm,
 		CacheDir:        cache.fsRoot,
 		MaxMemoryMib:    attrs.MaxMemoryMib,
 		HostFunctionMap: attrs.HostFunctionMap,
+		Metered:         attrs.Metered,
 	})
+
+	metrics.ModuleCompileDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		return nil, fmt.Errorf("failed to compile module: %w", err)
 	}
@@ -135,6 +150,7 @@ type FromURLParams struct {
 
 func (cache *ModuleCache) FromURL(ctx context.Context, params FromURLParams) (*wasi.Module, error) {
 	if mod := cache.pullFromCache(params.URL, params.Attrs); mod != nil {
+		metrics.ModuleCacheHits.Inc()
 		return mod, nil
 	}
 
@@ -148,6 +164,7 @@ func (cache *ModuleCache) FromURL(ctx context.Context, params FromURLParams) (*w
 	defer mutex.Unlock()
 
 	if mod := cache.pullFromCache(params.URL, params.Attrs); mod != nil {
+		metrics.ModuleCacheHits.Inc()
 		return mod, nil
 	}
 
@@ -211,7 +228,7 @@ func (cache ModuleCache) pullFromCache(key string, attrs ModuleAttrs) *wasi.Modu
 			defer cachedMod.mutex.RUnlock()
 			return cachedMod.Instance.Value()
 		}()
-		if instance != nil && instance.MaxMemoryMib() == attrs.MaxMemoryMib {
+		if instance != nil && attrs.matches(instance) {
 			return instance
 		}
 	}
@@ -0,0 +1,18 @@
+package wasi
+
+import (
+	"bytes"
+	"errors"
+)
+
+// ErrComponentNotSupported is returned when compiling a WebAssembly component instead of a core module.
+// The host API of flights for the component model is defined in pkg/flight/wit, but the runtime cannot execute components yet.
+var ErrComponentNotSupported = errors.New("webassembly components are not supported: flights must be compiled as wasip1 core modules")
+
+var wasmMagic = []byte("\x00asm")
+
+// IsComponent reports whether wasm is encoded as a WebAssembly component rather than a core module.
+// Components share the magic number of core modules but are encoded with layer 1 in place of the upper half of the version.
+func IsComponent(wasm []byte) bool {
+	return len(wasm) >= 8 && bytes.Equal(wasm[:4], wasmMagic) && wasm[6] == 0x01 && wasm[7] == 0x00
+}
@@ -0,0 +1,29 @@
+package wasi
+
+import (
+	"crypto/sha256"
+	"io"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/tetratelabs/wazero/sys"
+)
+
+// deterministicRand returns a source of randomness seeded from the name of the module and its input.
+func deterministicRand(name string, input []byte) io.Reader {
+	hash := sha256.New()
+	hash.Write([]byte(name))
+	hash.Write([]byte{0})
+	hash.Write(input)
+	return rand.NewChaCha8([32]byte(hash.Sum(nil)))
+}
+
+// deterministicNanotime returns a monotonic clock that advances by a millisecond on every read.
+// A clock that never advances would stall the go runtime of modules waiting on timers.
+func deterministicNanotime() sys.Nanotime {
+	var now atomic.Int64
+	return func() int64 {
+		return now.Add(time.Millisecond.Nanoseconds())
+	}
+}
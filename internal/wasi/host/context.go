@@ -3,12 +3,14 @@ package host
 import (
 	"context"
 	"slices"
+	"time"
 
 	"github.com/davidmdm/x/xsync"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 
 	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/k8s"
 )
 
 type ownerKey struct{}
@@ -38,17 +40,60 @@ func clusterAccessEnabled(ctx context.Context) ClusterAccessParams {
 	return value
 }
 
+type httpAccessKey struct{}
+
+// HTTPAccessParams grants flights access to the http_fetch host function.
+// Only URLs matching at least one of the URLMatchers glob patterns can be fetched.
+type HTTPAccessParams struct {
+	URLMatchers []string
+	// MaxResponseBytes limits the size of response bodies. Defaults to DefaultFetchMaxResponseBytes.
+	MaxResponseBytes int64
+	// Timeout limits the duration of each request. Defaults to DefaultFetchTimeout.
+	Timeout time.Duration
+}
+
+func WithHTTPAccess(ctx context.Context, access HTTPAccessParams) context.Context {
+	return context.WithValue(ctx, httpAccessKey{}, access)
+}
+
+func httpAccess(ctx context.Context) HTTPAccessParams {
+	value, _ := ctx.Value(httpAccessKey{}).(HTTPAccessParams)
+	return value
+}
+
+type releaseKey struct{}
+
+// ReleaseParams identifies the release the flight is evaluated for, and the store holding its revision history.
+type ReleaseParams struct {
+	Store     k8s.ReleaseStore
+	Name      string
+	Namespace string
+}
+
+func WithRelease(ctx context.Context, release ReleaseParams) context.Context {
+	return context.WithValue(ctx, releaseKey{}, release)
+}
+
+func getRelease(ctx context.Context) (ReleaseParams, bool) {
+	value, ok := ctx.Value(releaseKey{}).(ReleaseParams)
+	return value, ok && value.Store != nil
+}
+
 type resourceTrackingKey struct{}
 
 type TrackedResources struct {
 	External *xsync.Set[string]
 	Internal *xsync.Set[string]
+	Queries  *xsync.Set[ListQuery]
+	URLs     *xsync.Set[string]
 }
 
 func WithResourceTracking(ctx context.Context) context.Context {
 	return context.WithValue(ctx, resourceTrackingKey{}, TrackedResources{
 		External: &xsync.Set[string]{},
 		Internal: &xsync.Set[string]{},
+		Queries:  &xsync.Set[ListQuery]{},
+		URLs:     &xsync.Set[string]{},
 	})
 }
 
@@ -66,6 +111,36 @@ func trackExternalRef(ctx context.Context, ref string) {
 	}
 }
 
+// ListQueries returns the list queries made by the flight. Resources created after the flight has run may match them.
+func ListQueries(ctx context.Context) []ListQuery {
+	resources, ok := ctx.Value(resourceTrackingKey{}).(TrackedResources)
+	if !ok {
+		return nil
+	}
+	return slices.Collect(resources.Queries.All())
+}
+
+func trackListQuery(ctx context.Context, query ListQuery) {
+	if resources, ok := ctx.Value(resourceTrackingKey{}).(TrackedResources); ok {
+		resources.Queries.Add(query)
+	}
+}
+
+// FetchedURLs returns the URLs fetched by the flight. Unlike resources, changes to their content cannot be watched.
+func FetchedURLs(ctx context.Context) []string {
+	resources, ok := ctx.Value(resourceTrackingKey{}).(TrackedResources)
+	if !ok {
+		return nil
+	}
+	return slices.Collect(resources.URLs.All())
+}
+
+func trackFetchedURL(ctx context.Context, url string) {
+	if resources, ok := ctx.Value(resourceTrackingKey{}).(TrackedResources); ok {
+		resources.URLs.Add(url)
+	}
+}
+
 func InternalResources(ctx context.Context) *xsync.Set[string] {
 	resources, ok := ctx.Value(resourceTrackingKey{}).(TrackedResources)
 	if !ok {
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Severity is the severity of a diagnostic reported by a flight.
+type Severity string
+
+const (
+	SeverityInfo        Severity = "info"
+	SeverityWarning     Severity = "warning"
+	SeverityDeprecation Severity = "deprecation"
+)
+
+func (severity Severity) valid() bool {
+	switch severity {
+	case SeverityInfo, SeverityWarning, SeverityDeprecation:
+		return true
+	default:
+		return false
+	}
+}
+
+// MaxDiagnostics bounds the number of diagnostics a single evaluation of a flight can report.
+const MaxDiagnostics = 100
+
+// Diagnostic is a message reported by a flight about its evaluation that does not fail the release,
+// such as the use of a deprecated input field.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+func (diagnostic Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s", diagnostic.Severity, diagnostic.Message)
+}
+
+type diagnosticsKey struct{}
+
+type diagnostics struct {
+	mutex sync.Mutex
+	items []Diagnostic
+}
+
+// WithDiagnostics returns a context that collects the diagnostics reported by the flights evaluated with it.
+// Diagnostics reported by flights evaluated without such a context are discarded.
+func WithDiagnostics(ctx context.Context) context.Context {
+	return context.WithValue(ctx, diagnosticsKey{}, new(diagnostics))
+}
+
+// Diagnostics returns the diagnostics collected by a context returned from WithDiagnostics in the order they were reported.
+func Diagnostics(ctx context.Context) []Diagnostic {
+	collector, _ := ctx.Value(diagnosticsKey{}).(*diagnostics)
+	if collector == nil {
+		return nil
+	}
+
+	collector.mutex.Lock()
+	defer collector.mutex.Unlock()
+
+	return slices.Clone(collector.items)
+}
+
+// FormatDiagnostics formats diagnostics one per line.
+func FormatDiagnostics(diagnostics []Diagnostic) string {
+	lines := make([]string, len(diagnostics))
+	for i, diagnostic := range diagnostics {
+		lines[i] = diagnostic.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+type HostReportDiagnosticFunc func(ctx context.Context, severity Severity, message string) error
+
+// HostReportDiagnostic adds a diagnostic to the collector of the context. See WithDiagnostics.
+func HostReportDiagnostic() HostReportDiagnosticFunc {
+	return func(ctx context.Context, severity Severity, message string) error {
+		if !severity.valid() {
+			return fmt.Errorf("invalid diagnostic severity: %q", severity)
+		}
+
+		collector, _ := ctx.Value(diagnosticsKey{}).(*diagnostics)
+		if collector == nil {
+			return nil
+		}
+
+		collector.mutex.Lock()
+		defer collector.mutex.Unlock()
+
+		if len(collector.items) >= MaxDiagnostics {
+			return fmt.Errorf("cannot report more than %d diagnostics", MaxDiagnostics)
+		}
+
+		collector.items = append(collector.items, Diagnostic{Severity: severity, Message: message})
+
+		return nil
+	}
+}
@@ -0,0 +1,41 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostReportDiagnostic(t *testing.T) {
+	report := HostReportDiagnostic()
+
+	// Diagnostics reported without a collector are discarded.
+	require.NoError(t, report(context.Background(), SeverityWarning, "discarded"))
+
+	ctx := WithDiagnostics(context.Background())
+
+	require.NoError(t, report(ctx, SeverityDeprecation, "input field `replicas` is deprecated, use `scaling.min`"))
+	require.NoError(t, report(ctx, SeverityInfo, "using default image"))
+	require.ErrorContains(t, report(ctx, "fatal", "boom"), `invalid diagnostic severity: "fatal"`)
+
+	require.Equal(
+		t,
+		[]Diagnostic{
+			{Severity: SeverityDeprecation, Message: "input field `replicas` is deprecated, use `scaling.min`"},
+			{Severity: SeverityInfo, Message: "using default image"},
+		},
+		Diagnostics(ctx),
+	)
+
+	require.Equal(
+		t,
+		"deprecation: input field `replicas` is deprecated, use `scaling.min`\ninfo: using default image",
+		FormatDiagnostics(Diagnostics(ctx)),
+	)
+
+	for range MaxDiagnostics - 2 {
+		require.NoError(t, report(ctx, SeverityInfo, "info"))
+	}
+	require.ErrorContains(t, report(ctx, SeverityInfo, "one too many"), "cannot report more than 100 diagnostics")
+}
@@ -5,35 +5,121 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"slices"
 	"strings"
 
 	"github.com/tetratelabs/wazero/api"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/client-go/dynamic"
 
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/wasm"
 )
 
 var ErrFeatureNotGranted = errors.New("feature not granted")
 
-func BuildFunctionMap(client *k8s.Client) map[string]any {
-	lookup := HostLookupResource(client)
-	restMapping := HostDiscoverMapping(client)
+// Cluster serves the host functions that read the state of the cluster: k8s_lookup, k8s_list, k8s_rest_mapping and k8s_previous_revision.
+type Cluster interface {
+	Lookup(ctx context.Context, name, namespace, kind, apiVersion string) (*unstructured.Unstructured, error)
+	List(ctx context.Context, namespace, kind, apiVersion, labelSelector, fieldSelector string) ([]*unstructured.Unstructured, error)
+	RestMapping(ctx context.Context, groupOrAPIVersion, kind string) (*RestMapping, error)
+	PreviousRevision(ctx context.Context) (*Revision, error)
+}
+
+// Fetcher serves the http_fetch host function.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (*FetchResponse, error)
+}
+
+// Backends are the implementations of the host functions that reach outside of the wasm runtime.
+// If the Cluster is nil, the cluster functions fail as if cluster access was not granted and the release has no previous revision.
+// If the HTTP fetcher is nil, fetches fail as if http access was not granted.
+type Backends struct {
+	Cluster Cluster
+	HTTP    Fetcher
+}
+
+// ClientBackends returns the backends used by yoke and the ATC: the cluster of the client, and the default http client.
+func ClientBackends(client *k8s.Client) Backends {
+	return Backends{
+		Cluster: clientCluster{
+			lookup:           HostLookupResource(client),
+			list:             HostListResources(client),
+			restMapping:      HostDiscoverMapping(client),
+			previousRevision: HostPreviousRevision(),
+		},
+		HTTP: HostFetch(http.DefaultClient),
+	}
+}
+
+type clientCluster struct {
+	lookup           HostLookupResourceFunc
+	list             HostListResourcesFunc
+	restMapping      HostDiscoverMappingFunc
+	previousRevision HostPreviousRevisionFunc
+}
+
+func (cluster clientCluster) Lookup(ctx context.Context, name, namespace, kind, apiVersion string) (*unstructured.Unstructured, error) {
+	return cluster.lookup(ctx, name, namespace, kind, apiVersion)
+}
+
+func (cluster clientCluster) List(ctx context.Context, namespace, kind, apiVersion, labelSelector, fieldSelector string) ([]*unstructured.Unstructured, error) {
+	return cluster.list(ctx, namespace, kind, apiVersion, labelSelector, fieldSelector)
+}
+
+func (cluster clientCluster) RestMapping(ctx context.Context, groupOrAPIVersion, kind string) (*RestMapping, error) {
+	return cluster.restMapping(ctx, groupOrAPIVersion, kind)
+}
+
+func (cluster clientCluster) PreviousRevision(ctx context.Context) (*Revision, error) {
+	return cluster.previousRevision(ctx)
+}
+
+// BuildFunctionMap returns the host functions exposed to flights, backed by the given backends.
+// The key/value state and diagnostics functions are backed by the context of the execution. See WithReleaseState and WithDiagnostics.
+func BuildFunctionMap(backends Backends) map[string]any {
+	cluster := backends.Cluster
+	if cluster == nil {
+		cluster = noCluster{}
+	}
+	fetcher := backends.HTTP
+	if fetcher == nil {
+		fetcher = noFetcher{}
+	}
+
+	lookup := cluster.Lookup
+	list := cluster.List
+	restMapping := cluster.RestMapping
+	fetch := fetcher.Fetch
+	previousRevision := cluster.PreviousRevision
+	kvGet := HostKVGet()
+	kvSet := HostKVSet()
+	kvDelete := HostKVDelete()
+	reportDiagnostic := HostReportDiagnostic()
 
 	errHandler := func(ctx context.Context, module api.Module, stateRef wasm.Ptr, err error) wasm.Buffer {
 		errState := func() wasm.State {
 			switch {
-			case errors.Is(err, ErrFeatureNotGranted):
+			case errors.Is(err, ErrFeatureNotGranted), errors.Is(err, ErrReleaseStateUnavailable):
 				return wasm.StateFeatureNotGranted
+			case errors.Is(err, ErrURLNotAllowed):
+				return wasm.StateForbidden
+			case errors.Is(err, ErrNoPreviousRevision), errors.Is(err, ErrKeyNotFound):
+				return wasm.StateNotFound
 			case kerrors.IsNotFound(err):
 				return wasm.StateNotFound
 			case kerrors.IsForbidden(err):
@@ -49,29 +135,184 @@ func BuildFunctionMap(client *k8s.Client) map[string]any {
 
 	return map[string]any{
 		"k8s_lookup": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, name, namespace, kind, apiVersion wasm.String) wasm.Buffer {
-			resource, err := lookup(
+			var (
+				nameStr       = wasi.LoadString(module, name)
+				namespaceStr  = wasi.LoadString(module, namespace)
+				kindStr       = wasi.LoadString(module, kind)
+				apiVersionStr = wasi.LoadString(module, apiVersion)
+			)
+
+			ctx, span := tracing.Start(
 				ctx,
-				wasi.LoadString(module, name),
-				wasi.LoadString(module, namespace),
-				wasi.LoadString(module, kind),
-				wasi.LoadString(module, apiVersion),
+				"k8s_lookup",
+				attribute.String("name", nameStr),
+				attribute.String("namespace", namespaceStr),
+				attribute.String("kind", kindStr),
+				attribute.String("apiVersion", apiVersionStr),
 			)
+
+			resource, err := lookup(ctx, nameStr, namespaceStr, kindStr, apiVersionStr)
+			tracing.End(span, err)
+
 			if err != nil {
 				return errHandler(ctx, module, stateRef, err)
 			}
 			return wasi.MallocJSON(ctx, module, stateRef, resource)
 		},
 
+		"k8s_list": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, namespace, kind, apiVersion, labelSelector, fieldSelector wasm.String) wasm.Buffer {
+			var (
+				namespaceStr     = wasi.LoadString(module, namespace)
+				kindStr          = wasi.LoadString(module, kind)
+				apiVersionStr    = wasi.LoadString(module, apiVersion)
+				labelSelectorStr = wasi.LoadString(module, labelSelector)
+				fieldSelectorStr = wasi.LoadString(module, fieldSelector)
+			)
+
+			ctx, span := tracing.Start(
+				ctx,
+				"k8s_list",
+				attribute.String("namespace", namespaceStr),
+				attribute.String("kind", kindStr),
+				attribute.String("apiVersion", apiVersionStr),
+				attribute.String("labelSelector", labelSelectorStr),
+				attribute.String("fieldSelector", fieldSelectorStr),
+			)
+
+			resources, err := list(ctx, namespaceStr, kindStr, apiVersionStr, labelSelectorStr, fieldSelectorStr)
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return wasi.MallocJSON(ctx, module, stateRef, resources)
+		},
+
 		"k8s_rest_mapping": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, groupOrAPIVersion, kind wasm.String) wasm.Buffer {
-			mapping, err := restMapping(ctx, wasi.LoadString(module, groupOrAPIVersion), wasi.LoadString(module, kind))
+			var (
+				groupOrAPIVersionStr = wasi.LoadString(module, groupOrAPIVersion)
+				kindStr              = wasi.LoadString(module, kind)
+			)
+
+			ctx, span := tracing.Start(ctx, "k8s_rest_mapping", attribute.String("groupOrAPIVersion", groupOrAPIVersionStr), attribute.String("kind", kindStr))
+
+			mapping, err := restMapping(ctx, groupOrAPIVersionStr, kindStr)
+			tracing.End(span, err)
+
 			if err != nil {
 				return errHandler(ctx, module, stateRef, err)
 			}
 			return wasi.MallocJSON(ctx, module, stateRef, mapping)
 		},
+
+		"k8s_previous_revision": func(ctx context.Context, module api.Module, stateRef wasm.Ptr) wasm.Buffer {
+			ctx, span := tracing.Start(ctx, "k8s_previous_revision")
+
+			revision, err := previousRevision(ctx)
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return wasi.MallocJSON(ctx, module, stateRef, revision)
+		},
+
+		"http_fetch": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, url wasm.String) wasm.Buffer {
+			urlStr := wasi.LoadString(module, url)
+
+			ctx, span := tracing.Start(ctx, "http_fetch", attribute.String("url", urlStr))
+
+			resp, err := fetch(ctx, urlStr)
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return wasi.MallocJSON(ctx, module, stateRef, resp)
+		},
+
+		"kv_get": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, key wasm.String) wasm.Buffer {
+			keyStr := wasi.LoadString(module, key)
+
+			ctx, span := tracing.Start(ctx, "kv_get", attribute.String("key", keyStr))
+
+			value, err := kvGet(ctx, keyStr)
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return wasi.Malloc(ctx, module, []byte(value))
+		},
+
+		"kv_set": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, key, value wasm.String) wasm.Buffer {
+			keyStr := wasi.LoadString(module, key)
+
+			ctx, span := tracing.Start(ctx, "kv_set", attribute.String("key", keyStr))
+
+			err := kvSet(ctx, keyStr, wasi.LoadString(module, value))
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return 0
+		},
+
+		"kv_delete": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, key wasm.String) wasm.Buffer {
+			keyStr := wasi.LoadString(module, key)
+
+			ctx, span := tracing.Start(ctx, "kv_delete", attribute.String("key", keyStr))
+
+			err := kvDelete(ctx, keyStr)
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return 0
+		},
+
+		"report_diagnostic": func(ctx context.Context, module api.Module, stateRef wasm.Ptr, severity, message wasm.String) wasm.Buffer {
+			severityStr := wasi.LoadString(module, severity)
+
+			ctx, span := tracing.Start(ctx, "report_diagnostic", attribute.String("severity", severityStr))
+
+			err := reportDiagnostic(ctx, Severity(severityStr), wasi.LoadString(module, message))
+			tracing.End(span, err)
+
+			if err != nil {
+				return errHandler(ctx, module, stateRef, err)
+			}
+			return 0
+		},
 	}
 }
 
+type noCluster struct{}
+
+func (noCluster) Lookup(context.Context, string, string, string, string) (*unstructured.Unstructured, error) {
+	return nil, ErrFeatureNotGranted
+}
+
+func (noCluster) List(context.Context, string, string, string, string, string) ([]*unstructured.Unstructured, error) {
+	return nil, ErrFeatureNotGranted
+}
+
+func (noCluster) RestMapping(context.Context, string, string) (*RestMapping, error) {
+	return nil, ErrFeatureNotGranted
+}
+
+func (noCluster) PreviousRevision(context.Context) (*Revision, error) {
+	return nil, ErrNoPreviousRevision
+}
+
+type noFetcher struct{}
+
+func (noFetcher) Fetch(context.Context, string) (*FetchResponse, error) {
+	return nil, ErrFeatureNotGranted
+}
+
 type HostLookupResourceFunc func(ctx context.Context, name, namespace, kind, apiVersion string) (*unstructured.Unstructured, error)
 
 func HostLookupResource(client *k8s.Client) HostLookupResourceFunc {
@@ -136,6 +377,143 @@ func HostLookupResource(client *k8s.Client) HostLookupResourceFunc {
 	}
 }
 
+type HostListResourcesFunc func(ctx context.Context, namespace, kind, apiVersion, labelSelector, fieldSelector string) ([]*unstructured.Unstructured, error)
+
+// HostListResources lists resources matching the label and field selectors.
+// An empty namespace lists namespaced resources across all namespaces.
+// Unlike a lookup, resources outside of the release's ownership that are not granted by the resource access matchers
+// do not fail the call but are omitted from the result.
+func HostListResources(client *k8s.Client) HostListResourcesFunc {
+	return func(ctx context.Context, namespace, kind, apiVersion, labelSelector, fieldSelector string) ([]*unstructured.Unstructured, error) {
+		clusterAccess := clusterAccessEnabled(ctx)
+		if !clusterAccess.Enabled {
+			return nil, ErrFeatureNotGranted
+		}
+
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		gk := schema.GroupKind{Group: gv.Group, Kind: kind}
+
+		mapping, err := client.Mapper.RESTMapping(gk, gv.Version)
+		if err != nil {
+			return nil, err
+		}
+
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+			namespace = ""
+		}
+
+		list, err := client.Dynamic.Resource(mapping.Resource).Namespace(namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labelSelector,
+			FieldSelector: fieldSelector,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		trackListQuery(ctx, ListQuery{
+			Namespace:     namespace,
+			GroupKind:     gk.String(),
+			LabelSelector: labelSelector,
+			FieldSelector: fieldSelector,
+		})
+
+		resources := make([]*unstructured.Unstructured, 0, len(list.Items))
+		for i := range list.Items {
+			resource := &list.Items[i]
+			if internal.GetOwner(resource) == getOwner(ctx) {
+				trackInternalRef(ctx, internal.ResourceRef(resource))
+				resources = append(resources, resource)
+				continue
+			}
+			if slices.ContainsFunc(clusterAccess.ResourceMatchers, func(matcher string) bool {
+				return internal.MatchResource(resource, matcher)
+			}) {
+				trackExternalRef(ctx, internal.ResourceRef(resource))
+				resources = append(resources, resource)
+			}
+		}
+
+		return resources, nil
+	}
+}
+
+// ListQuery describes a call to list resources made by a flight.
+type ListQuery struct {
+	Namespace     string
+	GroupKind     string
+	LabelSelector string
+	FieldSelector string
+}
+
+// Matches reports whether the resource would be returned by the query.
+//
+// Field selectors are evaluated against the value found at their path in the resource, with missing fields
+// evaluating to the empty string, which is how the API server evaluates metadata.name, metadata.namespace,
+// the selectable fields of custom resources, and the resource specific fields of built-in types such as spec.nodeName
+// or status.phase. Fields that do not resolve to a string, number, or boolean cannot be evaluated this way
+// and are assumed to match: at worst the instance that listed them is evaluated without need.
+func (query ListQuery) Matches(resource *unstructured.Unstructured) bool {
+	if resource.GroupVersionKind().GroupKind().String() != query.GroupKind {
+		return false
+	}
+	if query.Namespace != "" && resource.GetNamespace() != query.Namespace {
+		return false
+	}
+
+	labelSelector, err := labels.Parse(query.LabelSelector)
+	if err != nil || !labelSelector.Matches(labels.Set(resource.GetLabels())) {
+		return false
+	}
+
+	fieldSelector, err := fields.ParseSelector(query.FieldSelector)
+	if err != nil {
+		return false
+	}
+
+	for _, requirement := range fieldSelector.Requirements() {
+		value, ok := fieldValue(resource, requirement.Field)
+		if !ok {
+			continue
+		}
+		switch requirement.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			if value != requirement.Value {
+				return false
+			}
+		case selection.NotEquals:
+			if value == requirement.Value {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// fieldValue returns the value of the field selector path in the resource as formatted by the API server.
+// It returns false if the path resolves to a value that field selectors cannot compare.
+func fieldValue(resource *unstructured.Unstructured, field string) (string, bool) {
+	value, found, err := unstructured.NestedFieldNoCopy(resource.Object, strings.Split(field, ".")...)
+	if err != nil {
+		return "", false
+	}
+	if !found || value == nil {
+		return "", true
+	}
+	switch value := value.(type) {
+	case string:
+		return value, true
+	case bool, int, int32, int64, float64:
+		return fmt.Sprint(value), true
+	default:
+		return "", false
+	}
+}
+
 type RestMapping struct {
 	Group      string
 	Version    string
@@ -0,0 +1,103 @@
+package host
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestListQueryMatches(t *testing.T) {
+	pod := &unstructured.Unstructured{
+		Object: map[string]any{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]any{
+				"name":      "foo",
+				"namespace": "default",
+				"labels":    map[string]any{"app": "foo"},
+			},
+			"spec": map[string]any{
+				"nodeName":   "node-a",
+				"containers": []any{map[string]any{"name": "main"}},
+			},
+			"status": map[string]any{
+				"phase": "Running",
+			},
+		},
+	}
+
+	cases := []struct {
+		Name    string
+		Query   ListQuery
+		Matches bool
+	}{
+		{
+			Name:    "no selectors",
+			Query:   ListQuery{GroupKind: "Pod"},
+			Matches: true,
+		},
+		{
+			Name:    "other kind",
+			Query:   ListQuery{GroupKind: "Secret"},
+			Matches: false,
+		},
+		{
+			Name:    "other namespace",
+			Query:   ListQuery{GroupKind: "Pod", Namespace: "kube-system"},
+			Matches: false,
+		},
+		{
+			Name:    "label selector",
+			Query:   ListQuery{GroupKind: "Pod", LabelSelector: "app=foo"},
+			Matches: true,
+		},
+		{
+			Name:    "mismatched label selector",
+			Query:   ListQuery{GroupKind: "Pod", LabelSelector: "app=bar"},
+			Matches: false,
+		},
+		{
+			Name:    "metadata fields",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "metadata.name=foo,metadata.namespace=default"},
+			Matches: true,
+		},
+		{
+			Name:    "resource specific field",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "status.phase=Running"},
+			Matches: true,
+		},
+		{
+			Name:    "mismatched resource specific field",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "spec.nodeName=node-b"},
+			Matches: false,
+		},
+		{
+			Name:    "excluded resource specific field",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "spec.nodeName!=node-a"},
+			Matches: false,
+		},
+		{
+			Name:    "missing field is empty",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "spec.schedulerName="},
+			Matches: true,
+		},
+		{
+			Name:    "missing field does not equal a value",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "spec.schedulerName=custom"},
+			Matches: false,
+		},
+		{
+			Name:    "field that cannot be compared",
+			Query:   ListQuery{GroupKind: "Pod", FieldSelector: "spec.containers=main"},
+			Matches: true,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Matches, tc.Query.Matches(pod))
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package host
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+const (
+	DefaultFetchTimeout          = 5 * time.Second
+	DefaultFetchMaxResponseBytes = 1 << 20
+
+	// maxFetchRedirects is the number of redirects followed by http_fetch, matching the default of the http package.
+	maxFetchRedirects = 10
+)
+
+var ErrURLNotAllowed = errors.New("url not allowed")
+
+// FetchResponse is the result of the http_fetch host function.
+type FetchResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+type HostFetchFunc func(ctx context.Context, url string) (*FetchResponse, error)
+
+// Fetch implements the Fetcher interface.
+func (fetch HostFetchFunc) Fetch(ctx context.Context, url string) (*FetchResponse, error) {
+	return fetch(ctx, url)
+}
+
+// HostFetch performs http GET requests on behalf of flights.
+// Requests are limited to the URLs matching the HTTPAccessParams of the context, and are bounded in time and response size.
+// Non 2xx responses are not errors and are returned to the flight as is.
+// Redirects are only followed to URLs matching the HTTPAccessParams, such that an allowed URL cannot be used to reach any other.
+func HostFetch(client *http.Client) HostFetchFunc {
+	return func(ctx context.Context, url string) (*FetchResponse, error) {
+		access := httpAccess(ctx)
+		if len(access.URLMatchers) == 0 {
+			return nil, ErrFeatureNotGranted
+		}
+
+		matchers := internal.Globs(access.URLMatchers)
+
+		if !matchers.Match(url) {
+			return nil, fmt.Errorf("%w: %s does not match any http access matcher", ErrURLNotAllowed, url)
+		}
+
+		client := *client
+		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxFetchRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxFetchRedirects)
+			}
+			if target := req.URL.String(); !matchers.Match(target) {
+				return fmt.Errorf("%w: redirect to %s does not match any http access matcher", ErrURLNotAllowed, target)
+			}
+			return nil
+		}
+
+		trackFetchedURL(ctx, url)
+
+		ctx, cancel := context.WithTimeout(ctx, cmp.Or(access.Timeout, DefaultFetchTimeout))
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build request: %w", err)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+		}
+		defer resp.Body.Close()
+
+		limit := cmp.Or(access.MaxResponseBytes, DefaultFetchMaxResponseBytes)
+
+		body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %w", err)
+		}
+		if int64(len(body)) > limit {
+			return nil, fmt.Errorf("response body of %s exceeds limit of %d bytes", url, limit)
+		}
+
+		return &FetchResponse{
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+		}, nil
+	}
+}
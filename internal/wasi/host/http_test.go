@@ -0,0 +1,76 @@
+package host
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHostFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config":
+			w.Write([]byte(`{"replicas":3}`))
+		case "/large":
+			w.Write([]byte(strings.Repeat("x", 64)))
+		case "/redirect/allowed":
+			http.Redirect(w, r, "/config", http.StatusFound)
+		case "/redirect/forbidden":
+			http.Redirect(w, r, "/nested/config", http.StatusFound)
+		case "/nested/config":
+			w.Write([]byte(`{"secret":true}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetch := HostFetch(server.Client())
+
+	_, err := fetch(context.Background(), server.URL+"/config")
+	require.ErrorIs(t, err, ErrFeatureNotGranted)
+
+	ctx := WithResourceTracking(context.Background())
+	ctx = WithHTTPAccess(ctx, HTTPAccessParams{
+		URLMatchers:      []string{server.URL + "/*"},
+		MaxResponseBytes: 32,
+	})
+
+	resp, err := fetch(ctx, server.URL+"/config")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, `{"replicas":3}`, string(resp.Body))
+
+	resp, err = fetch(ctx, server.URL+"/missing")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	_, err = fetch(ctx, server.URL+"/large")
+	require.ErrorContains(t, err, "exceeds limit of 32 bytes")
+
+	_, err = fetch(ctx, server.URL+"/nested/config")
+	require.ErrorIs(t, err, ErrURLNotAllowed)
+
+	require.ElementsMatch(
+		t,
+		[]string{server.URL + "/config", server.URL + "/missing", server.URL + "/large"},
+		FetchedURLs(ctx),
+	)
+
+	ctx = WithHTTPAccess(context.Background(), HTTPAccessParams{
+		URLMatchers: []string{server.URL + "/config", server.URL + "/redirect/*"},
+	})
+
+	resp, err = fetch(ctx, server.URL+"/redirect/allowed")
+	require.NoError(t, err)
+	require.Equal(t, `{"replicas":3}`, string(resp.Body))
+
+	_, err = fetch(ctx, server.URL+"/redirect/forbidden")
+	require.ErrorIs(t, err, ErrURLNotAllowed)
+	require.ErrorContains(t, err, "redirect to "+server.URL+"/nested/config")
+
+}
@@ -0,0 +1,169 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+// MaxReleaseStateBytes bounds the size of the keys and values of a release's state.
+// The state is stored with the revisions of the release, which are limited in size by the store.
+const MaxReleaseStateBytes = 256 * 1024
+
+var (
+	ErrKeyNotFound             = errors.New("key not found")
+	ErrReleaseStateUnavailable = errors.New("release state is only available to flights evaluated as part of a takeoff")
+)
+
+type releaseStateKey struct{}
+
+// ReleaseState is the key/value state of a release as modified by its flight.
+// It is loaded from the active revision of the release on first use, and is persisted with the next revision of the release.
+type ReleaseState struct {
+	mutex  sync.Mutex
+	loaded bool
+	values internal.State
+}
+
+// WithReleaseState returns a context holding a fresh state for the release of the context. See WithRelease.
+func WithReleaseState(ctx context.Context) context.Context {
+	return context.WithValue(ctx, releaseStateKey{}, new(ReleaseState))
+}
+
+// WithReleaseStateValues returns a context holding the given state instead of the state of the release of the context.
+// It allows flights to be evaluated with a state but without a release, such as in tests.
+func WithReleaseStateValues(ctx context.Context, values internal.State) context.Context {
+	return context.WithValue(ctx, releaseStateKey{}, &ReleaseState{loaded: true, values: maps.Clone(values)})
+}
+
+// GetReleaseState returns the state held by the context or nil if there is none.
+func GetReleaseState(ctx context.Context) *ReleaseState {
+	state, _ := ctx.Value(releaseStateKey{}).(*ReleaseState)
+	return state
+}
+
+// Values returns the current state.
+func (state *ReleaseState) Values(ctx context.Context) (internal.State, error) {
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	if err := state.load(ctx); err != nil {
+		return nil, err
+	}
+	return maps.Clone(state.values), nil
+}
+
+func (state *ReleaseState) load(ctx context.Context) error {
+	if state.loaded {
+		return nil
+	}
+
+	params, ok := getRelease(ctx)
+	if !ok {
+		return ErrReleaseStateUnavailable
+	}
+
+	release, err := params.Store.GetRelease(ctx, params.Name, params.Namespace)
+	if err != nil {
+		return fmt.Errorf("failed to get revision history: %w", err)
+	}
+
+	values, err := params.Store.GetRevisionState(ctx, release.ActiveRevision())
+	if err != nil {
+		return fmt.Errorf("failed to get state of revision %s: %w", release.ActiveRevision().Name, err)
+	}
+
+	state.values = values
+	state.loaded = true
+
+	return nil
+}
+
+type (
+	HostKVGetFunc    func(ctx context.Context, key string) (string, error)
+	HostKVSetFunc    func(ctx context.Context, key, value string) error
+	HostKVDeleteFunc func(ctx context.Context, key string) error
+)
+
+// HostKVGet returns the value of key in the release state of the context.
+func HostKVGet() HostKVGetFunc {
+	return func(ctx context.Context, key string) (string, error) {
+		state := GetReleaseState(ctx)
+		if state == nil {
+			return "", ErrReleaseStateUnavailable
+		}
+
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if err := state.load(ctx); err != nil {
+			return "", err
+		}
+
+		value, ok := state.values[key]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+		}
+		return value, nil
+	}
+}
+
+// HostKVSet sets key to value in the release state of the context.
+// It fails if the state would exceed MaxReleaseStateBytes.
+func HostKVSet() HostKVSetFunc {
+	return func(ctx context.Context, key, value string) error {
+		state := GetReleaseState(ctx)
+		if state == nil {
+			return ErrReleaseStateUnavailable
+		}
+
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if err := state.load(ctx); err != nil {
+			return err
+		}
+
+		size := len(key) + len(value)
+		for k, v := range state.values {
+			if k != key {
+				size += len(k) + len(v)
+			}
+		}
+		if size > MaxReleaseStateBytes {
+			return fmt.Errorf("cannot set %s: release state would exceed %d bytes", key, MaxReleaseStateBytes)
+		}
+
+		if state.values == nil {
+			state.values = internal.State{}
+		}
+		state.values[key] = value
+
+		return nil
+	}
+}
+
+// HostKVDelete removes key from the release state of the context. Deleting a missing key is not an error.
+func HostKVDelete() HostKVDeleteFunc {
+	return func(ctx context.Context, key string) error {
+		state := GetReleaseState(ctx)
+		if state == nil {
+			return ErrReleaseStateUnavailable
+		}
+
+		state.mutex.Lock()
+		defer state.mutex.Unlock()
+
+		if err := state.load(ctx); err != nil {
+			return err
+		}
+
+		delete(state.values, key)
+
+		return nil
+	}
+}
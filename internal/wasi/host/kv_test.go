@@ -0,0 +1,61 @@
+package host
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/k8s"
+)
+
+func TestHostKV(t *testing.T) {
+	var (
+		get = HostKVGet()
+		set = HostKVSet()
+		del = HostKVDelete()
+	)
+
+	_, err := get(context.Background(), "key")
+	require.ErrorIs(t, err, ErrReleaseStateUnavailable)
+
+	store := k8s.NewFileSystemStore(t.TempDir())
+	ctx := WithRelease(context.Background(), ReleaseParams{Store: store, Name: "foo", Namespace: "default"})
+
+	_, err = get(WithReleaseState(context.Background()), "key")
+	require.ErrorIs(t, err, ErrReleaseStateUnavailable)
+
+	now := time.Now()
+	require.NoError(t, store.CreateRevision(ctx, "foo", "default", internal.Revision{
+		Namespace: "default",
+		CreatedAt: now,
+		ActiveAt:  now,
+	}, internal.Stages{}, internal.State{"seed": "42"}))
+
+	ctx = WithReleaseState(ctx)
+
+	value, err := get(ctx, "seed")
+	require.NoError(t, err)
+	require.Equal(t, "42", value)
+
+	_, err = get(ctx, "port")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	require.NoError(t, set(ctx, "port", "8080"))
+	require.NoError(t, del(ctx, "seed"))
+	require.NoError(t, del(ctx, "missing"))
+
+	require.ErrorContains(t, set(ctx, "large", strings.Repeat("x", MaxReleaseStateBytes)), "release state would exceed")
+
+	state, err := GetReleaseState(ctx).Values(ctx)
+	require.NoError(t, err)
+	require.Equal(t, internal.State{"port": "8080"}, state)
+
+	// The state of the release is only modified by the next revision.
+	persisted, err := GetReleaseState(WithReleaseState(ctx)).Values(ctx)
+	require.NoError(t, err)
+	require.Equal(t, internal.State{"seed": "42"}, persisted)
+}
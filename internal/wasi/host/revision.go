@@ -0,0 +1,47 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/yokecd/yoke/internal"
+)
+
+var ErrNoPreviousRevision = errors.New("release has no previous revision")
+
+// Revision is the result of the k8s_previous_revision host function.
+type Revision struct {
+	internal.Revision
+	Stages internal.Stages `json:"stages"`
+}
+
+type HostPreviousRevisionFunc func(ctx context.Context) (*Revision, error)
+
+// HostPreviousRevision returns the active revision of the release the flight is evaluated for, with its stages.
+// Releases evaluated outside of a takeoff, or without any successful revision, have no previous revision.
+func HostPreviousRevision() HostPreviousRevisionFunc {
+	return func(ctx context.Context) (*Revision, error) {
+		params, ok := getRelease(ctx)
+		if !ok {
+			return nil, ErrNoPreviousRevision
+		}
+
+		release, err := params.Store.GetRelease(ctx, params.Name, params.Namespace)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get revision history: %w", err)
+		}
+
+		active := release.ActiveRevision()
+		if active.Name == "" {
+			return nil, ErrNoPreviousRevision
+		}
+
+		stages, err := params.Store.GetRevisionResources(ctx, active)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get resources of revision %s: %w", active.Name, err)
+		}
+
+		return &Revision{Revision: active, Stages: stages}, nil
+	}
+}
@@ -0,0 +1,53 @@
+package host
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/k8s"
+)
+
+func TestHostPreviousRevision(t *testing.T) {
+	previousRevision := HostPreviousRevision()
+
+	_, err := previousRevision(context.Background())
+	require.ErrorIs(t, err, ErrNoPreviousRevision)
+
+	store := k8s.NewFileSystemStore(t.TempDir())
+	ctx := WithRelease(context.Background(), ReleaseParams{Store: store, Name: "foo", Namespace: "default"})
+
+	_, err = previousRevision(ctx)
+	require.ErrorIs(t, err, ErrNoPreviousRevision)
+
+	configmap := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "config", "namespace": "default"},
+	}}
+
+	now := time.Now()
+
+	require.NoError(t, store.CreateRevision(ctx, "foo", "default", internal.Revision{
+		Namespace: "default",
+		CreatedAt: now,
+		ActiveAt:  now,
+		Resources: 1,
+	}, internal.Stages{{configmap}}, nil))
+
+	require.NoError(t, store.CreateRevision(ctx, "foo", "default", internal.Revision{
+		Namespace: "default",
+		CreatedAt: now.Add(time.Second),
+		Status:    internal.RevisionStatusFailed,
+	}, internal.Stages{}, nil))
+
+	revision, err := previousRevision(ctx)
+	require.NoError(t, err)
+	require.Equal(t, internal.RevisionStatusActive, revision.Status)
+	require.Equal(t, internal.Stages{{configmap}}, revision.Stages)
+}
@@ -0,0 +1,49 @@
+package wasi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+var ErrFunctionCallsExceeded = errors.New("function call budget exceeded")
+
+type callMeterKey struct{}
+
+// callMeter counts the guest function calls performed by an execution. Wazero does not expose instruction level metering,
+// but function calls are a portable proxy for the work performed by a module: loops that do not call functions
+// are rare in compiled go, and the go runtime calls into its scheduler regularly.
+//
+// Metering requires the module to be compiled with function listeners which slows down execution,
+// hence it is opt-in via CompileParams.Metered.
+type callMeter struct {
+	limit  uint64
+	calls  atomic.Uint64
+	cancel context.CancelCauseFunc
+}
+
+func (meter *callMeter) call() {
+	if calls := meter.calls.Add(1); meter.limit > 0 && calls == meter.limit+1 {
+		meter.cancel(fmt.Errorf("%w: budget of %d function calls exceeded", ErrFunctionCallsExceeded, meter.limit))
+	}
+}
+
+// withCallMeter returns a context that is cancelled once the module executing with it has called more than limit functions.
+// A limit of zero counts the function calls without enforcing any budget.
+func withCallMeter(ctx context.Context, limit uint64) (context.Context, *callMeter, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	meter := &callMeter{limit: limit, cancel: cancel}
+	return context.WithValue(ctx, callMeterKey{}, meter), meter, func() { cancel(nil) }
+}
+
+var callListenerFactory = experimental.FunctionListenerFactoryFunc(func(api.FunctionDefinition) experimental.FunctionListener {
+	return experimental.FunctionListenerFunc(func(ctx context.Context, _ api.Module, _ api.FunctionDefinition, _ []uint64, _ experimental.StackIterator) {
+		if meter, _ := ctx.Value(callMeterKey{}).(*callMeter); meter != nil {
+			meter.call()
+		}
+	})
+})
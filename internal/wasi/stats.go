@@ -0,0 +1,41 @@
+package wasi
+
+import (
+	"context"
+	"time"
+)
+
+// ExecStats describes the resources consumed by the execution of a module.
+type ExecStats struct {
+	// FunctionCalls is the number of guest function calls executed. It is only measured for metered modules.
+	FunctionCalls uint64
+	// MemoryBytes is the size of the module's linear memory once its execution completed.
+	// It is zero when the execution failed.
+	MemoryBytes uint64
+	// Duration is the wall clock duration of the execution.
+	Duration time.Duration
+}
+
+type execStatsKey struct{}
+
+// WithExecStats returns a context that collects the stats of the executions it is passed to.
+// If multiple executions share the context, the stats of the last one are kept.
+func WithExecStats(ctx context.Context) context.Context {
+	return context.WithValue(ctx, execStatsKey{}, new(ExecStats))
+}
+
+// GetExecStats returns the stats collected by a context returned from WithExecStats.
+// It returns false if the context does not collect stats.
+func GetExecStats(ctx context.Context) (ExecStats, bool) {
+	stats, _ := ctx.Value(execStatsKey{}).(*ExecStats)
+	if stats == nil {
+		return ExecStats{}, false
+	}
+	return *stats, true
+}
+
+func setExecStats(ctx context.Context, stats ExecStats) {
+	if value, _ := ctx.Value(execStatsKey{}).(*ExecStats); value != nil {
+		*value = stats
+	}
+}
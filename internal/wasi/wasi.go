@@ -14,12 +14,17 @@ import (
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
 	"github.com/tetratelabs/wazero/sys"
 
 	"github.com/davidmdm/x/xerr"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/metrics"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/internal/wasm"
 )
 
@@ -32,16 +37,30 @@ type ExecParams struct {
 	Timeout time.Duration
 	Env     map[string]string
 
+	// Deterministic replaces the sources of non-determinism exposed to the module.
+	// Randomness is seeded from BinName and a hash of Stdin, the wall clock is fixed to the unix epoch,
+	// and the monotonic clock advances by a fixed amount on every read.
+	Deterministic bool
+
+	// MaxFunctionCalls is the maximum number of guest function calls the module may execute before it is terminated.
+	// Zero means no limit. A non-zero budget requires a metered module.
+	MaxFunctionCalls uint64
+
 	CompileParams
 }
 
 func Execute(ctx context.Context, params ExecParams) (output []byte, err error) {
+	ctx, span := tracing.Start(ctx, "execute wasm", attribute.String("name", params.BinName))
+	defer func() { tracing.End(span, err) }()
+
 	mod, closeModule, err := func() (*Module, func(context.Context) error, error) {
 		if params.Module != nil {
 			// If the module was passed via params, we do not own its lifetime and so do not close.
 			return params.Module, func(context.Context) error { return nil }, nil
 		}
-		mod, err := Compile(ctx, params.CompileParams)
+		compileParams := params.CompileParams
+		compileParams.Metered = compileParams.Metered || params.MaxFunctionCalls > 0
+		mod, err := Compile(ctx, compileParams)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to compile module: %w", err)
 		}
@@ -54,6 +73,10 @@ func Execute(ctx context.Context, params ExecParams) (output []byte, err error)
 		err = xerr.Join(err, closeModule(ctx))
 	}()
 
+	if params.MaxFunctionCalls > 0 && !mod.Metered() {
+		return nil, errors.New("cannot enforce function call budget: module was not compiled with metering")
+	}
+
 	var (
 		stdout bytes.Buffer
 		stderr bytes.Buffer
@@ -76,13 +99,31 @@ func Execute(ctx context.Context, params ExecParams) (output []byte, err error)
 			}
 			return &stderr
 		}()).
-		WithRandSource(rand.Reader).
 		WithSysNanosleep().
-		WithSysNanotime().
-		WithSysWalltime().
 		WithArgs(append([]string{params.BinName}, params.Args...)...)
 
-	if stdin := params.Stdin; stdin != nil {
+	stdin := params.Stdin
+
+	if params.Deterministic {
+		var input []byte
+		if stdin != nil {
+			if input, err = io.ReadAll(stdin); err != nil {
+				return nil, fmt.Errorf("failed to read stdin: %w", err)
+			}
+			stdin = bytes.NewReader(input)
+		}
+		moduleCfg = moduleCfg.
+			WithRandSource(deterministicRand(params.BinName, input)).
+			WithWalltime(func() (int64, int32) { return 0, 0 }, sys.ClockResolution(time.Microsecond.Nanoseconds())).
+			WithNanotime(deterministicNanotime(), sys.ClockResolution(1))
+	} else {
+		moduleCfg = moduleCfg.
+			WithRandSource(rand.Reader).
+			WithSysNanotime().
+			WithSysWalltime()
+	}
+
+	if stdin != nil {
 		moduleCfg = moduleCfg.WithStdin(stdin)
 	}
 
@@ -105,9 +146,31 @@ func Execute(ctx context.Context, params ExecParams) (output []byte, err error)
 	}()
 	defer cancel()
 
-	if err := mod.Instantiate(ctx, moduleCfg); err != nil {
-		if errors.Is(err, context.DeadlineExceeded) {
-			if cause := context.Cause(ctx); !errors.Is(cause, context.DeadlineExceeded) {
+	ctx, meter, cancelMeter := withCallMeter(ctx, params.MaxFunctionCalls)
+	defer cancelMeter()
+
+	var stats ExecStats
+
+	start := time.Now()
+	defer func() {
+		stats.Duration = time.Since(start)
+		stats.FunctionCalls = meter.calls.Load()
+		setExecStats(ctx, stats)
+
+		metrics.WasmExecutionDuration.Observe(stats.Duration.Seconds())
+
+		internal.Debug(ctx).Printf(
+			"wasm execution stats: function calls: %d memory: %d bytes duration: %s\n",
+			stats.FunctionCalls,
+			stats.MemoryBytes,
+			stats.Duration.Round(time.Millisecond),
+		)
+	}()
+
+	memory, err := mod.Instantiate(ctx, moduleCfg)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			if cause := context.Cause(ctx); !errors.Is(cause, context.DeadlineExceeded) && !errors.Is(cause, context.Canceled) {
 				return nil, fmt.Errorf("%v: %w", err, cause)
 			}
 			return nil, err
@@ -134,6 +197,8 @@ func Execute(ctx context.Context, params ExecParams) (output []byte, err error)
 		return stdout.Bytes(), fmt.Errorf("failed to instantiate module: %w", runtimeErr)
 	}
 
+	stats.MemoryBytes = uint64(memory)
+
 	return stdout.Bytes(), nil
 }
 
@@ -142,27 +207,37 @@ type CompileParams struct {
 	CacheDir        string
 	MaxMemoryMib    uint32
 	HostFunctionMap map[string]any
+
+	// Metered compiles the module such that the guest function calls it performs are counted.
+	// Metering slows down execution and is required to enforce function call budgets.
+	Metered bool
 }
 
 type Module struct {
 	wazero.CompiledModule
 	wazero.Runtime
 	maxMemoryMib uint32
+	metered      bool
 	sha1         string
 	sha256       string
 }
 
-func (mod Module) Instantiate(ctx context.Context, cfg wazero.ModuleConfig) error {
+// Instantiate runs the module to completion and returns the size of its memory in bytes.
+func (mod Module) Instantiate(ctx context.Context, cfg wazero.ModuleConfig) (memory uint32, err error) {
 	module, err := mod.InstantiateModule(ctx, mod.CompiledModule, cfg)
 	if err != nil {
-		return err
+		return 0, err
 	}
 	if !reflect.ValueOf(module).IsNil() {
+		if mem := module.Memory(); mem != nil {
+			memory = mem.Size()
+			metrics.WasmMemory.Observe(float64(memory))
+		}
 		if err := module.Close(ctx); err != nil {
-			return fmt.Errorf("failed to close module: %w", err)
+			return 0, fmt.Errorf("failed to close module: %w", err)
 		}
 	}
-	return nil
+	return memory, nil
 }
 
 func (mod Module) Close(ctx context.Context) error {
@@ -190,6 +265,10 @@ const (
 func Compile(ctx context.Context, params CompileParams) (Module, error) {
 	defer internal.DebugTimer(ctx, "wasm compile")()
 
+	if IsComponent(params.Wasm) {
+		return Module{}, ErrComponentNotSupported
+	}
+
 	cfg := wazero.
 		NewRuntimeConfig().
 		WithCloseOnContextDone(true)
@@ -220,6 +299,10 @@ func Compile(ctx context.Context, params CompileParams) (Module, error) {
 
 	wasi_snapshot_preview1.MustInstantiate(ctx, runtime)
 
+	if params.Metered {
+		ctx = context.WithValue(ctx, experimental.FunctionListenerFactoryKey{}, callListenerFactory)
+	}
+
 	mod, err := runtime.CompileModule(ctx, params.Wasm)
 	if err != nil {
 		return Module{}, err
@@ -229,6 +312,7 @@ func Compile(ctx context.Context, params CompileParams) (Module, error) {
 		Runtime:        runtime,
 		CompiledModule: mod,
 		maxMemoryMib:   params.MaxMemoryMib,
+		metered:        params.Metered,
 		sha1:           internal.SHA1HexString(params.Wasm),
 		sha256:         internal.SHA256HexString(params.Wasm),
 	}, nil
@@ -238,6 +322,11 @@ func (mod Module) MaxMemoryMib() uint32 {
 	return mod.maxMemoryMib
 }
 
+// Metered reports whether the module was compiled with metering.
+func (mod Module) Metered() bool {
+	return mod.metered
+}
+
 func (mod Module) Checksum() string {
 	return mod.sha1
 }
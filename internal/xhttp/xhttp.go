@@ -4,6 +4,7 @@ import (
 	"cmp"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -13,7 +14,13 @@ import (
 
 	"github.com/docker/go-units"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+
 	"github.com/davidmdm/x/xruntime"
+
+	"github.com/yokecd/yoke/internal/tracing"
 )
 
 type LogFilterFunc func(pattern string, attrs []slog.Attr) bool
@@ -28,8 +35,8 @@ func WithLogger(logger *slog.Logger, handler http.Handler, filter LogFilterFunc)
 
 		handler.ServeHTTP(&sw, r)
 
-		if sw.Code() == 200 && (r.URL.Path == "/live" || r.URL.Path == "/ready") {
-			// Skip logging on simple liveness/readiness check passes as they polute the logs with information
+		if sw.Code() == 200 && (r.URL.Path == "/live" || r.URL.Path == "/ready" || r.URL.Path == "/metrics") {
+			// Skip logging on simple liveness/readiness check passes and metric scrapes as they polute the logs with information
 			// that we don't need to see
 			return
 		}
@@ -50,6 +57,36 @@ func WithLogger(logger *slog.Logger, handler http.Handler, filter LogFilterFunc)
 	})
 }
 
+// WithTracing starts a span for every request, continuing any trace propagated by the caller via the request headers.
+// Liveness, readiness and metric requests are not traced.
+func WithTracing(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/live" || r.URL.Path == "/ready" || r.URL.Path == "/metrics" {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Start(ctx, r.Method+" "+r.URL.Path, attribute.String("http.method", r.Method), attribute.String("http.path", r.URL.Path))
+
+		sw := statusWriter{ResponseWriter: w}
+
+		r = r.WithContext(ctx)
+		handler.ServeHTTP(&sw, r)
+
+		if r.Pattern != "" {
+			span.SetName(r.Pattern)
+		}
+		span.SetAttributes(attribute.Int("http.status_code", sw.Code()))
+
+		var err error
+		if sw.Code() >= 500 {
+			err = errors.New(http.StatusText(sw.Code()))
+		}
+		tracing.End(span, err)
+	})
+}
+
 func WithRecover(handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
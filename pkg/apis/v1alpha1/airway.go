@@ -22,8 +22,17 @@ const (
 type Airway struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitzero"`
-	Spec              AirwaySpec    `json:"spec"`
-	Status            flight.Status `json:"status,omitzero"`
+	Spec              AirwaySpec   `json:"spec"`
+	Status            AirwayStatus `json:"status,omitzero"`
+}
+
+// AirwayStatus is the status of an Airway.
+type AirwayStatus struct {
+	// Conditions are the conditions that are met for this airway. Only the Ready condition is set by yoke.
+	Conditions flight.Conditions `json:"conditions,omitempty"`
+
+	// Rollout is the state of the rollout of the airway's flight to its instances.
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
 }
 
 func (Airway) OpenAPISchema() *apiextensionsv1.JSONSchemaProps {
@@ -132,6 +141,18 @@ type AirwaySpec struct {
 	// 	- foo/* 												# matches all resources in namespace foo.
 	ResourceAccessMatchers []string `json:"resourceAccessMatchers,omitempty" Description:"ResourceMatcher expressions to allow explicit access to resources not owned by the flight."`
 
+	// HTTPAccessMatchers allow the flight to fetch data from http services via the WASI SDK.
+	// Only URLs matching at least one of the glob patterns can be fetched. By default no URL can be fetched.
+	//
+	// Examples Matchers:
+	// 	- https://config.internal/*					# matches all paths one level under config.internal
+	// 	- http://flags.default.svc/flags/* 	# matches all flags served by the flags service
+	HTTPAccessMatchers []string `json:"httpAccessMatchers,omitempty" Description:"URL glob patterns the flight is allowed to fetch via WASI SDK."`
+
+	// HTTPRefreshInterval sets the interval at which instances in dynamic mode that fetched URLs during their last evaluation are requeued.
+	// Unlike resources looked up in the cluster, changes to the content of URLs cannot be watched. By default 5m.
+	HTTPRefreshInterval metav1.Duration `json:"httpRefreshInterval,omitzero" Description:"Interval to requeue dynamic instances that fetched URLs. Default is 5m."`
+
 	// Insecure only applies to flights using OCI urls. Allows image references to be fetched without TLS verification.
 	Insecure bool `json:"insecure,omitempty" Description:"Insecure only applies to flights using OCI urls. Allows image references to be fetched without TLS verification."`
 
@@ -140,7 +161,7 @@ type AirwaySpec struct {
 	// However in the case of some multi-stage implementations, stages that depend on prior stages cannot pass dry-run.
 	// In this case there is no option but to skip the admission webhook.
 	//
-	// Therefore multi-stage Airways are not generally recommended.
+	// Therefore multi-stage Airways are not generally recommended. The WasmURLs.Validator still runs when the dry-run is skipped.
 	SkipAdmissionWebhook bool `json:"skipAdmissionWebhook,omitempty" Description:"Skip admission validation for your airway instances."`
 
 	// Mode sets different behaviors for how the child resources of flights are managed by the ATC.
@@ -176,6 +197,22 @@ type AirwaySpec struct {
 
 	// Timeout is the timeout for the airway instance's flight execution. Default setting is 10s.
 	Timeout metav1.Duration `json:"timeout,omitzero" Description:"Maximum execution duration before flight is cancelled."`
+
+	// MaxFunctionCalls is the maximum number of wasm function calls the flight execution can perform before it is cancelled.
+	// Contrary to Timeout, it does not depend on the load of the ATC. Leaving it unset does not limit function calls.
+	MaxFunctionCalls uint64 `json:"maxFunctionCalls,omitzero" Description:"Maximum number of wasm function calls before flight is cancelled."`
+
+	// Deterministic runs the flight with randomness seeded from the release name and input, and with a fixed clock.
+	// Identical inputs then produce identical outputs, avoiding spurious revisions.
+	Deterministic bool `json:"deterministic,omitempty" Description:"Run the flight with seeded randomness and a fixed clock."`
+
+	// Rollout controls how instances are upgraded when the flight of the Airway changes.
+	// By default every instance is upgraded to the new flight at once.
+	Rollout *RolloutPolicy `json:"rollout,omitempty" Description:"Policy for upgrading instances to a new flight progressively."`
+
+	// Suspend stops the ATC from taking off the instances of the Airway and fixing their drift, for example during maintenance windows or incidents.
+	// Suspended instances can still be deleted. To suspend a single instance set the yoke.cd/suspend annotation to "true" on it instead.
+	Suspend bool `json:"suspend,omitempty" Description:"Suspend takeoffs and drift fixing of all instances. Instances can still be deleted."`
 }
 
 func (AirwaySpec) OpenAPISchema() *apiextensionsv1.JSONSchemaProps {
@@ -198,6 +235,58 @@ type PruneOptions struct {
 	Namespaces bool `json:"namespaces,omitzero" Description:"Delete owned namespaces on deletion"`
 }
 
+const (
+	// AnnotationSuspend suspends a Flight, ClusterFlight, or Airway instance when set to "true".
+	// The ATC neither takes off suspended resources nor fixes their drift, but still removes their release when they are deleted.
+	AnnotationSuspend = "yoke.cd/suspend"
+
+	// AnnotationReconcileRequestedAt requests the immediate evaluation of a Flight, ClusterFlight, or Airway instance
+	// when set to a value it did not previously hold, for example a timestamp. It has no effect on suspended resources.
+	AnnotationReconcileRequestedAt = "yoke.cd/reconcile-requested-at"
+)
+
+// IsSuspended reports whether the resource is suspended via the AnnotationSuspend annotation.
+func IsSuspended(resource metav1.Object) bool {
+	return resource.GetAnnotations()[AnnotationSuspend] == "true"
+}
+
+// AnnotationResumeRollout resumes a paused rollout when set on an Airway to a value it was not previously resumed with, for example a timestamp.
+const AnnotationResumeRollout = "yoke.cd/resume-rollout"
+
+// RolloutPolicy describes how the instances of an Airway are upgraded when its flight changes.
+// Instances that are not upgraded yet keep being evaluated with the flight they last ran successfully.
+// Only the flight module is rolled out progressively: other changes to the Airway apply to every instance at once.
+type RolloutPolicy struct {
+	// MaxConcurrent is the maximum number of instances being upgraded at the same time. Zero does not limit concurrency.
+	MaxConcurrent int `json:"maxConcurrent,omitempty" Description:"Maximum number of instances upgraded concurrently. Unlimited by default."`
+
+	// Canary selects the instances upgraded first. Other instances are only upgraded once every canary instance runs the new flight.
+	Canary *metav1.LabelSelector `json:"canary,omitempty" Description:"Label selector of the instances to upgrade before any other."`
+
+	// MaxFailures pauses the rollout once that many instances failed to upgrade. Instances that failed to upgrade
+	// are not retried until the rollout is resumed via the yoke.cd/resume-rollout annotation or a new flight is rolled out.
+	// Zero never pauses the rollout.
+	MaxFailures int `json:"maxFailures,omitempty" Description:"Number of failed instance upgrades after which the rollout is paused. Never paused by default."`
+}
+
+// RolloutStatus is the state of the rollout of a flight to the instances of an Airway.
+type RolloutStatus struct {
+	// Flight is the flight being rolled out.
+	Flight flight.Module `json:"flight"`
+
+	// Previous is the flight rolled out before. Instances that have not reported the flight they run are considered to run it.
+	Previous flight.Module `json:"previous,omitzero"`
+
+	// Failed lists the references of the instances that failed to upgrade.
+	Failed []string `json:"failed,omitempty"`
+
+	// Paused is set once the number of failed instances reaches the MaxFailures of the rollout policy.
+	Paused bool `json:"paused,omitempty"`
+
+	// Resumed is the value of the yoke.cd/resume-rollout annotation the rollout was last resumed with.
+	Resumed string `json:"resumed,omitempty"`
+}
+
 type WasmURLs struct {
 	// Flight is the implementation used to implement the CustomResource as a Package. The flight is always applied against
 	// the storage version of the Custom Resource. This property is required.
@@ -214,6 +303,44 @@ type WasmURLs struct {
 	// ConverterChecksum is the explicit sha256 checksum to verify converter against.
 	// Useful when module cannot be referenced by its checksum implicity via tag or path components.
 	ConverterChecksum string `json:"converterChecksum,omitzero" Description:"Explicit sha256 checksum to verify converter against. Useful when module cannot be referenced by its checksum implicity via tag or path components."`
+
+	// Validator is the implementation of the admission validation of the Custom Resource. If present, the ATC runs it on creation and update of
+	// instances before the dry-run of the flight. It reads the Custom Resource from stdin and writes a Validation to stdout.
+	// The validator runs even if SkipAdmissionWebhook is set, which only skips the dry-run of the flight.
+	Validator string `json:"validator,omitempty" Description:"URL to validator module. Used to validate instances at admission. Supports http(s) or oci."`
+
+	// ValidatorChecksum is the explicit sha256 checksum to verify validator against.
+	// Useful when module cannot be referenced by its checksum implicity via tag or path components.
+	ValidatorChecksum string `json:"validatorChecksum,omitzero" Description:"Explicit sha256 checksum to verify validator against. Useful when module cannot be referenced by its checksum implicity via tag or path components."`
+
+	// Defaulter is the implementation of the mutating admission webhook of the Custom Resource. If present, the ATC registers a MutatingWebhookConfiguration
+	// for instances. It reads the Custom Resource from stdin and writes a JSON patch (RFC 6902) of the defaults to apply to stdout.
+	Defaulter string `json:"defaulter,omitempty" Description:"URL to defaulter module. Used to set defaults on instances at admission. Supports http(s) or oci."`
+
+	// DefaulterChecksum is the explicit sha256 checksum to verify defaulter against.
+	// Useful when module cannot be referenced by its checksum implicity via tag or path components.
+	DefaulterChecksum string `json:"defaulterChecksum,omitzero" Description:"Explicit sha256 checksum to verify defaulter against. Useful when module cannot be referenced by its checksum implicity via tag or path components."`
+}
+
+// Validation is the result a validator module writes to stdout. See WasmURLs.Validator.
+type Validation struct {
+	// Allowed admits the Custom Resource.
+	Allowed bool `json:"allowed"`
+
+	// Message is the reason the Custom Resource is denied.
+	Message string `json:"message,omitempty"`
+
+	// Errors are the invalid fields of the Custom Resource.
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes an invalid field of a Custom Resource.
+type FieldError struct {
+	// Field is the path of the field, for example spec.replicas.
+	Field string `json:"field"`
+
+	// Message describes why the field is invalid.
+	Message string `json:"message"`
 }
 
 func (airway Airway) MarshalJSON() ([]byte, error) {
@@ -30,7 +30,8 @@ type Flight struct {
 
 type FlightStatus struct {
 	flight.Status
-	Inventory []InventoryItem `json:"inventory,omitzero"`
+	Inventory []InventoryItem   `json:"inventory,omitzero"`
+	Execution *flight.Execution `json:"execution,omitempty"`
 }
 
 type InventoryItem struct {
@@ -115,9 +116,34 @@ type FlightSpec struct {
 	// 	- foo/* 												# matches all resources in namespace foo.
 	ResourceAccessMatchers []string `json:"resourceAccessMatchers,omitempty" Description:"ResourceMatcher expressions to allow explicit access to resources not owned by the flight."`
 
+	// HTTPAccessMatchers allow the flight to fetch data from http services via the WASI SDK.
+	// Only URLs matching at least one of the glob patterns can be fetched. By default no URL can be fetched.
+	//
+	// Examples Matchers:
+	// 	- https://config.internal/*					# matches all paths one level under config.internal
+	// 	- http://flags.default.svc/flags/* 	# matches all flags served by the flags service
+	HTTPAccessMatchers []string `json:"httpAccessMatchers,omitempty" Description:"URL glob patterns the flight is allowed to fetch via WASI SDK."`
+
+	// HTTPRefreshInterval sets the interval at which flights in dynamic mode that fetched URLs during their last evaluation are requeued.
+	// Unlike resources looked up in the cluster, changes to the content of URLs cannot be watched. By default 5m.
+	HTTPRefreshInterval metav1.Duration `json:"httpRefreshInterval,omitzero" Description:"Interval to requeue dynamic flights that fetched URLs. Default is 5m."`
+
 	// Insecure only applies to flights using OCI urls. Allows image references to be fetched without TLS verification.
 	Insecure bool `json:"insecure,omitempty" Description:"Insecure only applies to flights using OCI urls. Allows image references to be fetched without TLS verification."`
 
+	// Mode sets how the child resources of the flight are managed by the ATC. It has the same semantics as the mode of an Airway:
+	//
+	// - "standard" is the same as not specifying any mode. The flight is evaluated when the Flight resource changes,
+	// and no further evaluation is made should child resources be modified.
+	//
+	// - "static" checks any change to a child resource against desired state at admission time.
+	// If any fields conflict with the desired state the change is rejected at admission.
+	//
+	// - "dynamic" requeues the flight for evaluation any time a child resource or a resource looked up by the flight is modified.
+	//
+	// - "subscription" only requeues the flight when resources looked up by its last evaluation are modified.
+	Mode AirwayMode `json:"mode,omitempty"`
+
 	// SkipAdmissionWebhook bypasses admission webhook for the airway's CRs.
 	// The admission webhook validates that the resources that would be created pass a dry-run phase.
 	// However in the case of some multi-stage implementations, stages that depend on prior stages cannot pass dry-run.
@@ -141,6 +167,18 @@ type FlightSpec struct {
 
 	// Timeout is the timeout for the airway instance's flight execution. Default setting is 10s.
 	Timeout metav1.Duration `json:"timeout,omitzero" Description:"Maximum execution duration before flight is cancelled."`
+
+	// MaxFunctionCalls is the maximum number of wasm function calls the flight execution can perform before it is cancelled.
+	// Contrary to Timeout, it does not depend on the load of the ATC. Leaving it unset does not limit function calls.
+	MaxFunctionCalls uint64 `json:"maxFunctionCalls,omitzero" Description:"Maximum number of wasm function calls before flight is cancelled."`
+
+	// Deterministic runs the flight with randomness seeded from the release name and input, and with a fixed clock.
+	// Identical inputs then produce identical outputs, avoiding spurious revisions.
+	Deterministic bool `json:"deterministic,omitempty" Description:"Run the flight with seeded randomness and a fixed clock."`
+
+	// Suspend stops the ATC from taking off the flight and fixing its drift, for example during maintenance windows or incidents.
+	// Suspended flights can still be deleted. Setting the yoke.cd/suspend annotation to "true" has the same effect.
+	Suspend bool `json:"suspend,omitempty" Description:"Suspend takeoffs and drift fixing of the flight. The flight can still be deleted."`
 }
 
 func (FlightSpec) OpenAPISchema() *apiextensionsv1.JSONSchemaProps {
@@ -0,0 +1,323 @@
+// Package flighttest runs compiled flights against an in-memory cluster so that their output can be asserted on in tests.
+//
+// Flights are executed by the same wasm runtime and host functions used by yoke and the ATC. Only the backends of the host functions differ:
+// the cluster functions are served by a k8s.Fake instead of a cluster, the http_fetch host function by an http.Handler,
+// the kv host functions by an in-memory map, and the diagnostics reported by the flight are collected into Params.Diagnostics.
+package flighttest
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/text"
+	"github.com/yokecd/yoke/internal/wasi"
+	"github.com/yokecd/yoke/internal/wasi/host"
+	"github.com/yokecd/yoke/pkg/flight/wasi/diagnostics"
+	"github.com/yokecd/yoke/pkg/flight/wasi/k8s"
+)
+
+var update = flag.Bool("flighttest.update", false, "update golden files with the actual output of flights")
+
+// Stages are the resources output by a flight, grouped by the stages in which they are applied.
+type Stages = internal.Stages
+
+// Diagnostic is a diagnostic reported by a flight. See the diagnostics package.
+type Diagnostic struct {
+	Severity diagnostics.Severity
+	Message  string
+}
+
+type Params struct {
+	// Wasm is the compiled flight. See Build to compile a flight from source.
+	Wasm []byte
+	// Release is the release name passed to the flight. Defaults to "flighttest".
+	Release string
+	// Namespace is the release namespace passed to the flight. Defaults to "default".
+	Namespace string
+	// Stdin is the input of the flight.
+	Stdin io.Reader
+	// Args are the arguments passed to the flight.
+	Args []string
+	// Env is added to the environment of the flight.
+	Env map[string]string
+	// Cluster is the state the flight's lookups and previous revision are resolved against.
+	// If nil, cluster access is not granted and lookups fail with k8s.ErrorClusterAccessNotGranted.
+	Cluster *k8s.Fake
+	// HTTP serves the requests of the flight's fetches.
+	// If nil, http access is not granted and fetches fail with fetch.ErrorHTTPAccessNotGranted.
+	HTTP http.Handler
+	// State is the key/value state of the release. It is modified in place by the flight.
+	// If nil, the state is not available and kv operations fail with kv.ErrorStateNotAvailable.
+	State map[string]string
+	// Diagnostics receives the diagnostics reported by the flight when set.
+	Diagnostics *[]Diagnostic
+	// Deterministic seeds the randomness of the flight and fixes its clock, such that its output can be compared to golden files.
+	Deterministic bool
+	// MaxFunctionCalls limits the number of wasm function calls the flight can execute. Zero means no limit.
+	MaxFunctionCalls uint64
+	// Stderr receives the stderr of the flight when set.
+	Stderr io.Writer
+	// Timeout of the execution. Defaults to 10 seconds.
+	Timeout time.Duration
+}
+
+// Run executes the flight and parses its output into stages.
+func Run(ctx context.Context, params Params) (Stages, error) {
+	release := cmp.Or(params.Release, "flighttest")
+	namespace := cmp.Or(params.Namespace, "default")
+
+	env := map[string]string{
+		"YOKE_RELEASE":   release,
+		"YOKE_NAMESPACE": namespace,
+		"NAMESPACE":      namespace,
+		"YOKE_VERSION":   internal.GetYokeVersion(),
+	}
+	for key, value := range params.Env {
+		env[key] = value
+	}
+
+	backends := host.Backends{}
+	if params.Cluster != nil {
+		backends.Cluster = fakeCluster{params.Cluster}
+	}
+	if params.HTTP != nil {
+		backends.HTTP = handlerFetcher{params.HTTP}
+	}
+
+	if params.State != nil {
+		ctx = host.WithReleaseStateValues(ctx, params.State)
+	}
+	ctx = host.WithDiagnostics(ctx)
+
+	output, err := wasi.Execute(ctx, wasi.ExecParams{
+		BinName: release,
+		Stdin:   params.Stdin,
+		Stderr:  params.Stderr,
+		Args:    params.Args,
+		Timeout: params.Timeout,
+		Env:     env,
+
+		Deterministic:    params.Deterministic,
+		MaxFunctionCalls: params.MaxFunctionCalls,
+
+		CompileParams: wasi.CompileParams{
+			Wasm:            params.Wasm,
+			HostFunctionMap: host.BuildFunctionMap(backends),
+		},
+	})
+
+	if params.State != nil {
+		values, stateErr := host.GetReleaseState(ctx).Values(ctx)
+		if stateErr != nil {
+			return nil, fmt.Errorf("failed to read release state: %w", stateErr)
+		}
+		clear(params.State)
+		maps.Copy(params.State, values)
+	}
+
+	if params.Diagnostics != nil {
+		for _, diagnostic := range host.Diagnostics(ctx) {
+			*params.Diagnostics = append(*params.Diagnostics, Diagnostic{
+				Severity: diagnostics.Severity(diagnostic.Severity),
+				Message:  diagnostic.Message,
+			})
+		}
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute flight: %w", err)
+	}
+
+	stages, err := internal.ParseStages(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse flight output: %w", err)
+	}
+
+	return stages, nil
+}
+
+// Build compiles the flight at the given go package path for wasip1 and returns the resulting wasm.
+// Relative paths are resolved from the working directory of the test, which is the directory of the package under test.
+func Build(t testing.TB, path string) []byte {
+	t.Helper()
+
+	out := filepath.Join(t.TempDir(), "flight.wasm")
+
+	cmd := exec.Command("go", "build", "-o", out, path)
+	cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build flight %s: %v\n%s", path, err, output)
+	}
+
+	wasm, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("failed to read flight: %v", err)
+	}
+
+	return wasm
+}
+
+// Golden compares the yaml encoding of value with the golden file testdata/<name>.golden.
+// When the -flighttest.update flag is set, the golden file is written instead.
+func Golden(t testing.TB, name string, value any) {
+	t.Helper()
+
+	var actual bytes.Buffer
+	if err := internal.WriteOutput(&actual, internal.OutputFormatYAML, value); err != nil {
+		t.Fatalf("failed to encode value: %v", err)
+	}
+
+	path := filepath.Join("testdata", name+".golden")
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("failed to create golden file directory: %v", err)
+		}
+		if err := os.WriteFile(path, actual.Bytes(), 0o644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("golden file %s does not exist: run the test with -flighttest.update to create it", path)
+		}
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if diff := text.Diff(text.File{Name: path, Content: string(expected)}, text.File{Name: "actual", Content: actual.String()}, 3); diff != "" {
+		t.Errorf("output does not match golden file (run the test with -flighttest.update to update it):\n%s", diff)
+	}
+}
+
+// fakeCluster serves the cluster host functions from a k8s.Fake.
+type fakeCluster struct {
+	fake *k8s.Fake
+}
+
+func (cluster fakeCluster) Lookup(_ context.Context, name, namespace, kind, apiVersion string) (*unstructured.Unstructured, error) {
+	resource, err := cluster.fake.Get(k8s.ResourceIdentifier{
+		Name:       name,
+		Namespace:  namespace,
+		Kind:       kind,
+		ApiVersion: apiVersion,
+	})
+	return resource, apiError(err)
+}
+
+func (cluster fakeCluster) List(_ context.Context, namespace, kind, apiVersion, labelSelector, fieldSelector string) ([]*unstructured.Unstructured, error) {
+	items, err := cluster.fake.List(k8s.ListOptions{
+		Namespace:     namespace,
+		Kind:          kind,
+		ApiVersion:    apiVersion,
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return nil, apiError(err)
+	}
+	resources := make([]*unstructured.Unstructured, len(items))
+	for i := range items {
+		resources[i] = &items[i]
+	}
+	return resources, nil
+}
+
+func (cluster fakeCluster) RestMapping(_ context.Context, groupOrAPIVersion, kind string) (*host.RestMapping, error) {
+	mapping, err := cluster.fake.RestMapping(groupOrAPIVersion, kind)
+	if err != nil {
+		return nil, apiError(err)
+	}
+	return &host.RestMapping{
+		Group:      mapping.Group,
+		Version:    mapping.Version,
+		Kind:       mapping.Kind,
+		Resource:   mapping.Resource,
+		Namespaced: mapping.Namespaced,
+	}, nil
+}
+
+func (cluster fakeCluster) PreviousRevision(context.Context) (*host.Revision, error) {
+	revision, err := cluster.fake.PreviousRevision()
+	if err != nil {
+		return nil, host.ErrNoPreviousRevision
+	}
+
+	stages := make(internal.Stages, len(revision.Stages))
+	for i, stage := range revision.Stages {
+		stages[i] = stage
+	}
+
+	return &host.Revision{
+		Revision: internal.Revision{
+			Name:      revision.Name,
+			Source:    internal.Source{Ref: revision.Source.Ref, Checksum: revision.Source.Checksum},
+			CreatedAt: revision.CreatedAt,
+			ActiveAt:  revision.ActiveAt,
+			Resources: len(revision.Resources()),
+		},
+		Stages: stages,
+	}, nil
+}
+
+// apiError maps the errors of the fake to the api errors returned by a cluster, such that the host functions report them to the flight the same way.
+func apiError(err error) error {
+	reason := func() metav1.StatusReason {
+		switch {
+		case err == nil:
+			return ""
+		case k8s.IsErrNotFound(err):
+			return metav1.StatusReasonNotFound
+		case k8s.IsErrForbidden(err):
+			return metav1.StatusReasonForbidden
+		case k8s.IsErrUnauthenticated(err):
+			return metav1.StatusReasonUnauthorized
+		default:
+			return ""
+		}
+	}()
+	if reason == "" {
+		return err
+	}
+	return &kerrors.StatusError{ErrStatus: metav1.Status{Status: metav1.StatusFailure, Reason: reason, Message: err.Error()}}
+}
+
+// handlerFetcher serves the http_fetch host function from an http.Handler.
+type handlerFetcher struct {
+	handler http.Handler
+}
+
+func (fetcher handlerFetcher) Fetch(ctx context.Context, url string) (*host.FetchResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	recorder := httptest.NewRecorder()
+	fetcher.handler.ServeHTTP(recorder, req)
+	return &host.FetchResponse{
+		StatusCode: recorder.Code,
+		Header:     recorder.Header(),
+		Body:       recorder.Body.Bytes(),
+	}, nil
+}
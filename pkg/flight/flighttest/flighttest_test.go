@@ -0,0 +1,73 @@
+package flighttest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight/wasi/diagnostics"
+	"github.com/yokecd/yoke/pkg/flight/wasi/k8s"
+)
+
+func TestRun(t *testing.T) {
+	wasm := Build(t, "./testdata/flight")
+
+	configmap := func(name string, lbls, data map[string]string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: lbls},
+			Data:       data,
+		}
+	}
+
+	cluster, err := k8s.NewFake(
+		configmap("base", nil, map[string]string{"a": "base", "b": "base"}),
+		configmap("one", map[string]string{"app": "demo"}, map[string]string{"b": "one"}),
+		configmap("two", map[string]string{"app": "other"}, map[string]string{"c": "two"}),
+	)
+	require.NoError(t, err)
+
+	var reported []Diagnostic
+
+	stages, err := Run(context.Background(), Params{
+		Wasm:        wasm,
+		Release:     "demo",
+		Cluster:     cluster,
+		Diagnostics: &reported,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Diagnostic{{Severity: diagnostics.SeverityInfo, Message: "http access not granted: overrides are not applied"}}, reported)
+
+	Golden(t, "merge", stages)
+
+	stages, err = Run(context.Background(), Params{
+		Wasm:    wasm,
+		Release: "demo",
+		Cluster: cluster,
+		HTTP: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.String() != "http://config.internal/overrides" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(`{"c": "override"}`))
+		}),
+	})
+	require.NoError(t, err)
+
+	Golden(t, "merge_overrides", stages)
+
+	state := map[string]string{"runs": "1"}
+	for range 2 {
+		_, err = Run(context.Background(), Params{Wasm: wasm, Release: "demo", Cluster: cluster, State: state})
+		require.NoError(t, err)
+	}
+	require.Equal(t, map[string]string{"runs": "3"}, state)
+
+	_, err = Run(context.Background(), Params{Wasm: wasm, Release: "demo"})
+	require.ErrorContains(t, err, k8s.ErrorClusterAccessNotGranted.Error())
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/yokecd/yoke/pkg/flight"
+	"github.com/yokecd/yoke/pkg/flight/wasi/diagnostics"
+	"github.com/yokecd/yoke/pkg/flight/wasi/fetch"
+	"github.com/yokecd/yoke/pkg/flight/wasi/k8s"
+	"github.com/yokecd/yoke/pkg/flight/wasi/kv"
+)
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// This program merges the data of the configmaps labeled with the release name with the data of the "base" configmap,
+// and with the overrides served by a config service if http access is granted.
+// It counts its evaluations in the release state when it is available.
+func run() error {
+	data := map[string]string{}
+
+	base, err := k8s.Lookup[corev1.ConfigMap](k8s.ResourceIdentifier{
+		Name:       "base",
+		Namespace:  flight.Namespace(),
+		Kind:       "ConfigMap",
+		ApiVersion: "v1",
+	})
+	if err != nil && !k8s.IsErrNotFound(err) {
+		return fmt.Errorf("failed to lookup base configmap: %w", err)
+	}
+	if base != nil {
+		maps.Copy(data, base.Data)
+	}
+
+	sources, err := k8s.List[corev1.ConfigMap](k8s.ListOptions{
+		Namespace:     flight.Namespace(),
+		Kind:          "ConfigMap",
+		ApiVersion:    "v1",
+		LabelSelector: "app=" + flight.Release(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list configmaps: %w", err)
+	}
+	for _, source := range sources {
+		maps.Copy(data, source.Data)
+	}
+
+	resp, err := fetch.Get("http://config.internal/overrides")
+	if err != nil && !errors.Is(err, fetch.ErrorHTTPAccessNotGranted) {
+		return fmt.Errorf("failed to fetch overrides: %w", err)
+	}
+	if resp != nil {
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("failed to fetch overrides: unexpected status code %d", resp.StatusCode)
+		}
+		var overrides map[string]string
+		if err := json.Unmarshal(resp.Body, &overrides); err != nil {
+			return fmt.Errorf("failed to decode overrides: %w", err)
+		}
+		maps.Copy(data, overrides)
+	} else if err := diagnostics.Info("http access not granted: overrides are not applied"); err != nil {
+		return fmt.Errorf("failed to report diagnostic: %w", err)
+	}
+
+	if err := countRun(); err != nil && !errors.Is(err, kv.ErrorStateNotAvailable) {
+		return fmt.Errorf("failed to count run: %w", err)
+	}
+
+	return json.NewEncoder(os.Stdout).Encode(corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      flight.Release(),
+			Namespace: flight.Namespace(),
+		},
+		Data: data,
+	})
+}
+
+func countRun() error {
+	runs := 0
+	value, err := kv.Get("runs")
+	if err != nil && !kv.IsErrKeyNotFound(err) {
+		return err
+	}
+	if value != "" {
+		if runs, err = strconv.Atoi(value); err != nil {
+			return fmt.Errorf("invalid run count: %w", err)
+		}
+	}
+	return kv.Set("runs", strconv.Itoa(runs+1))
+}
@@ -14,6 +14,26 @@ type Status struct {
 	Conditions Conditions `json:"conditions,omitempty"`
 }
 
+// Execution describes the resources consumed by the flight execution that produced the current revision of a release.
+// The ATC reports it under status.execution of Flights and Airway instances.
+type Execution struct {
+	// FunctionCalls is the number of wasm function calls performed by the flight. It is only measured when a function call budget is set.
+	FunctionCalls uint64 `json:"functionCalls,omitempty"`
+	// MemoryBytes is the size of the flight's memory once its execution completed.
+	MemoryBytes uint64 `json:"memoryBytes,omitempty"`
+	// Duration is the wall clock duration of the flight execution.
+	Duration metav1.Duration `json:"duration"`
+}
+
+// Module identifies the flight module evaluated for an Airway instance.
+// The ATC reports it under status.flight of Airway instances.
+type Module struct {
+	// URL is the location of the flight module.
+	URL string `json:"url"`
+	// Checksum is the sha256 checksum the module is verified against, if any.
+	Checksum string `json:"checksum,omitempty"`
+}
+
 type Conditions []metav1.Condition
 
 func (conditions Conditions) OpenAPISchema() *apiextensionsv1.JSONSchemaProps {
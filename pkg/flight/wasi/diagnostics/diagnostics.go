@@ -0,0 +1,45 @@
+// Package diagnostics allows flights to report warnings, informational messages and deprecation notices
+// about their evaluation without failing the release.
+//
+// Diagnostics are printed by the yoke CLI after takeoff, surfaced by the ATC as events and as the Diagnostics condition
+// of the flight's resource, and written to the stderr of the yokecd plugin for ArgoCD.
+package diagnostics
+
+import (
+	"errors"
+
+	"github.com/yokecd/yoke/internal/wasm"
+
+	// Make sure to include wasi as it contains necessary "malloc" export that will be needed
+	// for the host to allocate a wasm.Buffer. IE: any wasm module that uses this package exports wasi.malloc
+	_ "github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+type Severity string
+
+const (
+	SeverityInfo        Severity = "info"
+	SeverityWarning     Severity = "warning"
+	SeverityDeprecation Severity = "deprecation"
+)
+
+// Info reports an informational message.
+func Info(message string) error {
+	return Report(SeverityInfo, message)
+}
+
+// Warn reports a warning.
+func Warn(message string) error {
+	return Report(SeverityWarning, message)
+}
+
+// Deprecation reports the use of a deprecated feature of the flight, for example:
+//
+//	diagnostics.Deprecation("input field `replicas` is deprecated, use `scaling.min`")
+func Deprecation(message string) error {
+	return Report(SeverityDeprecation, message)
+}
+
+func errorMapping(_ wasm.State, buffer wasm.Buffer) error {
+	return errors.New(buffer.String())
+}
@@ -0,0 +1,26 @@
+//go:build wasip1
+
+package diagnostics
+
+import (
+	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+//go:wasmimport host report_diagnostic
+func report(ptr wasm.Ptr, severity, message wasm.String) wasm.Buffer
+
+// Report reports a diagnostic with the given severity.
+// A flight can report at most 100 diagnostics per evaluation.
+func Report(severity Severity, message string) error {
+	var state wasm.State
+
+	buffer := report(wasm.PtrTo(&state), wasm.FromString(string(severity)), wasm.FromString(message))
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return errorMapping(state, buffer)
+	}
+
+	return nil
+}
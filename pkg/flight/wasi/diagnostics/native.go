@@ -0,0 +1,15 @@
+//go:build !wasip1
+
+package diagnostics
+
+import (
+	"fmt"
+	"os"
+)
+
+// Report reports a diagnostic with the given severity.
+// Natively built flights are not evaluated by yoke: diagnostics are written to stderr.
+func Report(severity Severity, message string) error {
+	_, err := fmt.Fprintf(os.Stderr, "%s: %s\n", severity, message)
+	return err
+}
@@ -0,0 +1,49 @@
+// Package fetch allows flights to read data from http services.
+//
+// Flights run without network access. Requests are made by the host on behalf of the flight,
+// and only to the URLs allowed by the http access matchers of the flight's invocation.
+package fetch
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/yokecd/yoke/internal/wasm"
+
+	// Make sure to include wasi as it contains necessary "malloc" export that will be needed
+	// for the host to allocate a wasm.Buffer. IE: any wasm module that uses this package exports wasi.malloc
+	_ "github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+// Response is the response to a fetch. Responses with non 2xx status codes are not errors.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+var ErrorHTTPAccessNotGranted = errors.New("http access has not been granted for this flight invocation")
+
+type ErrorURLNotAllowed string
+
+func (err ErrorURLNotAllowed) Error() string { return string(err) }
+
+func (ErrorURLNotAllowed) Is(target error) bool {
+	_, ok := target.(ErrorURLNotAllowed)
+	return ok
+}
+
+func IsErrURLNotAllowed(err error) bool {
+	return errors.Is(err, ErrorURLNotAllowed(""))
+}
+
+func errorMapping(state wasm.State, buffer wasm.Buffer) error {
+	switch state {
+	case wasm.StateFeatureNotGranted:
+		return ErrorHTTPAccessNotGranted
+	case wasm.StateForbidden:
+		return ErrorURLNotAllowed(buffer.String())
+	default:
+		return errors.New(buffer.String())
+	}
+}
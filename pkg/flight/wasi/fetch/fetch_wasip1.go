@@ -0,0 +1,32 @@
+//go:build wasip1
+
+package fetch
+
+import (
+	"encoding/json"
+
+	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+//go:wasmimport host http_fetch
+func fetch(ptr wasm.Ptr, url wasm.String) wasm.Buffer
+
+// Get fetches the url with an http GET request.
+func Get(url string) (*Response, error) {
+	var state wasm.State
+
+	buffer := fetch(wasm.PtrTo(&state), wasm.FromString(url))
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return nil, errorMapping(state, buffer)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(buffer.Slice(), &resp); err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,30 @@
+//go:build !wasip1
+
+package fetch
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Get fetches the url with an http GET request.
+// Natively built flights have network access: requests are made directly and are not subject to http access matchers.
+func Get(url string) (*Response, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
+}
@@ -0,0 +1,176 @@
+//go:build !wasip1
+
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Fake is an in-memory cluster state used to resolve lookups of natively built flights.
+// See SetFake to register it, and LoadFixtures to build it from a directory.
+type Fake struct {
+	// Resources are the objects returned by lookups and lists.
+	Resources []*unstructured.Unstructured
+	// Mappings are returned by GetRestMapping. If no mapping matches, one is derived from the kinds of the Resources.
+	Mappings []RestMapping
+	// Revision is returned by PreviousRevision. If nil, the release has no previous revision.
+	Revision *Revision
+}
+
+var _ backend = new(Fake)
+
+// NewFake builds a fake from resources of any type that converts to an unstructured object, such as typed kubernetes resources.
+// Resources must have their apiVersion and kind set.
+func NewFake(resources ...any) (*Fake, error) {
+	fake := new(Fake)
+	for _, resource := range resources {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(resource)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert %T to unstructured: %w", resource, err)
+		}
+		fake.Resources = append(fake.Resources, &unstructured.Unstructured{Object: obj})
+	}
+	return fake, nil
+}
+
+// LoadFixtures builds a fake from every yaml or json document found in the files of dir and its subdirectories.
+func LoadFixtures(dir string) (*Fake, error) {
+	fake := new(Fake)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !slices.Contains([]string{".yaml", ".yml", ".json"}, filepath.Ext(path)) {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		decoder := yaml.NewYAMLOrJSONDecoder(file, 4096)
+		for {
+			var obj map[string]any
+			if err := decoder.Decode(&obj); err != nil {
+				if errors.Is(err, io.EOF) {
+					return nil
+				}
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			if len(obj) == 0 {
+				continue
+			}
+			fake.Resources = append(fake.Resources, &unstructured.Unstructured{Object: obj})
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return fake, nil
+}
+
+// Get returns a copy of the resource matching the identifier.
+func (fake *Fake) Get(identifier ResourceIdentifier) (*unstructured.Unstructured, error) {
+	for _, resource := range fake.Resources {
+		if resource.GetAPIVersion() != identifier.ApiVersion || resource.GetKind() != identifier.Kind || resource.GetName() != identifier.Name {
+			continue
+		}
+		// Like the cluster, an empty namespace resolves to the default namespace for namespaced resources.
+		if namespace := resource.GetNamespace(); namespace == identifier.Namespace || (identifier.Namespace == "" && namespace == "default") {
+			return resource.DeepCopy(), nil
+		}
+	}
+	return nil, ErrorNotFound(fmt.Sprintf("%s %q not found", identifier.Kind, identifier.Name))
+}
+
+// List returns copies of the resources matching the options.
+// Field selectors only support the metadata.name and metadata.namespace fields.
+func (fake *Fake) List(opts ListOptions) ([]unstructured.Unstructured, error) {
+	labelSelector, err := labels.Parse(opts.LabelSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid label selector: %w", err)
+	}
+
+	fieldSelector, err := fields.ParseSelector(opts.FieldSelector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid field selector: %w", err)
+	}
+
+	var items []unstructured.Unstructured
+	for _, resource := range fake.Resources {
+		if resource.GetAPIVersion() != opts.ApiVersion || resource.GetKind() != opts.Kind {
+			continue
+		}
+		if opts.Namespace != "" && resource.GetNamespace() != "" && resource.GetNamespace() != opts.Namespace {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(resource.GetLabels())) {
+			continue
+		}
+		if !fieldSelector.Matches(fields.Set{"metadata.name": resource.GetName(), "metadata.namespace": resource.GetNamespace()}) {
+			continue
+		}
+		items = append(items, *resource.DeepCopy())
+	}
+
+	return items, nil
+}
+
+// RestMapping returns the mapping of the kind within the group or apiVersion.
+func (fake *Fake) RestMapping(groupOrAPIVersion, kind string) (*RestMapping, error) {
+	group, version, _ := strings.Cut(groupOrAPIVersion, "/")
+
+	matches := func(gvk schema.GroupVersionKind) bool {
+		return gvk.Group == group && gvk.Kind == kind && (version == "" || gvk.Version == version)
+	}
+
+	for _, mapping := range fake.Mappings {
+		if matches(schema.GroupVersionKind{Group: mapping.Group, Version: mapping.Version, Kind: mapping.Kind}) {
+			return &mapping, nil
+		}
+	}
+
+	for _, resource := range fake.Resources {
+		gvk := resource.GroupVersionKind()
+		if !matches(gvk) {
+			continue
+		}
+		plural, _ := meta.UnsafeGuessKindToResource(gvk)
+		return &RestMapping{
+			Group:      gvk.Group,
+			Version:    gvk.Version,
+			Kind:       gvk.Kind,
+			Resource:   plural.Resource,
+			Namespaced: resource.GetNamespace() != "",
+		}, nil
+	}
+
+	return nil, ErrorNotFound(fmt.Sprintf("no matches for kind %q in group %q", kind, group))
+}
+
+// PreviousRevision returns the fake's Revision.
+func (fake *Fake) PreviousRevision() (*Revision, error) {
+	if fake.Revision == nil {
+		return nil, ErrorNotFound("release has no previous revision")
+	}
+	return fake.Revision, nil
+}
@@ -0,0 +1,128 @@
+//go:build !wasip1
+
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestFake(t *testing.T) {
+	fake, err := NewFake(
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", Labels: map[string]string{"team": "x"}},
+			Data:       map[string]string{"key": "a"},
+		},
+		&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "other", Labels: map[string]string{"team": "y"}},
+		},
+		&corev1.Node{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Node"},
+			ObjectMeta: metav1.ObjectMeta{Name: "node", Labels: map[string]string{"zone": "y"}},
+		},
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(SetFake(fake))
+
+	cm, err := Lookup[corev1.ConfigMap](ResourceIdentifier{Name: "a", Kind: "ConfigMap", ApiVersion: "v1"})
+	require.NoError(t, err)
+	require.Equal(t, "a", cm.Data["key"])
+
+	_, err = Lookup[corev1.ConfigMap](ResourceIdentifier{Name: "b", Namespace: "default", Kind: "ConfigMap", ApiVersion: "v1"})
+	require.True(t, IsErrNotFound(err))
+
+	configmaps, err := List[corev1.ConfigMap](ListOptions{Kind: "ConfigMap", ApiVersion: "v1", LabelSelector: "team in (x,y)"})
+	require.NoError(t, err)
+	require.Len(t, configmaps, 2)
+
+	configmaps, err = List[corev1.ConfigMap](ListOptions{Namespace: "other", Kind: "ConfigMap", ApiVersion: "v1"})
+	require.NoError(t, err)
+	require.Len(t, configmaps, 1)
+	require.Equal(t, "b", configmaps[0].Name)
+
+	nodes, err := List[corev1.Node](ListOptions{Namespace: "default", Kind: "Node", ApiVersion: "v1", LabelSelector: "zone=y", FieldSelector: "metadata.name=node"})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+
+	mapping, err := GetRestMapping("", "Node")
+	require.NoError(t, err)
+	require.Equal(t, RestMapping{Version: "v1", Kind: "Node", Resource: "nodes", Namespaced: false}, *mapping)
+
+	mapping, err = GetRestMapping("", "ConfigMap")
+	require.NoError(t, err)
+	require.True(t, mapping.Namespaced)
+
+	_, err = GetRestMapping("apps/v1", "Deployment")
+	require.True(t, IsErrNotFound(err))
+
+	_, err = PreviousRevision()
+	require.True(t, IsErrNotFound(err))
+}
+
+func TestPreviousRevision(t *testing.T) {
+	t.Setenv("YOKE_NAMESPACE", "default")
+
+	secret, err := NewFake(&corev1.Secret{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"},
+		ObjectMeta: metav1.ObjectMeta{Name: "creds", Namespace: "default"},
+		Data:       map[string][]byte{"password": []byte("hunter2")},
+	})
+	require.NoError(t, err)
+
+	t.Cleanup(SetFake(&Fake{
+		Revision: &Revision{
+			Name:   "yoke.revision",
+			Stages: [][]*unstructured.Unstructured{secret.Resources},
+		},
+	}))
+
+	revision, err := PreviousRevision()
+	require.NoError(t, err)
+	require.Len(t, revision.Resources(), 1)
+
+	creds, err := FromRevision[corev1.Secret](revision, ResourceIdentifier{Name: "creds", Kind: "Secret", ApiVersion: "v1"})
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", string(creds.Data["password"]))
+
+	_, err = FromRevision[corev1.Secret](revision, ResourceIdentifier{Name: "creds", Namespace: "other", Kind: "Secret", ApiVersion: "v1"})
+	require.True(t, IsErrNotFound(err))
+}
+
+func TestFixtures(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "resources.yaml"), []byte(`
+apiVersion: v1
+kind: Secret
+metadata:
+  name: creds
+  namespace: default
+stringData:
+  password: hunter2
+---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-x
+`), 0o644))
+
+	t.Setenv(FixturesEnv, dir)
+
+	secret, err := Lookup[corev1.Secret](ResourceIdentifier{Name: "creds", Namespace: "default", Kind: "Secret", ApiVersion: "v1"})
+	require.NoError(t, err)
+	require.Equal(t, "hunter2", secret.StringData["password"])
+
+	namespaces, err := List[corev1.Namespace](ListOptions{Kind: "Namespace", ApiVersion: "v1"})
+	require.NoError(t, err)
+	require.Len(t, namespaces, 1)
+}
@@ -1,15 +1,14 @@
 package k8s
 
 import (
-	"encoding/json"
 	"errors"
 
 	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight"
 
 	// Make sure to include wasi as it contains necessary "malloc" export that will be needed
 	// for the host to allocate a wasm.Buffer. IE: any wasm module that uses this package exports wasi.malloc
-	"github.com/yokecd/yoke/pkg/flight"
-	"github.com/yokecd/yoke/pkg/flight/wasi"
+	_ "github.com/yokecd/yoke/pkg/flight/wasi"
 )
 
 type ResourceIdentifier struct {
@@ -19,6 +18,16 @@ type ResourceIdentifier struct {
 	ApiVersion string
 }
 
+// ListOptions identifies the resources to list.
+// An empty Namespace lists namespaced resources across all namespaces.
+type ListOptions struct {
+	Namespace     string
+	Kind          string
+	ApiVersion    string
+	LabelSelector string
+	FieldSelector string
+}
+
 type object[T any] interface {
 	*T
 	flight.Resource
@@ -41,28 +50,6 @@ type RestMapping struct {
 	Namespaced bool
 }
 
-func GetRestMapping(groupOrAPIVersion, kind string) (*RestMapping, error) {
-	var state wasm.State
-
-	buffer := getRestMapping(
-		wasm.PtrTo(&state),
-		wasm.FromString(groupOrAPIVersion),
-		wasm.FromString(kind),
-	)
-	defer wasi.Free(buffer)
-
-	if state != wasm.StateOK {
-		return nil, errorMapping(state, buffer)
-	}
-
-	var mapping RestMapping
-	if err := json.Unmarshal(buffer.Slice(), &mapping); err != nil {
-		return nil, err
-	}
-
-	return &mapping, nil
-}
-
 func errorMapping(state wasm.State, buffer wasm.Buffer) error {
 	switch state {
 	case wasm.StateFeatureNotGranted:
@@ -0,0 +1,30 @@
+//go:build !wasip1
+
+package k8s
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func List[T any](opts ListOptions) ([]T, error) {
+	backend, err := getBackend()
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := backend.List(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]T, len(items))
+	for i, item := range items {
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &results[i]); err != nil {
+			return nil, fmt.Errorf("failed to convert to structured result: %w", err)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,40 @@
+//go:build wasip1
+
+package k8s
+
+import (
+	"encoding/json"
+
+	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+//go:wasmimport host k8s_list
+func list(ptr wasm.Ptr, namespace, kind, apiversion, labelSelector, fieldSelector wasm.String) wasm.Buffer
+
+// List returns the resources matching the label and field selectors of the options.
+// Resources not owned by the release are only returned if they are matched by the resource access matchers of the flight.
+func List[T any](opts ListOptions) ([]T, error) {
+	var state wasm.State
+
+	buffer := list(
+		wasm.PtrTo(&state),
+		wasm.FromString(opts.Namespace),
+		wasm.FromString(opts.Kind),
+		wasm.FromString(opts.ApiVersion),
+		wasm.FromString(opts.LabelSelector),
+		wasm.FromString(opts.FieldSelector),
+	)
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return nil, errorMapping(state, buffer)
+	}
+
+	var resources []T
+	if err := json.Unmarshal(buffer.Slice(), &resources); err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
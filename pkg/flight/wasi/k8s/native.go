@@ -0,0 +1,208 @@
+//go:build !wasip1
+
+package k8s
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/yokecd/yoke/internal/home"
+	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// FixturesEnv is the environment variable pointing to a directory of yaml or json fixtures.
+// When set, native builds resolve lookups against the fixtures instead of a cluster.
+const FixturesEnv = "YOKE_K8S_FIXTURES"
+
+// backend resolves cluster state for flights built natively, that is for any target other than wasip1.
+type backend interface {
+	Get(identifier ResourceIdentifier) (*unstructured.Unstructured, error)
+	List(opts ListOptions) ([]unstructured.Unstructured, error)
+	RestMapping(groupOrAPIVersion, kind string) (*RestMapping, error)
+	PreviousRevision() (*Revision, error)
+}
+
+var fake atomic.Pointer[Fake]
+
+// SetFake registers an in-memory fake used to resolve lookups, lists and rest mappings of natively built flights,
+// typically from unit tests. It returns a function that restores the previously registered fake.
+func SetFake(value *Fake) (restore func()) {
+	previous := fake.Swap(value)
+	return func() { fake.Store(previous) }
+}
+
+// getBackend returns the backend used by native builds.
+// A fake registered from test code takes precedence, followed by the fixtures directory.
+// Both must be opted into explicitly, whereas a kubeconfig is almost always present on a developer's machine.
+// Otherwise state is read from the cluster of the in-cluster config or kubeconfig, if cluster access is granted.
+func getBackend() (backend, error) {
+	if value := fake.Load(); value != nil {
+		return value, nil
+	}
+	if dir := os.Getenv(FixturesEnv); dir != "" {
+		fixtures, err := LoadFixtures(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load fixtures: %w", err)
+		}
+		return fixtures, nil
+	}
+	if clusterAccess, _ := strconv.ParseBool(os.Getenv("CLUSTER_ACCESS")); !clusterAccess {
+		return nil, ErrorClusterAccessNotGranted
+	}
+	client, err := getClient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubernetes client: %w", err)
+	}
+	return cluster{client}, nil
+}
+
+var getClient = sync.OnceValues(func() (client *k8s.Client, err error) {
+	cfg, err := rest.InClusterConfig()
+	if err == nil {
+		return k8s.NewClient(cfg, "default")
+	}
+	return k8s.NewClientFromKubeConfig(cmp.Or(os.Getenv("KUBECONFIG"), home.Kubeconfig))
+})
+
+type cluster struct {
+	client *k8s.Client
+}
+
+func (cluster cluster) Get(identifier ResourceIdentifier) (*unstructured.Unstructured, error) {
+	mapping, err := cluster.mapping(identifier.ApiVersion, identifier.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	intf := func() dynamic.ResourceInterface {
+		if mapping.Scope == meta.RESTScopeNamespace {
+			return cluster.client.Dynamic.Resource(mapping.Resource).Namespace(cmp.Or(identifier.Namespace, cluster.client.DefaultNamespace))
+		}
+		return cluster.client.Dynamic.Resource(mapping.Resource)
+	}()
+
+	obj, err := intf.Get(context.Background(), identifier.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	return obj, nil
+}
+
+func (cluster cluster) List(opts ListOptions) ([]unstructured.Unstructured, error) {
+	mapping, err := cluster.mapping(opts.ApiVersion, opts.Kind)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := opts.Namespace
+	if mapping.Scope != meta.RESTScopeNamespace {
+		namespace = ""
+	}
+
+	list, err := cluster.client.Dynamic.Resource(mapping.Resource).Namespace(namespace).List(context.Background(), metav1.ListOptions{
+		LabelSelector: opts.LabelSelector,
+		FieldSelector: opts.FieldSelector,
+	})
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	return list.Items, nil
+}
+
+func (cluster cluster) RestMapping(groupOrAPIVersion, kind string) (*RestMapping, error) {
+	group, version, _ := strings.Cut(groupOrAPIVersion, "/")
+
+	var versions []string
+	if version != "" {
+		versions = append(versions, version)
+	}
+
+	mapping, err := cluster.client.Mapper.RESTMapping(schema.GroupKind{Group: group, Kind: kind}, versions...)
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	return &RestMapping{
+		Group:      mapping.GroupVersionKind.Group,
+		Version:    mapping.GroupVersionKind.Version,
+		Kind:       mapping.GroupVersionKind.Kind,
+		Resource:   mapping.Resource.Resource,
+		Namespaced: mapping.Scope.Name() == meta.RESTScopeNameNamespace,
+	}, nil
+}
+
+// PreviousRevision reads the active revision of the release identified by the YOKE_RELEASE and YOKE_NAMESPACE
+// environment variables from the cluster.
+func (cluster cluster) PreviousRevision() (*Revision, error) {
+	ctx := context.Background()
+
+	release, err := cluster.client.GetRelease(ctx, os.Getenv("YOKE_RELEASE"), cmp.Or(flight.Namespace(), cluster.client.DefaultNamespace))
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	active := release.ActiveRevision()
+	if active.Name == "" {
+		return nil, ErrorNotFound("release has no previous revision")
+	}
+
+	stages, err := cluster.client.GetRevisionResources(ctx, active)
+	if err != nil {
+		return nil, apiError(err)
+	}
+
+	revision := &Revision{
+		Name:      active.Name,
+		Source:    RevisionSource(active.Source),
+		CreatedAt: active.CreatedAt,
+		ActiveAt:  active.ActiveAt,
+	}
+	for _, stage := range stages {
+		revision.Stages = append(revision.Stages, stage)
+	}
+
+	return revision, nil
+}
+
+func (cluster cluster) mapping(apiVersion, kind string) (*meta.RESTMapping, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse apiVerion: %w", err)
+	}
+	mapping, err := cluster.client.Mapper.RESTMapping(schema.GroupKind{Group: gv.Group, Kind: kind}, gv.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get resource mapping: %w", err)
+	}
+	return mapping, nil
+}
+
+// apiError maps api errors to the errors returned by the wasip1 implementation.
+func apiError(err error) error {
+	switch {
+	case kerrors.IsNotFound(err):
+		return ErrorNotFound(err.Error())
+	case kerrors.IsUnauthorized(err):
+		return ErrorUnauthenticated(err.Error())
+	case kerrors.IsForbidden(err):
+		return ErrorForbidden(err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !wasip1
+
+package k8s
+
+// PreviousRevision returns the active revision of the release.
+// If the release has never been successfully deployed, or the flight is not evaluated as part of a takeoff, an ErrorNotFound is returned.
+func PreviousRevision() (*Revision, error) {
+	backend, err := getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.PreviousRevision()
+}
@@ -0,0 +1,33 @@
+//go:build wasip1
+
+package k8s
+
+import (
+	"encoding/json"
+
+	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+//go:wasmimport host k8s_previous_revision
+func previousRevision(ptr wasm.Ptr) wasm.Buffer
+
+// PreviousRevision returns the active revision of the release.
+// If the release has never been successfully deployed, or the flight is not evaluated as part of a takeoff, an ErrorNotFound is returned.
+func PreviousRevision() (*Revision, error) {
+	var state wasm.State
+
+	buffer := previousRevision(wasm.PtrTo(&state))
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return nil, errorMapping(state, buffer)
+	}
+
+	var revision Revision
+	if err := json.Unmarshal(buffer.Slice(), &revision); err != nil {
+		return nil, err
+	}
+
+	return &revision, nil
+}
@@ -2,8 +2,10 @@
 
 package k8s
 
-import "github.com/yokecd/yoke/internal/wasm"
-
-func getRestMapping(ptr wasm.Ptr, groupOrAPIVersion, kind wasm.String) wasm.Buffer {
-	panic("mock getRestMapping not implemented: should be used in the context of wasip1")
+func GetRestMapping(groupOrAPIVersion, kind string) (*RestMapping, error) {
+	backend, err := getBackend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.RestMapping(groupOrAPIVersion, kind)
 }
@@ -2,7 +2,34 @@
 
 package k8s
 
-import "github.com/yokecd/yoke/internal/wasm"
+import (
+	"encoding/json"
+
+	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight/wasi"
+)
 
 //go:wasmimport host k8s_rest_mapping
 func getRestMapping(ptr wasm.Ptr, groupOrAPIVersion, kind wasm.String) wasm.Buffer
+
+func GetRestMapping(groupOrAPIVersion, kind string) (*RestMapping, error) {
+	var state wasm.State
+
+	buffer := getRestMapping(
+		wasm.PtrTo(&state),
+		wasm.FromString(groupOrAPIVersion),
+		wasm.FromString(kind),
+	)
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return nil, errorMapping(state, buffer)
+	}
+
+	var mapping RestMapping
+	if err := json.Unmarshal(buffer.Slice(), &mapping); err != nil {
+		return nil, err
+	}
+
+	return &mapping, nil
+}
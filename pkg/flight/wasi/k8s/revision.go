@@ -0,0 +1,63 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/yokecd/yoke/pkg/flight"
+)
+
+// Revision is the active revision of the release, that is the desired state deployed before the current takeoff.
+type Revision struct {
+	Name      string         `json:"name"`
+	Source    RevisionSource `json:"source"`
+	CreatedAt time.Time      `json:"createdAt"`
+	ActiveAt  time.Time      `json:"activeAt,omitzero"`
+	// Stages are the resources of the revision as stored by yoke, grouped by the stages in which they were applied.
+	Stages [][]*unstructured.Unstructured `json:"stages"`
+}
+
+type RevisionSource struct {
+	Ref      string `json:"ref"`
+	Checksum string `json:"checksum"`
+}
+
+// Resources returns the resources of every stage of the revision.
+func (revision Revision) Resources() []*unstructured.Unstructured {
+	var resources []*unstructured.Unstructured
+	for _, stage := range revision.Stages {
+		resources = append(resources, stage...)
+	}
+	return resources
+}
+
+// FromRevision returns the resource of the revision matching the identifier.
+// An empty namespace matches cluster scoped resources and resources of the release's namespace.
+// If no resource matches an ErrorNotFound is returned.
+func FromRevision[T any](revision *Revision, identifier ResourceIdentifier) (*T, error) {
+	for _, resource := range revision.Resources() {
+		if resource.GetAPIVersion() != identifier.ApiVersion || resource.GetKind() != identifier.Kind || resource.GetName() != identifier.Name {
+			continue
+		}
+		if namespace := resource.GetNamespace(); namespace != identifier.Namespace && (identifier.Namespace != "" || namespace != flight.Namespace()) {
+			continue
+		}
+
+		data, err := json.Marshal(resource)
+		if err != nil {
+			return nil, err
+		}
+
+		var result T
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, fmt.Errorf("failed to convert to structured result: %w", err)
+		}
+
+		return &result, nil
+	}
+
+	return nil, ErrorNotFound(fmt.Sprintf("%s %q not found in revision %s", identifier.Kind, identifier.Name, revision.Name))
+}
@@ -0,0 +1,43 @@
+// Package kv allows flights to persist small values between evaluations of their release,
+// such as allocated ports, generated seeds or migration markers.
+//
+// The state is scoped to the release and is stored with every revision of the release: modifications are persisted
+// by the revision created by the takeoff, and are discarded if the takeoff fails or creates no revision.
+// Descending to a previous revision restores the state of that revision, and mayday removes it with the release.
+package kv
+
+import (
+	"errors"
+
+	"github.com/yokecd/yoke/internal/wasm"
+
+	// Make sure to include wasi as it contains necessary "malloc" export that will be needed
+	// for the host to allocate a wasm.Buffer. IE: any wasm module that uses this package exports wasi.malloc
+	_ "github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+var ErrorStateNotAvailable = errors.New("release state is only available to flights evaluated as part of a takeoff")
+
+type ErrorKeyNotFound string
+
+func (err ErrorKeyNotFound) Error() string { return string(err) }
+
+func (ErrorKeyNotFound) Is(target error) bool {
+	_, ok := target.(ErrorKeyNotFound)
+	return ok
+}
+
+func IsErrKeyNotFound(err error) bool {
+	return errors.Is(err, ErrorKeyNotFound(""))
+}
+
+func errorMapping(state wasm.State, buffer wasm.Buffer) error {
+	switch state {
+	case wasm.StateFeatureNotGranted:
+		return ErrorStateNotAvailable
+	case wasm.StateNotFound:
+		return ErrorKeyNotFound(buffer.String())
+	default:
+		return errors.New(buffer.String())
+	}
+}
@@ -0,0 +1,59 @@
+//go:build wasip1
+
+package kv
+
+import (
+	"github.com/yokecd/yoke/internal/wasm"
+	"github.com/yokecd/yoke/pkg/flight/wasi"
+)
+
+//go:wasmimport host kv_get
+func get(ptr wasm.Ptr, key wasm.String) wasm.Buffer
+
+//go:wasmimport host kv_set
+func set(ptr wasm.Ptr, key, value wasm.String) wasm.Buffer
+
+//go:wasmimport host kv_delete
+func del(ptr wasm.Ptr, key wasm.String) wasm.Buffer
+
+// Get returns the value of key. If the key is not set, an ErrorKeyNotFound is returned.
+func Get(key string) (string, error) {
+	var state wasm.State
+
+	buffer := get(wasm.PtrTo(&state), wasm.FromString(key))
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return "", errorMapping(state, buffer)
+	}
+
+	return buffer.String(), nil
+}
+
+// Set sets key to value.
+func Set(key, value string) error {
+	var state wasm.State
+
+	buffer := set(wasm.PtrTo(&state), wasm.FromString(key), wasm.FromString(value))
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return errorMapping(state, buffer)
+	}
+
+	return nil
+}
+
+// Delete removes key. Deleting a key that is not set is not an error.
+func Delete(key string) error {
+	var state wasm.State
+
+	buffer := del(wasm.PtrTo(&state), wasm.FromString(key))
+	defer wasi.Free(buffer)
+
+	if state != wasm.StateOK {
+		return errorMapping(state, buffer)
+	}
+
+	return nil
+}
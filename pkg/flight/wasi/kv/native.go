@@ -0,0 +1,41 @@
+//go:build !wasip1
+
+package kv
+
+import "sync"
+
+// Natively built flights do not run as part of a takeoff. Their state lives in process memory and is not persisted.
+var (
+	mutex  sync.Mutex
+	values = map[string]string{}
+)
+
+// Get returns the value of key. If the key is not set, an ErrorKeyNotFound is returned.
+func Get(key string) (string, error) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	value, ok := values[key]
+	if !ok {
+		return "", ErrorKeyNotFound("key not found: " + key)
+	}
+	return value, nil
+}
+
+// Set sets key to value.
+func Set(key, value string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	values[key] = value
+	return nil
+}
+
+// Delete removes key. Deleting a key that is not set is not an error.
+func Delete(key string) error {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	delete(values, key)
+	return nil
+}
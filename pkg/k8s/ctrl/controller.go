@@ -16,16 +16,23 @@ import (
 	"github.com/davidmdm/x/xruntime"
 	"github.com/davidmdm/x/xsync"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic/dynamicinformer"
 	kcache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 
 	"github.com/yokecd/yoke/internal"
 	internalk8s "github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/metrics"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/pkg/k8s"
 )
 
@@ -81,10 +88,17 @@ type Params struct {
 	Client      *k8s.Client
 	Logger      *slog.Logger
 	Concurrency int
+
+	// Recorder emits kubernetes events on behalf of the controller's handlers. If nil, events are discarded.
+	Recorder record.EventRecorder
 }
 
 func NewController(params Params) *Instance {
 	params.Concurrency = max(params.Concurrency, 1)
+	if params.Recorder == nil {
+		// The FakeRecorder drops events when it has no channel to send them to.
+		params.Recorder = new(record.FakeRecorder)
+	}
 	return &Instance{
 		Params: params,
 		events: NewQueue[Event](params.Concurrency),
@@ -97,6 +111,18 @@ type Entry struct {
 	Forwarders []schema.GroupKind
 	Funcs      Funcs
 	Filter     func(event Event) bool
+
+	// Scope restricts the resources watched by the informers of the group kind.
+	// Unlike Filter, resources out of scope are never listed and are not held in memory.
+	Scope Scope
+}
+
+// Scope restricts the resources watched for a group kind. The zero value watches every resource.
+type Scope struct {
+	// Namespaces restricts namespaced resources to the given namespaces. It has no effect on cluster scoped resources.
+	Namespaces []string
+	// Selector restricts resources to those matching the label selector.
+	Selector labels.Selector
 }
 
 func (instance *Instance) Register(entries ...Entry) error {
@@ -117,11 +143,21 @@ func (instance *Instance) register(entry Entry) error {
 		return fmt.Errorf("failed to get rest mapping: %w", err)
 	}
 
-	factory := dynamicinformer.NewDynamicSharedInformerFactory(instance.Client.Dynamic, 0)
+	namespaces := []string{metav1.NamespaceAll}
+	if len(entry.Scope.Namespaces) > 0 && mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		namespaces = entry.Scope.Namespaces
+	}
 
-	genericInformer := factory.ForResource(mapping.Resource)
-	informer := genericInformer.Informer()
-	lister := genericInformer.Lister()
+	tweakListOptions := func(opts *metav1.ListOptions) {
+		if entry.Scope.Selector != nil && !entry.Scope.Selector.Empty() {
+			opts.LabelSelector = entry.Scope.Selector.String()
+		}
+	}
+
+	var (
+		factories []dynamicinformer.DynamicSharedInformerFactory
+		lister    = scopedLister{resource: mapping.Resource.GroupResource(), listers: map[string]kcache.GenericLister{}}
+	)
 
 	var resourceMap xsync.Set[Event]
 
@@ -159,10 +195,23 @@ func (instance *Instance) register(entry Entry) error {
 		UpdateFunc: informerUpdateHandler,
 	}
 
-	if _, err := informer.AddEventHandler(eventHandlers); err != nil {
-		return fmt.Errorf("failed to add event handlers: %w", err)
+	// Informers cannot watch a set of namespaces, so a factory is created per namespace in scope.
+	for _, namespace := range namespaces {
+		factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(instance.Client.Dynamic, 0, namespace, tweakListOptions)
+
+		genericInformer := factory.ForResource(mapping.Resource)
+		if _, err := genericInformer.Informer().AddEventHandler(eventHandlers); err != nil {
+			return fmt.Errorf("failed to add event handlers: %w", err)
+		}
+
+		lister.listers[namespace] = genericInformer.Lister()
+		factories = append(factories, factory)
 	}
 
+	// Forwarded resources are not necessarily within the scope of the group kind, and are watched by their own factory.
+	forwarders := dynamicinformer.NewDynamicSharedInformerFactory(instance.Client.Dynamic, 0)
+	factories = append(factories, forwarders)
+
 	for _, forward := range entry.Forwarders {
 		mapping, err := instance.Client.Mapper.RESTMapping(forward)
 		if err != nil {
@@ -181,7 +230,7 @@ func (instance *Instance) register(entry Entry) error {
 			}
 		}
 
-		if _, err := factory.ForResource(mapping.Resource).Informer().AddEventHandler(kcache.ResourceEventHandlerFuncs{
+		if _, err := forwarders.ForResource(mapping.Resource).Informer().AddEventHandler(kcache.ResourceEventHandlerFuncs{
 			AddFunc:    requeue,
 			UpdateFunc: func(_ any, obj any) { requeue(obj) },
 			DeleteFunc: requeue,
@@ -192,7 +241,9 @@ func (instance *Instance) register(entry Entry) error {
 
 	done := make(chan struct{})
 
-	factory.Start(done)
+	for _, factory := range factories {
+		factory.Start(done)
+	}
 
 	instance.gks.Store(
 		entry.GroupKind,
@@ -200,7 +251,9 @@ func (instance *Instance) register(entry Entry) error {
 			handler: entry.Funcs.Handler,
 			shutdown: sync.OnceFunc(func() {
 				close(done)
-				factory.Shutdown()
+				for _, factory := range factories {
+					factory.Shutdown()
+				}
 				instance.gks.Delete(entry.GroupKind)
 				if teardown := entry.Funcs.Teardown; teardown != nil {
 					teardown()
@@ -228,6 +281,8 @@ func (instance *Instance) Run(ctx context.Context) error {
 
 	defer instance.events.Stop()
 
+	metrics.SetQueueDepthFunc(instance.events.Len)
+
 	var (
 		wg     sync.WaitGroup
 		active xsync.Map[string, *atomic.Uint32]
@@ -290,14 +345,33 @@ func (instance *Instance) Run(ctx context.Context) error {
 						ctx = context.WithValue(ctx, loggerKey{}, logger)
 						ctx = context.WithValue(ctx, clientKey{}, instance.Client)
 						ctx = context.WithValue(ctx, instanceKey{}, instance)
+						ctx = context.WithValue(ctx, recorderKey{}, instance.Recorder)
 						ctx = internal.WithStdio(ctx, io.Discard, io.Discard, internal.Stdin(ctx))
 
 						logger.Info("processing event")
 
+						ctx, span := tracing.Start(
+							ctx,
+							"reconcile",
+							attribute.String("groupKind", event.GroupKind.String()),
+							attribute.String("name", event.Name),
+							attribute.String("namespace", event.Namespace),
+							attribute.Int("attempt", event.meta.attempts),
+						)
+
 						start := time.Now()
 
 						result, err := safe(state.handler)(ctx, event)
 
+						tracing.End(span, err)
+
+						gk := event.GroupKind.String()
+						metrics.Reconciles.WithLabelValues(gk).Inc()
+						metrics.ReconcileDuration.WithLabelValues(gk).Observe(time.Since(start).Seconds())
+						if err != nil {
+							metrics.ReconcileErrors.WithLabelValues(gk).Inc()
+						}
+
 						shouldRequeue := result.Requeue || result.RequeueAfter > 0 || (err != nil && !errors.Is(err, terminalError{}))
 
 						if shouldRequeue {
@@ -305,6 +379,7 @@ func (instance *Instance) Run(ctx context.Context) error {
 								result.RequeueAfter = withJitter(min(time.Duration(powInt(2, event.meta.attempts))*time.Second, 15*time.Minute), 0.10)
 							}
 							logger = logger.With(slog.String("requeueAfter", result.RequeueAfter.String()))
+							metrics.Requeues.WithLabelValues(gk).Inc()
 							timers.Store(event.String(), time.AfterFunc(result.RequeueAfter, func() {
 								if err != nil {
 									event.meta.attempts++
@@ -337,6 +412,21 @@ func (instance *Instance) IsListeningForGK(gk schema.GroupKind) bool {
 	return ok
 }
 
+// InScope reports whether the resource identified by the event is held by the informers of its group kind.
+// It is false if the group kind is not registered, or if the resource does not exist or is out of the scope of its entry.
+func (instance *Instance) InScope(evt Event) bool {
+	state, ok := instance.gks.Load(evt.GroupKind)
+	if !ok {
+		return false
+	}
+	if evt.Namespace != "" {
+		_, err := state.lister.ByNamespace(evt.Namespace).Get(evt.Name)
+		return err == nil
+	}
+	_, err := state.lister.Get(evt.Name)
+	return err == nil
+}
+
 func (instance *Instance) SendEvent(evt Event) {
 	instance.events.Enqueue(evt)
 }
@@ -378,6 +468,17 @@ func Client(ctx context.Context) *k8s.Client {
 	return client
 }
 
+type recorderKey struct{}
+
+// Recorder returns the event recorder of the controller. Events are discarded if the controller was not configured with a recorder.
+func Recorder(ctx context.Context) record.EventRecorder {
+	recorder, _ := ctx.Value(recorderKey{}).(record.EventRecorder)
+	if recorder == nil {
+		return new(record.FakeRecorder)
+	}
+	return recorder
+}
+
 type instanceKey struct{}
 
 func Inst(ctx context.Context) *Instance {
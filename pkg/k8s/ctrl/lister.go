@@ -0,0 +1,68 @@
+package ctrl
+
+import (
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kcache "k8s.io/client-go/tools/cache"
+)
+
+// scopedLister is a GenericLister over the listers of every namespace in the scope of an entry.
+// Listers are keyed by namespace, metav1.NamespaceAll being used for an unscoped or cluster scoped group kind.
+type scopedLister struct {
+	resource schema.GroupResource
+	listers  map[string]kcache.GenericLister
+}
+
+var _ kcache.GenericLister = scopedLister{}
+
+func (lister scopedLister) List(selector labels.Selector) ([]runtime.Object, error) {
+	var result []runtime.Object
+	for _, l := range lister.listers {
+		objects, err := l.List(selector)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, objects...)
+	}
+	return result, nil
+}
+
+func (lister scopedLister) Get(name string) (runtime.Object, error) {
+	if namespace, name, ok := strings.Cut(name, "/"); ok {
+		return lister.ByNamespace(namespace).Get(name)
+	}
+	for _, l := range lister.listers {
+		if obj, err := l.Get(name); err == nil {
+			return obj, nil
+		}
+	}
+	return nil, kerrors.NewNotFound(lister.resource, name)
+}
+
+func (lister scopedLister) ByNamespace(namespace string) kcache.GenericNamespaceLister {
+	if l, ok := lister.listers[namespace]; ok {
+		return l.ByNamespace(namespace)
+	}
+	if l, ok := lister.listers[metav1.NamespaceAll]; ok {
+		return l.ByNamespace(namespace)
+	}
+	return emptyNamespaceLister{resource: lister.resource}
+}
+
+// emptyNamespaceLister is returned for namespaces out of scope.
+type emptyNamespaceLister struct {
+	resource schema.GroupResource
+}
+
+func (emptyNamespaceLister) List(labels.Selector) ([]runtime.Object, error) {
+	return nil, nil
+}
+
+func (lister emptyNamespaceLister) Get(name string) (runtime.Object, error) {
+	return nil, kerrors.NewNotFound(lister.resource, name)
+}
@@ -0,0 +1,57 @@
+package ctrl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kcache "k8s.io/client-go/tools/cache"
+)
+
+func TestScopedLister(t *testing.T) {
+	resource := schema.GroupResource{Group: "yoke.cd", Resource: "flights"}
+
+	listerFor := func(namespace string, names ...string) kcache.GenericLister {
+		indexer := kcache.NewIndexer(kcache.MetaNamespaceKeyFunc, kcache.Indexers{kcache.NamespaceIndex: kcache.MetaNamespaceIndexFunc})
+		for _, name := range names {
+			obj := new(unstructured.Unstructured)
+			obj.SetName(name)
+			obj.SetNamespace(namespace)
+			require.NoError(t, indexer.Add(obj))
+		}
+		return kcache.NewGenericLister(indexer, resource)
+	}
+
+	lister := scopedLister{
+		resource: resource,
+		listers: map[string]kcache.GenericLister{
+			"foo": listerFor("foo", "a", "b"),
+			"bar": listerFor("bar", "c"),
+		},
+	}
+
+	objects, err := lister.List(labels.Everything())
+	require.NoError(t, err)
+	require.Len(t, objects, 3)
+
+	obj, err := lister.ByNamespace("bar").Get("c")
+	require.NoError(t, err)
+	require.Equal(t, "c", obj.(*unstructured.Unstructured).GetName())
+
+	_, err = lister.Get("foo/a")
+	require.NoError(t, err)
+
+	_, err = lister.ByNamespace("foo").Get("c")
+	require.True(t, kerrors.IsNotFound(err))
+
+	_, err = lister.ByNamespace("baz").Get("a")
+	require.True(t, kerrors.IsNotFound(err))
+
+	objects, err = lister.ByNamespace("baz").List(labels.Everything())
+	require.NoError(t, err)
+	require.Empty(t, objects)
+}
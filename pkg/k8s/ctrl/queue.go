@@ -99,6 +99,14 @@ func NewQueue[T fmt.Stringer](concurrency int) *Queue[T] {
 	return &queue
 }
 
+// Len returns the number of values waiting to be pulled from the queue.
+func (queue *Queue[T]) Len() int {
+	queue.lock.Lock()
+	defer queue.lock.Unlock()
+
+	return len(queue.buffer) + len(queue.pipe)
+}
+
 func (queue *Queue[T]) Pull() <-chan T {
 	queue.semaphore <- struct{}{}
 	return queue.C
@@ -1,6 +1,7 @@
 package yoke
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"fmt"
@@ -15,9 +16,13 @@ import (
 
 	"github.com/davidmdm/x/xerr"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/k8s"
 	"github.com/yokecd/yoke/internal/oci"
+	"github.com/yokecd/yoke/internal/text"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/wasi/host"
 )
@@ -33,6 +38,9 @@ func LoadWasm(ctx context.Context, params *FlightParams) (err error) {
 	}
 	defer internal.DebugTimer(ctx, "load wasm")()
 
+	ctx, span := tracing.Start(ctx, "load wasm", attribute.String("url", params.Path))
+	defer func() { tracing.End(span, err) }()
+
 	params.Wasm, err = LoadWasmFromURL(ctx, params.Path, params.Insecure)
 	return
 }
@@ -119,18 +127,23 @@ func gzipReader(r io.Reader) io.Reader {
 
 type ClusterAccessParams = host.ClusterAccessParams
 
+type HTTPAccessParams = host.HTTPAccessParams
+
 type EvalParams struct {
 	Client        *k8s.Client
 	Release       string
 	Namespace     string
 	ClusterAccess ClusterAccessParams
+	HTTPAccess    HTTPAccessParams
 	Flight        FlightParams
 }
 
-func EvalFlight(ctx context.Context, params EvalParams) ([]byte, error) {
+func EvalFlight(ctx context.Context, params EvalParams) (output []byte, err error) {
+	ctx, span := tracing.Start(ctx, "eval flight", attribute.String("release", params.Release), attribute.String("namespace", params.Namespace))
+	defer func() { tracing.End(span, err) }()
+
 	if params.Flight.Input != nil && params.Flight.Path == "" && params.Flight.Module.Instance == nil && len(params.Flight.Wasm) == 0 {
-		output, err := io.ReadAll(params.Flight.Input)
-		return output, err
+		return io.ReadAll(params.Flight.Input)
 	}
 
 	if err := LoadWasm(ctx, &params.Flight); err != nil {
@@ -151,8 +164,9 @@ func EvalFlight(ctx context.Context, params EvalParams) ([]byte, error) {
 
 	ctx = host.WithOwner(ctx, internal.OwnerFrom(params.Release, params.Namespace))
 	ctx = host.WithClusterAccess(ctx, params.ClusterAccess)
+	ctx = host.WithHTTPAccess(ctx, params.HTTPAccess)
 
-	output, err := wasi.Execute(ctx, wasi.ExecParams{
+	return wasi.Execute(ctx, wasi.ExecParams{
 		Module:  params.Flight.Module.Instance,
 		BinName: params.Release,
 		Stdin:   params.Flight.Input,
@@ -160,13 +174,53 @@ func EvalFlight(ctx context.Context, params EvalParams) ([]byte, error) {
 		Args:    params.Flight.Args,
 		Timeout: params.Flight.Timeout,
 		Env:     env,
+
+		Deterministic:    params.Flight.Deterministic,
+		MaxFunctionCalls: params.Flight.MaxFunctionCalls,
+
 		CompileParams: wasi.CompileParams{
 			Wasm:            params.Flight.Wasm,
 			CacheDir:        params.Flight.CompilationCacheDir,
-			HostFunctionMap: host.BuildFunctionMap(params.Client),
+			HostFunctionMap: host.BuildFunctionMap(host.ClientBackends(params.Client)),
 			MaxMemoryMib:    uint32(params.Flight.MaxMemoryMib),
 		},
 	})
+}
+
+// verifyDeterminism evaluates the flight again and fails if its output differs from the output of a previous evaluation.
+// The flight input of params must be unread.
+func verifyDeterminism(ctx context.Context, params EvalParams, expected []byte) error {
+	// The state must not carry the modifications of the previous evaluation,
+	// and the diagnostics of the previous evaluation must not be reported twice.
+	ctx = host.WithReleaseState(ctx)
+	ctx = host.WithDiagnostics(ctx)
+
+	actual, err := EvalFlight(ctx, params)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate flight to verify determinism: %w", err)
+	}
+
+	if bytes.Equal(expected, actual) {
+		return nil
+	}
+
+	toYamlFile := func(name string, output []byte) (text.File, error) {
+		stages, err := internal.ParseStages(output)
+		if err != nil {
+			return text.File{Name: name, Content: string(output)}, nil
+		}
+		return text.ToYamlFile(name, internal.CanonicalObjectMap(stages.Flatten()))
+	}
+
+	first, err := toYamlFile("first", expected)
+	if err != nil {
+		return err
+	}
+
+	second, err := toYamlFile("second", actual)
+	if err != nil {
+		return err
+	}
 
-	return output, err
+	return fmt.Errorf("flight is not deterministic: consecutive evaluations produced different outputs:\n%s", text.Diff(first, second, 4))
 }
@@ -0,0 +1,160 @@
+package yoke
+
+import (
+	"context"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/yokecd/yoke/internal/wasi"
+	"github.com/yokecd/yoke/internal/x"
+)
+
+func TestEvalFlightDeterministic(t *testing.T) {
+	const modDir = "./test_output/random"
+	require.NoError(t, os.RemoveAll(modDir))
+	require.NoError(t, os.MkdirAll(modDir, 0o755))
+
+	require.NoError(t, x.X("go mod init temp", x.Dir(modDir)))
+	require.NoError(t, os.WriteFile(path.Join(modDir, "main.go"), []byte(`package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"io"
+	"os"
+	"time"
+)
+
+func main() {
+	input, _ := io.ReadAll(os.Stdin)
+	json.NewEncoder(os.Stdout).Encode(map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"name": "random"},
+		"data": map[string]string{
+			"input":  string(input),
+			"random": rand.Text(),
+			"time":   time.Now().String(),
+		},
+	})
+}`), 0o644))
+
+	require.NoError(t, x.X("go build -o ./main.wasm ./main.go", x.Env("GOOS=wasip1", "GOARCH=wasm"), x.Dir(modDir)))
+
+	wasm, err := os.ReadFile(path.Join(modDir, "main.wasm"))
+	require.NoError(t, err)
+
+	eval := func(release, input string, deterministic bool) EvalParams {
+		return EvalParams{
+			Release:   release,
+			Namespace: "default",
+			Flight: FlightParams{
+				Wasm:          wasm,
+				Input:         strings.NewReader(input),
+				Deterministic: deterministic,
+			},
+		}
+	}
+
+	ctx := context.Background()
+
+	first, err := EvalFlight(ctx, eval("foo", "input", true))
+	require.NoError(t, err)
+
+	second, err := EvalFlight(ctx, eval("foo", "input", true))
+	require.NoError(t, err)
+	require.Equal(t, string(first), string(second))
+
+	require.NoError(t, verifyDeterminism(ctx, eval("foo", "input", true), first))
+
+	other, err := EvalFlight(ctx, eval("foo", "other", true))
+	require.NoError(t, err)
+	require.NotEqual(t, string(first), string(other))
+
+	other, err = EvalFlight(ctx, eval("bar", "input", true))
+	require.NoError(t, err)
+	require.NotEqual(t, string(first), string(other))
+
+	nondeterministic, err := EvalFlight(ctx, eval("foo", "input", false))
+	require.NoError(t, err)
+
+	require.ErrorContains(t, verifyDeterminism(ctx, eval("foo", "input", false), nondeterministic), "flight is not deterministic")
+}
+
+func TestEvalFlightFunctionCalls(t *testing.T) {
+	const modDir = "./test_output/function_calls"
+	require.NoError(t, os.RemoveAll(modDir))
+	require.NoError(t, os.MkdirAll(modDir, 0o755))
+
+	require.NoError(t, x.X("go mod init temp", x.Dir(modDir)))
+	require.NoError(t, os.WriteFile(path.Join(modDir, "main.go"), []byte(`package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+//go:noinline
+func step(n int) int { return n + 1 }
+
+func main() {
+	forever := slices.Contains(os.Args, "forever")
+	n := 0
+	for forever || n < 1000 {
+		n = step(n)
+	}
+	fmt.Println("[]")
+}`), 0o644))
+
+	require.NoError(t, x.X("go build -o ./main.wasm ./main.go", x.Env("GOOS=wasip1", "GOARCH=wasm"), x.Dir(modDir)))
+
+	wasm, err := os.ReadFile(path.Join(modDir, "main.wasm"))
+	require.NoError(t, err)
+
+	eval := func(args ...string) EvalParams {
+		return EvalParams{
+			Release:   "function-calls",
+			Namespace: "default",
+			Flight: FlightParams{
+				Wasm:             wasm,
+				Args:             args,
+				MaxFunctionCalls: 1_000_000,
+			},
+		}
+	}
+
+	ctx := wasi.WithExecStats(context.Background())
+
+	output, err := EvalFlight(ctx, eval())
+	require.NoError(t, err)
+	require.Equal(t, "[]\n", string(output))
+
+	stats, ok := wasi.GetExecStats(ctx)
+	require.True(t, ok)
+	require.Greater(t, stats.FunctionCalls, uint64(1000))
+	require.Less(t, stats.FunctionCalls, uint64(1_000_000))
+	require.NotZero(t, stats.MemoryBytes)
+	require.NotZero(t, stats.Duration)
+
+	_, err = EvalFlight(ctx, eval("forever"))
+	require.ErrorIs(t, err, wasi.ErrFunctionCallsExceeded)
+
+	stats, _ = wasi.GetExecStats(ctx)
+	require.Greater(t, stats.FunctionCalls, uint64(1_000_000))
+}
+
+func TestEvalFlightComponent(t *testing.T) {
+	component := []byte("\x00asm\x0d\x00\x01\x00")
+
+	_, err := EvalFlight(context.Background(), EvalParams{
+		Release:   "component",
+		Namespace: "default",
+		Flight:    FlightParams{Wasm: component},
+	})
+	require.ErrorIs(t, err, wasi.ErrComponentNotSupported)
+}
@@ -5,7 +5,9 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
 	"reflect"
+	"slices"
 	"time"
 
 	"github.com/davidmdm/x/xerr"
@@ -19,7 +21,8 @@ import (
 )
 
 type Commander struct {
-	k8s *k8s.Client
+	k8s   *k8s.Client
+	store ReleaseStore
 }
 
 func FromKubeConfigFlags(flags *genericclioptions.ConfigFlags) (*Commander, error) {
@@ -27,7 +30,7 @@ func FromKubeConfigFlags(flags *genericclioptions.ConfigFlags) (*Commander, erro
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize k8s client: %w", err)
 	}
-	return &Commander{client}, nil
+	return &Commander{k8s: client, store: client}, nil
 }
 
 func FromKubeConfig(path string) (*Commander, error) {
@@ -35,11 +38,11 @@ func FromKubeConfig(path string) (*Commander, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize k8s client: %w", err)
 	}
-	return &Commander{client}, nil
+	return &Commander{k8s: client, store: client}, nil
 }
 
 func FromK8Client(client *k8s.Client) *Commander {
-	return &Commander{client}
+	return &Commander{k8s: client, store: client}
 }
 
 type DescentParams struct {
@@ -67,17 +70,17 @@ func (commander Commander) Descent(ctx context.Context, params DescentParams) (e
 
 	targetNS := cmp.Or(params.Namespace, commander.k8s.DefaultNamespace)
 
-	release, err := commander.k8s.GetRelease(ctx, params.Release, targetNS)
+	release, err := commander.store.GetRelease(ctx, params.Release, targetNS)
 	if err != nil {
 		return fmt.Errorf("failed to get revisions for release %q: %w", params.Release, err)
 	}
 
 	if params.Lock {
-		if err := commander.k8s.LockRelease(ctx, *release); err != nil {
+		if err := commander.store.LockRelease(ctx, *release); err != nil {
 			return fmt.Errorf("failed to aquire release lock: %w", err)
 		}
 		defer func() {
-			if unlockErr := commander.k8s.UnlockRelease(ctx, *release); unlockErr != nil {
+			if unlockErr := commander.store.UnlockRelease(ctx, *release); unlockErr != nil {
 				err = xerr.Join(err, fmt.Errorf("failed to unlock release: %w", unlockErr))
 			}
 		}()
@@ -92,13 +95,16 @@ func (commander Commander) Descent(ctx context.Context, params DescentParams) (e
 	}
 
 	targetRevision := release.History[params.RevisionID-1]
+	if targetRevision.Failed() {
+		return fmt.Errorf("cannot descend to revision %d: revision failed at stage %d", params.RevisionID, targetRevision.FailedStage)
+	}
 
-	next, err := commander.k8s.GetRevisionResources(ctx, targetRevision)
+	next, err := commander.store.GetRevisionResources(ctx, targetRevision)
 	if err != nil {
 		return fmt.Errorf("failed to lookup target revision resources: %w", err)
 	}
 
-	previous, err := commander.k8s.GetRevisionResources(ctx, release.ActiveRevision())
+	previous, err := commander.store.GetRevisionResources(ctx, release.ActiveRevision())
 	if err != nil {
 		return fmt.Errorf("failed to lookup current revision resources: %w", err)
 	}
@@ -119,7 +125,7 @@ func (commander Commander) Descent(ctx context.Context, params DescentParams) (e
 		}
 	}
 
-	if err := commander.k8s.UpdateRevisionActiveState(ctx, targetRevision); err != nil {
+	if err := commander.store.UpdateRevisionActiveState(ctx, targetRevision); err != nil {
 		return fmt.Errorf("failed to update revision history: %w", err)
 	}
 
@@ -145,7 +151,7 @@ func (commander Commander) Mayday(ctx context.Context, params MaydayParams) erro
 
 	targetNS := cmp.Or(params.Namespace, commander.k8s.DefaultNamespace)
 
-	release, err := commander.k8s.GetRelease(ctx, params.Release, targetNS)
+	release, err := commander.store.GetRelease(ctx, params.Release, targetNS)
 	if err != nil {
 		return fmt.Errorf("failed to get revision history for release: %w", err)
 	}
@@ -154,18 +160,23 @@ func (commander Commander) Mayday(ctx context.Context, params MaydayParams) erro
 		return internal.Warningf("mayday noop: no history found for release %q in namespace %q", params.Release, targetNS)
 	}
 
-	stages, err := commander.k8s.GetRevisionResources(ctx, release.ActiveRevision())
+	stages, err := commander.store.GetRevisionResources(ctx, release.ActiveRevision())
 	if err != nil {
 		return fmt.Errorf("failed to get resources for current revision: %w", err)
 	}
 
-	if _, _, err := commander.k8s.PruneReleaseDiff(ctx, stages, nil, params.PruneOpts); err != nil {
+	attempted, err := commander.failedAttemptResources(ctx, *release)
+	if err != nil {
+		return fmt.Errorf("failed to get resources for failed revisions: %w", err)
+	}
+
+	if _, _, err := commander.k8s.PruneReleaseDiff(ctx, append(stages, attempted...), nil, params.PruneOpts); err != nil {
 		return fmt.Errorf("failed to delete resources: %w", err)
 	}
 
 	fmt.Fprintf(internal.Stderr(ctx), "Removed %d resource(s)...\n\n", len(stages.Flatten()))
 
-	if err := commander.k8s.DeleteRevisions(ctx, *release); err != nil {
+	if err := commander.store.DeleteRevisions(ctx, *release); err != nil {
 		return fmt.Errorf("failed to delete revision history: %w", err)
 	}
 
@@ -174,6 +185,26 @@ func (commander Commander) Mayday(ctx context.Context, params MaydayParams) erro
 	return nil
 }
 
+// failedAttemptResources returns the stages of failed revisions attempted after the release's active revision.
+// Resources created by failed takeoffs are not part of any successful revision but may still exist in the cluster.
+func (commander Commander) failedAttemptResources(ctx context.Context, release internal.Release) (internal.Stages, error) {
+	active := release.ActiveRevision()
+
+	var result internal.Stages
+	for _, revision := range release.History {
+		if !revision.Failed() || revision.CreatedAt.Before(active.ActiveAt) {
+			continue
+		}
+		stages, err := commander.store.GetRevisionResources(ctx, revision)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", revision.Name, err)
+		}
+		result = append(result, stages...)
+	}
+
+	return result, nil
+}
+
 type TurbulenceParams struct {
 	Namespace     string
 	Release       string
@@ -182,6 +213,28 @@ type TurbulenceParams struct {
 	Fix           bool
 	Color         bool
 	Silent        bool
+
+	// OutputFormat writes a TurbulenceReport in the given structured format instead of a unified diff.
+	// Has no effect if Fix is true.
+	OutputFormat OutputFormat
+}
+
+type OutputFormat = internal.OutputFormat
+
+// ResourceDrift is the difference between the desired state of a resource in the active revision and its state in the cluster.
+type ResourceDrift struct {
+	// Resource is the canonical name of the resource.
+	Resource string         `json:"resource"`
+	Expected map[string]any `json:"expected"`
+	Actual   map[string]any `json:"actual"`
+	Drifted  bool           `json:"drifted"`
+}
+
+// TurbulenceReport is the structured output of turbulence for a release.
+type TurbulenceReport struct {
+	Release   string          `json:"release"`
+	Namespace string          `json:"namespace"`
+	Resources []ResourceDrift `json:"resources"`
 }
 
 func (commander Commander) Turbulence(ctx context.Context, params TurbulenceParams) error {
@@ -193,7 +246,7 @@ func (commander Commander) Turbulence(ctx context.Context, params TurbulencePara
 		ctx = internal.WithStderr(ctx, io.Discard)
 	}
 
-	release, err := commander.k8s.GetRelease(ctx, params.Release, targetNS)
+	release, err := commander.store.GetRelease(ctx, params.Release, targetNS)
 	if err != nil {
 		return fmt.Errorf("failed to get revisions for release %s: %w", params.Release, err)
 	}
@@ -202,7 +255,7 @@ func (commander Commander) Turbulence(ctx context.Context, params TurbulencePara
 		return fmt.Errorf("release not found for %q in namespace %q", params.Release, targetNS)
 	}
 
-	stages, err := commander.k8s.GetRevisionResources(ctx, release.ActiveRevision())
+	stages, err := commander.store.GetRevisionResources(ctx, release.ActiveRevision())
 	if err != nil {
 		return fmt.Errorf("failed to get current resources: %w", err)
 	}
@@ -248,6 +301,26 @@ func (commander Commander) Turbulence(ctx context.Context, params TurbulencePara
 		return xerr.MultiErrOrderedFrom("failed to apply desired state to drift", errs...)
 	}
 
+	if params.OutputFormat.Structured() {
+		report := TurbulenceReport{
+			Release:   params.Release,
+			Namespace: targetNS,
+			Resources: []ResourceDrift{},
+		}
+		for _, name := range slices.Sorted(maps.Keys(expected)) {
+			drift := ResourceDrift{
+				Resource: name,
+				Expected: expected[name].Object,
+				Drifted:  !reflect.DeepEqual(expected[name], actual[name]),
+			}
+			if value := actual[name]; value != nil {
+				drift.Actual = value.Object
+			}
+			report.Resources = append(report.Resources, drift)
+		}
+		return internal.WriteOutput(internal.Stdout(ctx), params.OutputFormat, report)
+	}
+
 	expectedFile, err := text.ToYamlFile("expected", expected)
 	if err != nil {
 		return fmt.Errorf("failed to encode expected state to yaml: %w", err)
@@ -281,7 +354,7 @@ type UnlockParams struct {
 }
 
 func (commander Commander) UnlockRelease(ctx context.Context, params UnlockParams) error {
-	return commander.k8s.UnlockRelease(ctx, internal.Release{
+	return commander.store.UnlockRelease(ctx, internal.Release{
 		Name:      params.Release,
 		Namespace: cmp.Or(params.Namespace, commander.k8s.DefaultNamespace),
 	})
@@ -0,0 +1,242 @@
+package yoke
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+
+	"github.com/yokecd/yoke/internal"
+	"github.com/yokecd/yoke/internal/k8s"
+	"github.com/yokecd/yoke/internal/text"
+)
+
+// ResourceDiff is the server-side difference between the live state of a resource and the state it would have after takeoff.
+type ResourceDiff struct {
+	// Resource is the canonical name of the resource.
+	Resource string `json:"resource"`
+
+	// Live is the current state of the resource in the cluster. It is nil if the resource does not exist yet.
+	Live map[string]any `json:"live,omitempty"`
+
+	// Next is the state returned by applying the resource with server-side apply in dry-run mode.
+	// If the dry-run failed, Next is the desired state as output by the flight.
+	Next map[string]any `json:"next,omitempty"`
+
+	// Error is set if the resource could not be applied in dry-run mode.
+	// This is commonly due to resources depending on resources from previous stages that do not exist yet.
+	Error string `json:"error,omitempty"`
+}
+
+// OwnershipChange describes a resource that exists in the cluster but would be taken over by the release.
+type OwnershipChange struct {
+	Resource string `json:"resource"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// ServerSideDiffResult is the structured result of diffing a release against the live state of the cluster.
+type ServerSideDiffResult struct {
+	Resources []ResourceDiff `json:"resources"`
+
+	// Pruned lists the resources of the active revision that would be removed.
+	Pruned []string `json:"pruned"`
+
+	// Orphaned lists the CRDs and namespaces of the active revision that would no longer be part of the release
+	// but that are not removed since the corresponding prune options are not set.
+	Orphaned []string `json:"orphaned"`
+
+	// Ownership lists the resources whose owning release would change.
+	Ownership []OwnershipChange `json:"ownership"`
+}
+
+// ResourceChange is a resource that differs between two sets of resources.
+type ResourceChange struct {
+	// Resource is the canonical name of the resource.
+	Resource string `json:"resource"`
+
+	// From is the previous state of the resource. It is nil if the resource was added.
+	From any `json:"from,omitempty"`
+
+	// To is the next state of the resource. It is nil if the resource was removed.
+	To any `json:"to,omitempty"`
+}
+
+// DiffResources returns the resources that were added, removed, or modified between from and to, sorted by name.
+// The maps are keyed by canonical resource name as returned by internal.CanonicalObjectMap.
+func DiffResources(from, to map[string]any) []ResourceChange {
+	names := slices.Collect(maps.Keys(from))
+	for name := range to {
+		if _, ok := from[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	changes := []ResourceChange{}
+	for _, name := range names {
+		previous, next := from[name], to[name]
+		if reflect.DeepEqual(previous, next) {
+			continue
+		}
+		changes = append(changes, ResourceChange{Resource: name, From: previous, To: next})
+	}
+	return changes
+}
+
+// serverSideDiffIgnoredProps are properties set by the api-server that change on every apply and would only add noise to the diff.
+var serverSideDiffIgnoredProps = [][]string{
+	{"metadata", "generation"},
+	{"metadata", "resourceVersion"},
+	{"metadata", "managedFields"},
+	{"metadata", "creationTimestamp"},
+	{"metadata", "uid"},
+	{"status"},
+}
+
+func (commander Commander) serverSideDiff(ctx context.Context, previous, next internal.Stages, params TakeoffParams) (*ServerSideDiffResult, error) {
+	defer internal.DebugTimer(ctx, "server-side diff of "+params.Release)()
+
+	var result ServerSideDiffResult
+
+	for _, resource := range next.Flatten() {
+		name := internal.Canonical(resource)
+		diff := ResourceDiff{Resource: name}
+
+		live, err := commander.k8s.GetInClusterState(ctx, resource)
+		if err != nil && !kerrors.IsNotFound(err) {
+			// The resource mapping may not exist yet. For example if the CRD is part of the same release.
+			diff.Error = err.Error()
+			diff.Next = internal.DropProperties(resource, serverSideDiffIgnoredProps).Object
+			result.Resources = append(result.Resources, diff)
+			continue
+		}
+
+		if live != nil && err == nil {
+			if from, to := internal.GetOwner(live), internal.GetOwner(resource); from != to {
+				result.Ownership = append(result.Ownership, OwnershipChange{Resource: name, From: from, To: to})
+			}
+			diff.Live = internal.DropProperties(live, serverSideDiffIgnoredProps).Object
+		}
+
+		// Ownership changes are reported as part of the result instead of failing the dry-run.
+		dryRun, err := commander.k8s.DryRunApplyResource(ctx, resource, k8s.ApplyOpts{
+			ForceConflicts: params.ForceConflicts,
+			ForceOwnership: true,
+		})
+		if err != nil {
+			diff.Error = err.Error()
+			dryRun = resource
+		}
+
+		diff.Next = internal.DropProperties(dryRun, serverSideDiffIgnoredProps).Object
+
+		result.Resources = append(result.Resources, diff)
+	}
+
+	desired := map[string]struct{}{}
+	for _, resource := range next.Flatten() {
+		desired[internal.CanonicalWithoutVersion(resource)] = struct{}{}
+	}
+
+	for _, resource := range previous.Flatten() {
+		if _, ok := desired[internal.CanonicalWithoutVersion(resource)]; ok {
+			continue
+		}
+		if (!params.RemoveCRDs && internal.IsCRD(resource)) || (!params.RemoveNamespaces && internal.IsNamespace(resource)) {
+			result.Orphaned = append(result.Orphaned, internal.Canonical(resource))
+			continue
+		}
+
+		live, err := commander.k8s.GetInClusterState(ctx, resource)
+		if err != nil && !kerrors.IsNotFound(err) {
+			return nil, fmt.Errorf("failed to lookup %s: %w", internal.Canonical(resource), err)
+		}
+
+		// Like PruneReleaseDiff, resources that no longer exist or that are owned by another release are left alone.
+		if internal.GetOwner(live) != internal.GetOwner(resource) {
+			continue
+		}
+
+		result.Pruned = append(result.Pruned, internal.Canonical(resource))
+	}
+
+	slices.Sort(result.Pruned)
+	slices.Sort(result.Orphaned)
+
+	return &result, nil
+}
+
+func writeServerSideDiff(w io.Writer, result ServerSideDiffResult, params TakeoffParams) error {
+	live := map[string]any{}
+	next := map[string]any{}
+	for _, diff := range result.Resources {
+		if diff.Live != nil {
+			live[diff.Resource] = diff.Live
+		}
+		next[diff.Resource] = diff.Next
+	}
+
+	a, err := text.ToYamlFile("live", live)
+	if err != nil {
+		return err
+	}
+
+	b, err := text.ToYamlFile("dry-run", next)
+	if err != nil {
+		return err
+	}
+
+	differ := func() text.DiffFunc {
+		if params.Color {
+			return text.DiffColorized
+		}
+		return text.Diff
+	}()
+
+	var output strings.Builder
+
+	output.WriteString(differ(a, b, params.Context))
+
+	section := func(title string, lines []string) {
+		if len(lines) == 0 {
+			return
+		}
+		if output.Len() > 0 {
+			output.WriteString("\n")
+		}
+		output.WriteString(title + ":\n")
+		for _, line := range lines {
+			output.WriteString("  - " + line + "\n")
+		}
+	}
+
+	section("resources to be pruned", result.Pruned)
+	section("resources to be orphaned", result.Orphaned)
+
+	section("resources changing ownership", func() []string {
+		lines := make([]string, len(result.Ownership))
+		for i, change := range result.Ownership {
+			lines[i] = fmt.Sprintf("%s: %q -> %q", change.Resource, change.From, change.To)
+		}
+		return lines
+	}())
+
+	section("resources that failed dry-run (showing desired state)", func() []string {
+		var lines []string
+		for _, diff := range result.Resources {
+			if diff.Error != "" {
+				lines = append(lines, diff.Resource+": "+diff.Error)
+			}
+		}
+		return lines
+	}())
+
+	_, err = io.WriteString(w, output.String())
+	return err
+}
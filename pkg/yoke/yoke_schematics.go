@@ -72,7 +72,7 @@ func GetSchematic(ctx context.Context, params GetSchematicParams) ([]byte, error
 			output, err := wasi.Execute(ctx, wasi.ExecParams{
 				BinName:       "schematics",
 				Args:          args,
-				CompileParams: wasi.CompileParams{Wasm: wasm, HostFunctionMap: host.BuildFunctionMap(nil)},
+				CompileParams: wasi.CompileParams{Wasm: wasm, HostFunctionMap: host.BuildFunctionMap(host.Backends{})},
 			})
 			if err != nil {
 				return nil, fmt.Errorf("failed to execute schematics: %s: %w", key, err)
@@ -0,0 +1,52 @@
+package yoke
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/yokecd/yoke/internal/k8s"
+)
+
+// ReleaseStore persists the revision history of releases.
+type ReleaseStore = k8s.ReleaseStore
+
+const (
+	ReleaseStoreSecret        = "secret"
+	ReleaseStoreChunkedSecret = "chunked-secret"
+
+	// ReleaseStoreFilePrefix prefixes the directory of a filesystem release store, for example: file://./history
+	ReleaseStoreFilePrefix = "file://"
+)
+
+// WithReleaseStore returns a commander that reads and writes release history using the given store.
+// By default release history is stored as Secrets in the namespace of the release.
+func (commander Commander) WithReleaseStore(store ReleaseStore) *Commander {
+	commander.store = store
+	return &commander
+}
+
+// ReleaseStore returns the store used by the commander to read and write release history.
+func (commander Commander) ReleaseStore() ReleaseStore {
+	return commander.store
+}
+
+// ParseReleaseStore returns the release store described by value. The value must be one of:
+//   - "" or "secret": the default store. Every revision is a single gzipped Secret.
+//   - "chunked-secret": revisions are split across as many Secrets as needed to stay under the Secret size limit.
+//   - "file://<dir>": revisions are stored in a local directory.
+func (commander Commander) ParseReleaseStore(value string) (ReleaseStore, error) {
+	switch {
+	case value == "" || value == ReleaseStoreSecret:
+		return commander.k8s, nil
+	case value == ReleaseStoreChunkedSecret:
+		return k8s.NewChunkedSecretStore(commander.k8s, k8s.DefaultChunkSize), nil
+	case strings.HasPrefix(value, ReleaseStoreFilePrefix):
+		dir := strings.TrimPrefix(value, ReleaseStoreFilePrefix)
+		if dir == "" {
+			return nil, fmt.Errorf("release store directory is required: %s<dir>", ReleaseStoreFilePrefix)
+		}
+		return k8s.NewFileSystemStore(dir), nil
+	default:
+		return nil, fmt.Errorf("unknown release store %q: must be one of %s, %s, or %s<dir>", value, ReleaseStoreSecret, ReleaseStoreChunkedSecret, ReleaseStoreFilePrefix)
+	}
+}
@@ -1,22 +1,29 @@
 package yoke
 
 import (
+	"bytes"
 	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
 	"os"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/davidmdm/x/xerr"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -26,6 +33,7 @@ import (
 	"github.com/yokecd/yoke/internal"
 	"github.com/yokecd/yoke/internal/k8s"
 	"github.com/yokecd/yoke/internal/text"
+	"github.com/yokecd/yoke/internal/tracing"
 	"github.com/yokecd/yoke/internal/wasi"
 	"github.com/yokecd/yoke/internal/wasi/host"
 	"github.com/yokecd/yoke/internal/xcrypto"
@@ -55,10 +63,20 @@ type FlightParams struct {
 	// Running without timeouts is not recommended but you do you.
 	Timeout time.Duration
 
+	// MaxFunctionCalls is the maximum number of wasm function calls a flight can execute before it is terminated.
+	// Unlike Timeout it does not depend on the load of the machine running the flight. If zero, function calls are not limited.
+	// Enforcing a function call budget requires compiling the flight with metering which slows down its execution.
+	MaxFunctionCalls uint64
+
 	// Env specifies user-defined envvars to be added to the flight execution.
 	// Standard yoke envvars will take precendence.
 	Env map[string]string
 
+	// Deterministic runs the flight without access to sources of non-determinism.
+	// Randomness is seeded from the release name and a hash of the input, and the wall clock is fixed to the unix epoch.
+	// Identical inputs produce identical outputs, which avoids spurious revisions and diffs.
+	Deterministic bool
+
 	// Stderr is the writer that will be exposed to the wasm module as os.Stderr.
 	// If not provided all stderr writes in the wasm module will be buffered instead
 	// and surfaced to the user only on error exit codes.
@@ -114,6 +132,16 @@ type TakeoffParams struct {
 	// Output diffs with ansi colors.
 	Color bool
 
+	// ServerSideDiff diffs the live state of each resource against the result of applying it with server-side apply in dry-run mode,
+	// instead of diffing the active revision against the next flight output. This accounts for defaulting, mutating webhooks,
+	// and fields owned by other field managers. Resources that would be pruned or change ownership are listed as well.
+	// Has no effect if DiffOnly is false.
+	ServerSideDiff bool
+
+	// OutputFormat writes the diff as structured data in the given format instead of a unified diff.
+	// Has no effect if DiffOnly is false.
+	OutputFormat OutputFormat
+
 	// Create namespace of target release if not exists.
 	CreateNamespace bool
 
@@ -131,6 +159,9 @@ type TakeoffParams struct {
 	// This includes enabling/disabling cluster-access and granting any external resource matchers.
 	ClusterAccess ClusterAccessParams
 
+	// HTTPAccess grants the flight access to the host http_fetch function for URLs matching its URLMatchers.
+	HTTPAccess HTTPAccessParams
+
 	// HistoryCapSize limits the number of revisions kept in the release's history by the size. If Cap is less than 1 history is uncapped.
 	HistoryCapSize int
 
@@ -181,11 +212,22 @@ type TakeoffParams struct {
 	// These configmaps have labels "resource.yoke.cd/readiness in (lua,conditions)" and allow you to define the status conditions,
 	// or a custom lua script to define readiness for a given GroupKind. This allows you to define readiness for resources that yoke does not know about.
 	LoadCustomReadiness bool
+
+	// Atomic rolls the release back to its previous state if any stage fails to apply or become ready.
+	// The previous active revision's stages are re-applied and any resources created by the failed attempt are pruned.
+	// If the release has no previous revision, everything applied by the failed attempt is removed.
+	Atomic bool
+
+	// VerifyDeterminism evaluates the flight twice and fails if the outputs differ.
+	VerifyDeterminism bool
 }
 
 func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (err error) {
 	defer internal.DebugTimer(ctx, "takeoff of "+params.Release)()
 
+	ctx, span := tracing.Start(ctx, "takeoff", attribute.String("release", params.Release), attribute.String("namespace", params.Namespace))
+	defer func() { tracing.End(span, err) }()
+
 	if params.LoadCustomReadiness {
 		readiness, err := commander.k8s.LoadCustomReadinessFuncs(ctx)
 		if err != nil {
@@ -195,6 +237,7 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 	}
 
 	targetNS := cmp.Or(params.Namespace, commander.k8s.DefaultNamespace)
+	fullReleaseName := params.ReleasePrefix + params.Release
 
 	if err := LoadWasm(ctx, &params.Flight); err != nil {
 		return fmt.Errorf("failed to load wasm: %w", err)
@@ -226,16 +269,35 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		}
 	}
 
-	output, err := EvalFlight(
-		ctx,
-		EvalParams{
-			Client:        commander.k8s,
-			Release:       params.Release,
-			Namespace:     targetNS,
-			ClusterAccess: params.ClusterAccess,
-			Flight:        params.Flight,
-		},
-	)
+	// Flights may read the active revision of their release, for example to preserve values generated by previous revisions.
+	ctx = host.WithRelease(ctx, host.ReleaseParams{
+		Store:     commander.store,
+		Name:      fullReleaseName,
+		Namespace: targetNS,
+	})
+
+	// Flights may persist key/value state that is stored with the revision created by the takeoff.
+	ctx = host.WithReleaseState(ctx)
+
+	// The input is consumed by the evaluation and must be buffered to be evaluated twice.
+	var input []byte
+	if params.VerifyDeterminism && params.Flight.Input != nil {
+		if input, err = io.ReadAll(params.Flight.Input); err != nil {
+			return fmt.Errorf("failed to read flight input: %w", err)
+		}
+		params.Flight.Input = bytes.NewReader(input)
+	}
+
+	evalParams := EvalParams{
+		Client:        commander.k8s,
+		Release:       params.Release,
+		Namespace:     targetNS,
+		ClusterAccess: params.ClusterAccess,
+		HTTPAccess:    params.HTTPAccess,
+		Flight:        params.Flight,
+	}
+
+	output, err := EvalFlight(ctx, evalParams)
 	if err != nil {
 		// The calling process may wish to capture the identity resource and act on it.
 		// Hence even though we have an error we need to evaluate the output against the identity function before returning said error.
@@ -252,6 +314,15 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		return fmt.Errorf("failed to takeoff: resource provided is either empty or invalid")
 	}
 
+	if params.VerifyDeterminism {
+		if input != nil {
+			evalParams.Flight.Input = bytes.NewReader(input)
+		}
+		if err := verifyDeterminism(ctx, evalParams, output); err != nil {
+			return err
+		}
+	}
+
 	if params.SendToStdout {
 		_, err = internal.Stdout(ctx).Write(output)
 		return err
@@ -335,15 +406,34 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 	dropUndesiredMetaProps(stages.Flatten())
 
 	if params.DiffOnly {
-		release, err := commander.k8s.GetRelease(ctx, params.Release, targetNS)
+		release, err := commander.store.GetRelease(ctx, fullReleaseName, targetNS)
 		if err != nil {
 			return fmt.Errorf("failed to get revision history: %w", err)
 		}
-		current, err := commander.k8s.GetRevisionResources(ctx, release.ActiveRevision())
+		current, err := commander.store.GetRevisionResources(ctx, release.ActiveRevision())
 		if err != nil {
 			return fmt.Errorf("failed to get current resources for revision: %w", err)
 		}
 
+		if params.ServerSideDiff {
+			result, err := commander.serverSideDiff(ctx, current, stages, params)
+			if err != nil {
+				return fmt.Errorf("failed to compute server-side diff: %w", err)
+			}
+			if params.OutputFormat.Structured() {
+				return internal.WriteOutput(internal.Stdout(ctx), params.OutputFormat, result)
+			}
+			return writeServerSideDiff(internal.Stdout(ctx), *result, params)
+		}
+
+		if params.OutputFormat.Structured() {
+			changes := DiffResources(
+				internal.CanonicalObjectMap(current.Flatten()),
+				internal.CanonicalObjectMap(stages.Flatten()),
+			)
+			return internal.WriteOutput(internal.Stdout(ctx), params.OutputFormat, changes)
+		}
+
 		a, err := text.ToYamlFile("current", internal.CanonicalObjectMap(current.Flatten()))
 		if err != nil {
 			return err
@@ -374,8 +464,6 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		}
 	}
 
-	fullReleaseName := params.ReleasePrefix + params.Release
-
 	source := func() internal.Source {
 		if params.Flight.Module.Instance != nil {
 			return params.Flight.Module.SourceMetadata
@@ -383,7 +471,7 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		return internal.SourceFrom(params.Flight.Path, params.Flight.Wasm)
 	}()
 
-	release, err := commander.k8s.GetRelease(ctx, fullReleaseName, targetNS)
+	release, err := commander.store.GetRelease(ctx, fullReleaseName, targetNS)
 	if err != nil {
 		return fmt.Errorf("failed to get revision history for release %q: %w", params.Release, err)
 	}
@@ -397,11 +485,11 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 	}
 
 	if !params.DryRun && params.Lock {
-		if err := commander.k8s.LockRelease(ctx, *release); err != nil {
+		if err := commander.store.LockRelease(ctx, *release); err != nil {
 			return fmt.Errorf("failed to lock release: %w", err)
 		}
 		defer func() {
-			if unlockErr := commander.k8s.UnlockRelease(ctx, *release); unlockErr != nil {
+			if unlockErr := commander.store.UnlockRelease(ctx, *release); unlockErr != nil {
 				err = xerr.Join(err, fmt.Errorf("failed to unlock release: %w", unlockErr))
 			}
 		}()
@@ -411,12 +499,22 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		if len(release.History) == 0 {
 			return nil, nil
 		}
-		return commander.k8s.GetRevisionResources(ctx, release.ActiveRevision())
+		return commander.store.GetRevisionResources(ctx, release.ActiveRevision())
 	}()
 	if err != nil {
 		return fmt.Errorf("failed to get previous resources for revision: %w", err)
 	}
 
+	previousState, err := commander.store.GetRevisionState(ctx, release.ActiveRevision())
+	if err != nil {
+		return fmt.Errorf("failed to get previous state for revision: %w", err)
+	}
+
+	state, err := host.GetReleaseState(ctx).Values(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get release state: %w", err)
+	}
+
 	applyOpts := k8s.ApplyResourcesOpts{
 		ApplyOpts: k8s.ApplyOpts{
 			DryRun:         params.DryRun,
@@ -426,14 +524,79 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		SkipDryRun: params.SkipDryRun,
 	}
 
+	// Resources taken over from other owners must survive a rollback: they did not belong to this release before the attempt.
+	var adopted map[string]bool
+	if params.Atomic && params.ForceOwnership && !params.DryRun {
+		if adopted, err = commander.foreignResources(ctx, stages); err != nil {
+			return fmt.Errorf("failed to lookup ownership of resources: %w", err)
+		}
+	}
+
+	// fail records the failed attempt in the release history and rolls back the release if the takeoff is atomic.
+	// If the first stage failed its dry-run, nothing was applied to the cluster and there is nothing to record.
+	// A failure identical to the latest revision is not recorded again, such that retries of the same failing takeoff,
+	// as performed by the ATC, do not evict the successful revisions from a capped history.
+	fail := func(stage int, cause error) error {
+		if params.DryRun || (stage == 0 && errors.As(cause, new(k8s.DryRunError))) {
+			return cause
+		}
+
+		failure := internal.Revision{
+			Source:      source,
+			CreatedAt:   time.Now(),
+			Resources:   len(stages.Flatten()),
+			Status:      internal.RevisionStatusFailed,
+			Error:       truncate(cause.Error(), maxRevisionErrorLength),
+			FailedStage: stage + 1,
+		}
+
+		repeated := func() bool {
+			if len(release.History) == 0 {
+				return false
+			}
+			latest := release.History[len(release.History)-1]
+			if !latest.Failed() || latest.Source != failure.Source || latest.FailedStage != failure.FailedStage || latest.Error != failure.Error {
+				return false
+			}
+			resources, err := commander.store.GetRevisionResources(ctx, latest)
+			return err == nil && reflect.DeepEqual(resources, stages)
+		}()
+
+		if !repeated {
+			if err := commander.store.CreateRevision(ctx, fullReleaseName, targetNS, failure, stages, nil); err != nil {
+				cause = xerr.Join(cause, fmt.Errorf("failed to record failed revision: %w", err))
+			} else if params.HistoryCapSize > 0 {
+				if err := commander.store.CapReleaseHistory(ctx, fullReleaseName, targetNS, params.HistoryCapSize); err != nil {
+					cause = xerr.Join(cause, fmt.Errorf("failed to cap release history: %w", err))
+				}
+			}
+		}
+
+		if params.Atomic {
+			attempted := make(internal.Stages, stage+1)
+			for i, resources := range stages[:stage+1] {
+				attempted[i] = slices.DeleteFunc(slices.Clone(resources), func(resource *unstructured.Unstructured) bool {
+					return adopted[internal.CanonicalWithoutVersion(resource)]
+				})
+			}
+			return commander.rollback(ctx, cause, previous, attempted, params)
+		}
+
+		return cause
+	}
+
 	for i, stage := range stages {
+		ctx, span := tracing.Start(ctx, "apply stage", attribute.Int("stage", i+1), attribute.Int("resources", len(stage)))
+
 		if err := commander.k8s.ApplyResources(ctx, stage, applyOpts); err != nil {
-			return fmt.Errorf("failed to apply resources: %w", err)
+			tracing.End(span, err)
+			return fail(i, fmt.Errorf("failed to apply resources: %w", err))
 		}
 
 		if params.DryRun {
 			// If we are running in dry-run mode, we are not actually applying resources
 			// so we do not want to wait for them to become ready.
+			span.End()
 			continue
 		}
 
@@ -451,9 +614,16 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 
 		if waitOpts.Timeout > 0 {
 			if err := commander.k8s.WaitForReadyMany(ctx, stage, waitOpts); err != nil {
-				return fmt.Errorf("release did not become ready within wait period: to rollback use `yoke descent`: %w", err)
+				tracing.End(span, err)
+				hint := ""
+				if !params.Atomic {
+					hint = ": to rollback use `yoke descent`"
+				}
+				return fail(i, fmt.Errorf("release did not become ready within wait period%s: %w", hint, err))
 			}
 		}
+
+		span.End()
 	}
 
 	if params.DryRun {
@@ -461,32 +631,55 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 		return nil
 	}
 
-	if reflect.DeepEqual(previous, stages) {
+	attempted, err := commander.failedAttemptResources(ctx, *release)
+	if err != nil {
+		return fmt.Errorf("failed to get resources for failed revisions: %w", err)
+	}
+
+	if reflect.DeepEqual(previous, stages) && maps.Equal(previousState, state) {
+		// Re-applying the active revision after failed attempts still needs to remove the resources those attempts created.
+		// No new revision is required as the active revision already describes the release.
+		if len(attempted) > 0 {
+			removed, _, err := commander.k8s.PruneReleaseDiff(ctx, attempted, stages, params.PruneOpts)
+			if err != nil {
+				return fmt.Errorf("failed to prune resources of failed attempts: %w", err)
+			}
+			if len(removed) > 0 {
+				fmt.Fprintf(internal.Stderr(ctx), "pruned %d resource(s) of failed attempts of %s\n", len(removed), params.Release)
+				return nil
+			}
+		}
 		return internal.Noopf("resources are the same as previous revision: skipping creation of new revision")
 	}
 
-	now := time.Now()
-	if err := commander.k8s.CreateRevision(
-		ctx,
-		fullReleaseName,
-		targetNS,
-		internal.Revision{
-			Source:    source,
-			CreatedAt: now,
-			ActiveAt:  now,
-			Resources: len(stages.Flatten()),
-		},
-		stages,
-	); err != nil {
+	if err := func() (err error) {
+		ctx, span := tracing.Start(ctx, "create revision")
+		defer func() { tracing.End(span, err) }()
+
+		now := time.Now()
+		return commander.store.CreateRevision(
+			ctx,
+			fullReleaseName,
+			targetNS,
+			internal.Revision{
+				Source:    source,
+				CreatedAt: now,
+				ActiveAt:  now,
+				Resources: len(stages.Flatten()),
+			},
+			stages,
+			state,
+		)
+	}(); err != nil {
 		return fmt.Errorf("failed to create revision: %w", err)
 	}
 
-	if _, _, err := commander.k8s.PruneReleaseDiff(ctx, previous, stages, params.PruneOpts); err != nil {
+	if _, _, err := commander.k8s.PruneReleaseDiff(ctx, append(previous, attempted...), stages, params.PruneOpts); err != nil {
 		return fmt.Errorf("failed to prune release diff: %w", err)
 	}
 
 	if params.HistoryCapSize > 0 {
-		if err := commander.k8s.CapReleaseHistory(ctx, fullReleaseName, targetNS, params.HistoryCapSize); err != nil {
+		if err := commander.store.CapReleaseHistory(ctx, fullReleaseName, targetNS, params.HistoryCapSize); err != nil {
 			return internal.Warningf("failed to cap release history after successful takeoff of %s: %v", params.Release, err)
 		}
 	}
@@ -500,6 +693,84 @@ func (commander Commander) Takeoff(ctx context.Context, params TakeoffParams) (e
 	return nil
 }
 
+// rollback restores the release to its previous stages after a failed takeoff attempt.
+// The previous stages are re-applied and any resource from the attempted stages that is not part of the previous stages is pruned.
+// The previous stages are applied with the same conflict and ownership options as the attempt that is being rolled back.
+// The cause is always returned, either annotated with the rollback result or joined with the rollback error.
+func (commander Commander) rollback(ctx context.Context, cause error, previous, attempted internal.Stages, params TakeoffParams) error {
+	defer internal.DebugTimer(ctx, "rollback of "+params.Release)()
+
+	applyOpts := k8s.ApplyResourcesOpts{
+		ApplyOpts: k8s.ApplyOpts{
+			ForceConflicts: params.ForceConflicts,
+			ForceOwnership: params.ForceOwnership,
+		},
+		SkipDryRun: true,
+	}
+
+	err := func() error {
+		for _, stage := range previous {
+			if err := commander.k8s.ApplyResources(ctx, stage, applyOpts); err != nil {
+				return fmt.Errorf("failed to apply previous resources: %w", err)
+			}
+			if params.Wait > 0 {
+				if err := commander.k8s.WaitForReadyMany(ctx, stage, k8s.WaitOptions{Timeout: params.Wait, Interval: params.Poll}); err != nil {
+					return fmt.Errorf("previous resources did not become ready within wait period: %w", err)
+				}
+			}
+		}
+		if _, _, err := commander.k8s.PruneReleaseDiff(ctx, attempted, previous, params.PruneOpts); err != nil {
+			return fmt.Errorf("failed to prune attempted resources: %w", err)
+		}
+		return nil
+	}()
+	if err != nil {
+		return xerr.Join(cause, fmt.Errorf("failed to rollback: %w", err))
+	}
+
+	if len(previous) == 0 {
+		fmt.Fprintf(internal.Stderr(ctx), "rolled back %s: removed resources from failed takeoff\n", params.Release)
+		return fmt.Errorf("%w: rolled back by removing all applied resources", cause)
+	}
+
+	fmt.Fprintf(internal.Stderr(ctx), "rolled back %s to previous revision\n", params.Release)
+	return fmt.Errorf("%w: rolled back to previous revision", cause)
+}
+
+// foreignResources returns the canonical names, without version, of the resources that exist in the cluster
+// but are not owned by the release the resources are being deployed to.
+func (commander Commander) foreignResources(ctx context.Context, stages internal.Stages) (map[string]bool, error) {
+	foreign := map[string]bool{}
+	for _, resource := range stages.Flatten() {
+		state, err := commander.k8s.GetInClusterState(ctx, resource)
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				continue
+			}
+			return nil, fmt.Errorf("%s: %w", internal.Canonical(resource), err)
+		}
+		if internal.GetOwner(state) != internal.GetOwner(resource) {
+			foreign[internal.CanonicalWithoutVersion(resource)] = true
+		}
+	}
+	return foreign, nil
+}
+
+// maxRevisionErrorLength bounds the error message stored on failed revisions.
+// Errors are stored as annotations which are limited in size by the kubernetes API.
+const maxRevisionErrorLength = 4096
+
+// truncate cuts the value to at most size bytes without splitting a multi-byte rune.
+func truncate(value string, size int) string {
+	if len(value) <= size {
+		return value
+	}
+	for size > 0 && !utf8.RuneStart(value[size]) {
+		size--
+	}
+	return value[:size] + "..."
+}
+
 func ExportToFS(dir, release string, resources []*unstructured.Unstructured) error {
 	root := filepath.Join(dir, release)
 